@@ -28,6 +28,20 @@ func GetBoolEnv(key string, defaultValue bool) bool {
 	return boolValue
 }
 
+// GetIntEnv gets an integer environment variable or returns a default value.
+func GetIntEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return intValue
+}
+
 // GetDurationEnv gets a duration environment variable or returns a default value.
 // Expects value like "30s", "5m", "1h".
 func GetDurationEnv(key string, defaultValue time.Duration) time.Duration {
@@ -0,0 +1,399 @@
+// Package client is the Go SDK for the order service.
+//
+// A Client talks to the service over gRPC (NewGRPC) or the REST API served
+// under /v1 (NewHTTP) and exposes both through OrderService, which mirrors
+// the methods of the service's OrderService. Every call gets a deadline,
+// calls that fail because the server is unavailable are retried with
+// exponential backoff, and errors unwrap to the same sentinel errors the
+// service returns, re-exported here along with the types of OrderService:
+//
+//	conn, err := grpc.NewClient("localhost:9090", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	...
+//	orders := client.NewGRPC(conn)
+//	order, err := orders.GetOrder(ctx, id)
+//	if errors.Is(err, client.ErrNotFound) {
+//		...
+//	}
+//
+// Package clienttest runs the service in-process for tests.
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"lab10/internal/idgen"
+	"lab10/internal/service"
+	pb "lab10/proto/orders"
+)
+
+// OrderService is the order service as seen by clients. Methods behave like
+// their service.OrderService counterparts, which also implements it, so code
+// written against OrderService can run in-process or remotely.
+type OrderService interface {
+	CreateOrder(ctx context.Context, order *Order) error
+	GetOrder(ctx context.Context, id string) (*Order, error)
+	GetOrderIncludingDeleted(ctx context.Context, id string) (*Order, error)
+	ListOrders(ctx context.Context, opts ListOptions) (*ListResult, error)
+	UpdateOrder(ctx context.Context, id string, update OrderUpdate) (*Order, error)
+	UpdateOrderStatus(ctx context.Context, id string, update StatusUpdate) (*Order, error)
+	GetOrderHistory(ctx context.Context, id string) ([]StatusChange, error)
+	DeleteOrder(ctx context.Context, id string) error
+	RestoreOrder(ctx context.Context, id string) (*Order, error)
+	BatchCreateOrders(ctx context.Context, orders []*Order, atomic bool) ([]BatchResult, error)
+}
+
+var (
+	_ OrderService = (*Client)(nil)
+	_ OrderService = (*service.OrderService)(nil)
+)
+
+const (
+	// DefaultTimeout bounds each call, including its retries.
+	DefaultTimeout = 10 * time.Second
+
+	// idempotencyKeyMetadata makes retried CreateOrder calls safe.
+	idempotencyKeyMetadata = "idempotency-key"
+)
+
+// RetryPolicy controls how calls failing with codes.Unavailable are retried.
+// The n-th retry waits a random time between half and all of
+// min(InitialBackoff * Multiplier^(n-1), MaxBackoff).
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt; 1 disables retries.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+// DefaultRetryPolicy tries each call up to four times over roughly a second.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Multiplier:     2,
+}
+
+// orderRPC is the part of pb.OrderServiceClient the client uses. The REST
+// transport implements it too.
+type orderRPC interface {
+	CreateOrder(ctx context.Context, in *pb.CreateOrderRequest, opts ...grpc.CallOption) (*pb.CreateOrderResponse, error)
+	GetOrder(ctx context.Context, in *pb.GetOrderRequest, opts ...grpc.CallOption) (*pb.GetOrderResponse, error)
+	ListOrders(ctx context.Context, in *pb.ListOrdersRequest, opts ...grpc.CallOption) (*pb.ListOrdersResponse, error)
+	UpdateOrder(ctx context.Context, in *pb.UpdateOrderRequest, opts ...grpc.CallOption) (*pb.UpdateOrderResponse, error)
+	UpdateOrderStatus(ctx context.Context, in *pb.UpdateOrderStatusRequest, opts ...grpc.CallOption) (*pb.UpdateOrderStatusResponse, error)
+	GetOrderHistory(ctx context.Context, in *pb.GetOrderHistoryRequest, opts ...grpc.CallOption) (*pb.GetOrderHistoryResponse, error)
+	DeleteOrder(ctx context.Context, in *pb.DeleteOrderRequest, opts ...grpc.CallOption) (*pb.DeleteOrderResponse, error)
+	RestoreOrder(ctx context.Context, in *pb.RestoreOrderRequest, opts ...grpc.CallOption) (*pb.RestoreOrderResponse, error)
+	BatchCreateOrders(ctx context.Context, in *pb.BatchCreateOrdersRequest, opts ...grpc.CallOption) (*pb.BatchOrdersResponse, error)
+	WatchOrders(ctx context.Context, in *pb.WatchOrdersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.OrderEvent], error)
+}
+
+// Client is an OrderService backed by a remote server.
+// Safe for concurrent use.
+type Client struct {
+	rpc     orderRPC
+	timeout time.Duration
+	retry   RetryPolicy
+	keys    service.IDGenerator
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithTimeout sets the deadline of each call, retries included.
+// Zero leaves calls bounded only by their context.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// New creates a client calling the service through rpc.
+func New(rpc pb.OrderServiceClient, opts ...Option) *Client {
+	return newClient(rpc, opts...)
+}
+
+// NewGRPC creates a client calling the service's gRPC API over conn.
+func NewGRPC(conn grpc.ClientConnInterface, opts ...Option) *Client {
+	return newClient(pb.NewOrderServiceClient(conn), opts...)
+}
+
+// NewHTTP creates a client calling the service's REST API at baseURL
+// (e.g. "http://localhost:8080"). A nil httpClient uses http.DefaultClient.
+func NewHTTP(baseURL string, httpClient *http.Client, opts ...Option) *Client {
+	return newClient(newRESTClient(baseURL, httpClient), opts...)
+}
+
+func newClient(rpc orderRPC, opts ...Option) *Client {
+	c := &Client{
+		rpc:     rpc,
+		timeout: DefaultTimeout,
+		retry:   DefaultRetryPolicy,
+		keys:    idgen.NewUUIDv7(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CreateOrder creates an order and fills in the fields set by the server.
+// The call carries an idempotency key (a fresh one unless ctx already has
+// idempotency-key metadata), so retrying it can't create the order twice.
+func (c *Client) CreateOrder(ctx context.Context, order *Order) error {
+	if md, _ := metadata.FromOutgoingContext(ctx); len(md.Get(idempotencyKeyMetadata)) == 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, idempotencyKeyMetadata, c.keys.NewID())
+	}
+	req := createRequestToProto(order)
+	var resp *pb.CreateOrderResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		resp, err = c.rpc.CreateOrder(ctx, req)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	created, err := orderFromProto(resp.GetOrder())
+	if err != nil {
+		return err
+	}
+	*order = *created
+	return nil
+}
+
+// GetOrder retrieves an order by ID.
+func (c *Client) GetOrder(ctx context.Context, id string) (*Order, error) {
+	return c.getOrder(ctx, &pb.GetOrderRequest{Id: id})
+}
+
+// GetOrderIncludingDeleted retrieves an order by ID even if it is soft-deleted.
+func (c *Client) GetOrderIncludingDeleted(ctx context.Context, id string) (*Order, error) {
+	return c.getOrder(ctx, &pb.GetOrderRequest{Id: id, IncludeDeleted: true})
+}
+
+func (c *Client) getOrder(ctx context.Context, req *pb.GetOrderRequest) (*Order, error) {
+	var resp *pb.GetOrderResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		resp, err = c.rpc.GetOrder(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderFromProto(resp.GetOrder())
+}
+
+// ListOrders returns one page of orders.
+func (c *Client) ListOrders(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	req, err := listRequestToProto(opts)
+	if err != nil {
+		return nil, err
+	}
+	var resp *pb.ListOrdersResponse
+	err = c.call(ctx, func(ctx context.Context) (err error) {
+		resp, err = c.rpc.ListOrders(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ListResult{NextPageToken: resp.GetNextPageToken()}
+	for _, pbOrder := range resp.GetOrders() {
+		order, err := orderFromProto(pbOrder)
+		if err != nil {
+			return nil, err
+		}
+		result.Orders = append(result.Orders, order)
+	}
+	return result, nil
+}
+
+// UpdateOrder changes the non-nil fields of update on a pending order.
+func (c *Client) UpdateOrder(ctx context.Context, id string, update OrderUpdate) (*Order, error) {
+	req := updateRequestToProto(id, update)
+	var resp *pb.UpdateOrderResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		resp, err = c.rpc.UpdateOrder(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderFromProto(resp.GetOrder())
+}
+
+// UpdateOrderStatus changes an order's status and returns the order as read
+// right after the update.
+func (c *Client) UpdateOrderStatus(ctx context.Context, id string, update StatusUpdate) (*Order, error) {
+	pbStatus, err := statusToProto(update.Status)
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.UpdateOrderStatusRequest{
+		Id:              id,
+		Status:          pbStatus,
+		ExpectedVersion: update.ExpectedVersion,
+		Actor:           update.Actor,
+		Reason:          update.Reason,
+	}
+	err = c.call(ctx, func(ctx context.Context) error {
+		_, err := c.rpc.UpdateOrderStatus(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.GetOrder(ctx, id)
+}
+
+// GetOrderHistory returns the status changes of an order, oldest first.
+func (c *Client) GetOrderHistory(ctx context.Context, id string) ([]StatusChange, error) {
+	var resp *pb.GetOrderHistoryResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		resp, err = c.rpc.GetOrderHistory(ctx, &pb.GetOrderHistoryRequest{Id: id})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]StatusChange, 0, len(resp.GetEvents()))
+	for _, event := range resp.GetEvents() {
+		history = append(history, statusChangeFromProto(event))
+	}
+	return history, nil
+}
+
+// DeleteOrder soft-deletes an order.
+func (c *Client) DeleteOrder(ctx context.Context, id string) error {
+	return c.call(ctx, func(ctx context.Context) error {
+		_, err := c.rpc.DeleteOrder(ctx, &pb.DeleteOrderRequest{Id: id})
+		return err
+	})
+}
+
+// RestoreOrder undoes a soft delete and returns the restored order.
+func (c *Client) RestoreOrder(ctx context.Context, id string) (*Order, error) {
+	var resp *pb.RestoreOrderResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		resp, err = c.rpc.RestoreOrder(ctx, &pb.RestoreOrderRequest{Id: id})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderFromProto(resp.GetOrder())
+}
+
+// BatchCreateOrders creates up to 1000 orders in one call and returns a
+// result per order, in order. Failed items carry an *Error; in atomic mode
+// either every order is created or none is.
+func (c *Client) BatchCreateOrders(ctx context.Context, orders []*Order, atomic bool) ([]BatchResult, error) {
+	req := &pb.BatchCreateOrdersRequest{Atomic: atomic}
+	for _, order := range orders {
+		req.Orders = append(req.Orders, createRequestToProto(order))
+	}
+
+	var resp *pb.BatchOrdersResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		resp, err = c.rpc.BatchCreateOrders(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, 0, len(resp.GetResults()))
+	for _, item := range resp.GetResults() {
+		if code := codes.Code(item.GetErrorCode()); code != codes.OK {
+			results = append(results, BatchResult{Err: itemError(code, item.GetErrorMessage(), item.GetErrorReason())})
+			continue
+		}
+		order, err := orderFromProto(item.GetOrder())
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, BatchResult{Order: order})
+	}
+	return results, nil
+}
+
+// Watch streams the order events matching filter to handle until ctx ends,
+// handle returns an error, or the server ends the stream, and returns why
+// it stopped. Only the gRPC API supports it. Watch is not bound by the
+// client's timeout, and a broken stream is not retried: re-read the state
+// you care about and watch again.
+func (c *Client) Watch(ctx context.Context, filter WatchFilter, handle func(Event) error) error {
+	stream, err := c.rpc.WatchOrders(ctx, &pb.WatchOrdersRequest{CustomerId: filter.CustomerID, OrderId: filter.OrderID})
+	if err != nil {
+		return fromStatus(err)
+	}
+	for {
+		pbEvent, err := stream.Recv()
+		if err != nil {
+			return fromStatus(err)
+		}
+		event, err := eventFromProto(pbEvent)
+		if err != nil {
+			return err
+		}
+		if err := handle(event); err != nil {
+			return err
+		}
+	}
+}
+
+// call runs attempt under the client's deadline, retrying it while it fails
+// with codes.Unavailable, and converts the final error to an *Error.
+func (c *Client) call(ctx context.Context, attempt func(ctx context.Context) error) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	backoff := c.retry.InitialBackoff
+	for n := 1; ; n++ {
+		err := attempt(ctx)
+		if err == nil {
+			return nil
+		}
+		if !retryable(err) || n >= c.retry.MaxAttempts {
+			return fromStatus(err)
+		}
+
+		timer := time.NewTimer(jitter(backoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fromStatus(err)
+		case <-timer.C:
+		}
+		backoff = min(time.Duration(float64(backoff)*c.retry.Multiplier), c.retry.MaxBackoff)
+	}
+}
+
+// jitter returns a random duration between d/2 and d, so clients that failed
+// together don't all retry at the same moment.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
@@ -0,0 +1,236 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"lab10/client"
+	"lab10/client/clienttest"
+	pb "lab10/proto/orders"
+)
+
+func newOrder() *client.Order {
+	return &client.Order{
+		CustomerID: "CUST-001",
+		Items:      []client.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 2, UnitPrice: client.MustMoney("12.34", "EUR")}},
+	}
+}
+
+// TestClient runs an order through its lifecycle over both transports.
+func TestClient(t *testing.T) {
+	srv := clienttest.NewServer(t)
+	clients := map[string]*client.Client{
+		"grpc": srv.GRPCClient(),
+		"http": srv.HTTPClient(),
+	}
+
+	for name, orders := range clients {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			order := newOrder()
+			if err := orders.CreateOrder(ctx, order); err != nil {
+				t.Fatalf("CreateOrder() error = %v", err)
+			}
+			if order.ID == "" || order.Status != client.StatusPending || order.TotalAmount != client.MustMoney("24.68", "EUR") {
+				t.Fatalf("CreateOrder() = %+v, want a pending order of 24.68 EUR", order)
+			}
+
+			got, err := orders.GetOrder(ctx, order.ID)
+			if err != nil {
+				t.Fatalf("GetOrder() error = %v", err)
+			}
+			if got.CreatedAt.IsZero() || got.Items[0].UnitPrice != order.Items[0].UnitPrice {
+				t.Errorf("GetOrder() = %+v, want created_at and items of the created order", got)
+			}
+
+			region := "DE"
+			updated, err := orders.UpdateOrder(ctx, order.ID, client.OrderUpdate{Region: &region, ExpectedVersion: got.Version})
+			if err != nil {
+				t.Fatalf("UpdateOrder() error = %v", err)
+			}
+			if updated.Region != region || updated.CustomerID != order.CustomerID {
+				t.Errorf("UpdateOrder() = %+v, want region %s and the customer unchanged", updated, region)
+			}
+
+			confirmed, err := orders.UpdateOrderStatus(ctx, order.ID, client.StatusUpdate{Status: client.StatusConfirmed, Actor: "test"})
+			if err != nil {
+				t.Fatalf("UpdateOrderStatus() error = %v", err)
+			}
+			if confirmed.Status != client.StatusConfirmed {
+				t.Errorf("UpdateOrderStatus() status = %s, want %s", confirmed.Status, client.StatusConfirmed)
+			}
+			history, err := orders.GetOrderHistory(ctx, order.ID)
+			if err != nil || len(history) != 1 || history[0].Actor != "test" {
+				t.Errorf("GetOrderHistory() = %+v, %v; want the confirmation by test", history, err)
+			}
+
+			page, err := orders.ListOrders(ctx, client.ListOptions{Filter: client.ListFilter{Status: client.StatusConfirmed, CustomerID: "CUST-001"}})
+			if err != nil {
+				t.Fatalf("ListOrders() error = %v", err)
+			}
+			found := false
+			for _, o := range page.Orders {
+				found = found || o.ID == order.ID
+			}
+			if !found {
+				t.Errorf("ListOrders() = %d orders without %s", len(page.Orders), order.ID)
+			}
+
+			if err := orders.DeleteOrder(ctx, order.ID); err != nil {
+				t.Fatalf("DeleteOrder() error = %v", err)
+			}
+			if deleted, err := orders.GetOrderIncludingDeleted(ctx, order.ID); err != nil || !deleted.IsDeleted() {
+				t.Errorf("GetOrderIncludingDeleted() = %+v, %v; want the deleted order", deleted, err)
+			}
+			if _, err := orders.RestoreOrder(ctx, order.ID); err != nil {
+				t.Fatalf("RestoreOrder() error = %v", err)
+			}
+		})
+	}
+}
+
+// TestClientErrors checks errors unwrap to the sentinels the service
+// returns, also for the items of a batch.
+func TestClientErrors(t *testing.T) {
+	srv := clienttest.NewServer(t)
+	ctx := context.Background()
+	order := newOrder()
+	if err := srv.Service.CreateOrder(ctx, order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	for name, orders := range map[string]*client.Client{"grpc": srv.GRPCClient(), "http": srv.HTTPClient()} {
+		t.Run(name, func(t *testing.T) {
+			_, err := orders.GetOrder(ctx, "missing")
+			if !errors.Is(err, client.ErrNotFound) {
+				t.Errorf("GetOrder() of missing order error = %v, want %v", err, client.ErrNotFound)
+			}
+
+			_, err = orders.UpdateOrderStatus(ctx, order.ID, client.StatusUpdate{Status: client.StatusDelivered})
+			if !errors.Is(err, client.ErrInvalidStatusTransition) {
+				t.Errorf("UpdateOrderStatus() error = %v, want %v", err, client.ErrInvalidStatusTransition)
+			}
+
+			_, err = orders.UpdateOrderStatus(ctx, order.ID, client.StatusUpdate{Status: client.StatusCancelled, ExpectedVersion: 99})
+			if !errors.Is(err, client.ErrVersionConflict) {
+				t.Errorf("UpdateOrderStatus() with stale version error = %v, want %v", err, client.ErrVersionConflict)
+			}
+
+			err = orders.CreateOrder(ctx, &client.Order{Items: []client.LineItem{{ProductID: "SKU-1", UnitPrice: client.MustMoney("1.00", "USD")}}})
+			var verr *client.ValidationError
+			if !errors.Is(err, client.ErrInvalidOrder) || !errors.As(err, &verr) || len(verr.Violations) < 2 {
+				t.Errorf("CreateOrder() of invalid order error = %v, want %v with field violations", err, client.ErrInvalidOrder)
+			}
+			var clientErr *client.Error
+			if !errors.As(err, &clientErr) || clientErr.Code != codes.InvalidArgument {
+				t.Errorf("CreateOrder() of invalid order error = %v, want a *client.Error with code %s", err, codes.InvalidArgument)
+			}
+
+			results, err := orders.BatchCreateOrders(ctx, []*client.Order{newOrder(), {}}, true)
+			if err != nil {
+				t.Fatalf("BatchCreateOrders() error = %v", err)
+			}
+			if !errors.Is(results[0].Err, client.ErrBatchAborted) || !errors.Is(results[1].Err, client.ErrInvalidOrder) {
+				t.Errorf("BatchCreateOrders() errors = %v, %v; want %v, %v", results[0].Err, results[1].Err, client.ErrBatchAborted, client.ErrInvalidOrder)
+			}
+		})
+	}
+}
+
+// flakyRPC fails GetOrder with Unavailable until its failures run out.
+type flakyRPC struct {
+	pb.OrderServiceClient
+	failures int
+	calls    int
+}
+
+func (f *flakyRPC) GetOrder(ctx context.Context, in *pb.GetOrderRequest, _ ...grpc.CallOption) (*pb.GetOrderResponse, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, status.Error(codes.Unavailable, "connection refused")
+	}
+	return &pb.GetOrderResponse{Order: &pb.Order{Id: in.GetId()}}, nil
+}
+
+// failingRPC fails GetOrder with err.
+type failingRPC struct {
+	pb.OrderServiceClient
+	err error
+}
+
+func (f *failingRPC) GetOrder(context.Context, *pb.GetOrderRequest, ...grpc.CallOption) (*pb.GetOrderResponse, error) {
+	return nil, f.err
+}
+
+// TestClientErrorReasons checks errors are told apart by the reason of
+// their ErrorInfo detail, not by their message.
+func TestClientErrorReasons(t *testing.T) {
+	withReason := func(code codes.Code, msg, reason string) error {
+		st, err := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: "orders.lab10"})
+		if err != nil {
+			t.Fatalf("WithDetails() error = %v", err)
+		}
+		return st.Err()
+	}
+	tests := []struct {
+		name    string
+		err     error
+		want    error
+		notWant error
+	}{
+		{"reason", withReason(codes.FailedPrecondition, "out of widgets", "INSUFFICIENT_STOCK"), client.ErrInsufficientStock, nil},
+		{"message alone", status.Error(codes.FailedPrecondition, client.ErrInsufficientStock.Error()), nil, client.ErrInsufficientStock},
+		{"other domain", func() error {
+			st, _ := status.New(codes.NotFound, "gone").WithDetails(&errdetails.ErrorInfo{Reason: "ORDER_NOT_FOUND", Domain: "example.com"})
+			return st.Err()
+		}(), nil, client.ErrNotFound},
+		{"unknown reason", withReason(codes.NotFound, "gone", "SOMETHING_NEW"), nil, client.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.New(&failingRPC{err: tt.err}).GetOrder(context.Background(), "ORD-1")
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("GetOrder() error = %v, want %v", err, tt.want)
+			}
+			if tt.notWant != nil && errors.Is(err, tt.notWant) {
+				t.Errorf("GetOrder() error = %v, must not be %v", err, tt.notWant)
+			}
+			if status.Code(err) != status.Code(tt.err) {
+				t.Errorf("GetOrder() code = %s, want %s", status.Code(err), status.Code(tt.err))
+			}
+		})
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	policy := client.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 2}
+	ctx := context.Background()
+
+	rpc := &flakyRPC{failures: 2}
+	if _, err := client.New(rpc, client.WithRetryPolicy(policy)).GetOrder(ctx, "ORD-1"); err != nil || rpc.calls != 3 {
+		t.Errorf("GetOrder() = %v after %d calls, want success on the 3rd", err, rpc.calls)
+	}
+
+	rpc = &flakyRPC{failures: 5}
+	_, err := client.New(rpc, client.WithRetryPolicy(policy)).GetOrder(ctx, "ORD-1")
+	if status.Code(err) != codes.Unavailable || rpc.calls != 3 {
+		t.Errorf("GetOrder() = %v after %d calls, want Unavailable after 3", err, rpc.calls)
+	}
+
+	// The deadline stops retries that would outlast it
+	rpc = &flakyRPC{failures: 100}
+	slow := client.RetryPolicy{MaxAttempts: 100, InitialBackoff: time.Second, MaxBackoff: time.Second, Multiplier: 1}
+	start := time.Now()
+	_, err = client.New(rpc, client.WithRetryPolicy(slow), client.WithTimeout(50*time.Millisecond)).GetOrder(ctx, "ORD-1")
+	if status.Code(err) != codes.Unavailable || time.Since(start) > time.Second/2 {
+		t.Errorf("GetOrder() = %v after %v, want Unavailable within the 50ms deadline", err, time.Since(start))
+	}
+}
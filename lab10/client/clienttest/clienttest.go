@@ -0,0 +1,103 @@
+// Package clienttest runs the order service in-process for tests of code
+// that uses package client: the gRPC API over an in-memory bufconn listener
+// and the REST API on an httptest server, both backed by one in-memory
+// service.
+//
+//	srv := clienttest.NewServer(t)
+//	orders := srv.GRPCClient()
+package clienttest
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"lab10/client"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+	"lab10/internal/transport/gateway"
+	grpcTransport "lab10/internal/transport/grpc"
+	pb "lab10/proto/orders"
+)
+
+// bufSize is the capacity of the in-memory connection buffers.
+const bufSize = 1 << 20
+
+// Server is an in-process order service. Everything it starts is stopped
+// when the test ends.
+type Server struct {
+	// Service is the service behind both APIs, for setting up and checking
+	// state without going through a client.
+	Service *service.OrderService
+
+	conn *grpc.ClientConn
+	rest *httptest.Server
+}
+
+// NewServer starts a server backed by an in-memory repository.
+// opts configure the order service.
+func NewServer(t testing.TB, opts ...service.Option) *Server {
+	t.Helper()
+	repo := repository.NewMemoryRepository()
+	svc := service.NewOrderService(repo, opts...)
+	orders := grpcTransport.NewOrderServer(svc)
+	customers := grpcTransport.NewCustomerServer(service.NewCustomerService(repo, svc))
+	products := grpcTransport.NewProductServer(service.NewCatalogService(repo))
+
+	listener := bufconn.Listen(bufSize)
+	grpcServer := grpc.NewServer()
+	pb.RegisterOrderServiceServer(grpcServer, orders)
+	pb.RegisterCustomerServiceServer(grpcServer, customers)
+	pb.RegisterProductServiceServer(grpcServer, products)
+	go grpcServer.Serve(listener)
+	t.Cleanup(func() {
+		orders.Shutdown()
+		grpcServer.Stop()
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("clienttest: dialing bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	handler, err := gateway.NewHandler(context.Background(), orders, customers, products)
+	if err != nil {
+		t.Fatalf("clienttest: creating REST gateway: %v", err)
+	}
+	rest := httptest.NewServer(handler)
+	t.Cleanup(rest.Close)
+
+	return &Server{Service: svc, conn: conn, rest: rest}
+}
+
+// Conn returns the gRPC connection to the server, for calling it with
+// generated stubs directly.
+func (s *Server) Conn() *grpc.ClientConn {
+	return s.conn
+}
+
+// URL returns the base URL of the REST API.
+func (s *Server) URL() string {
+	return s.rest.URL
+}
+
+// GRPCClient returns a client calling the server over gRPC.
+func (s *Server) GRPCClient(opts ...client.Option) *client.Client {
+	return client.NewGRPC(s.conn, opts...)
+}
+
+// HTTPClient returns a client calling the server's REST API.
+func (s *Server) HTTPClient(opts ...client.Option) *client.Client {
+	return client.NewHTTP(s.rest.URL, s.rest.Client(), opts...)
+}
@@ -0,0 +1,315 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+	pb "lab10/proto/orders"
+)
+
+// orderFromProto converts a protobuf Order to a domain Order.
+// Orders from older servers without the Timestamp fields fall back to the
+// deprecated Unix-second ones.
+func orderFromProto(o *pb.Order) (*domain.Order, error) {
+	if o == nil {
+		return nil, fmt.Errorf("client: response has no order")
+	}
+	items, err := lineItemsFromProto(o.GetItems())
+	if err != nil {
+		return nil, err
+	}
+	total, err := moneyFromProto(o.GetTotal())
+	if err != nil {
+		return nil, fmt.Errorf("client: order total: %w", err)
+	}
+	pricing, err := priceBreakdownFromProto(o.GetPricing())
+	if err != nil {
+		return nil, err
+	}
+
+	order := &domain.Order{
+		ID:          o.GetId(),
+		CustomerID:  o.GetCustomerId(),
+		Items:       items,
+		Status:      statusFromProto(o.GetStatus()),
+		TotalAmount: total,
+		CreatedAt:   timeFromProto(o.GetCreateTime(), o.GetCreatedAt()),
+		UpdatedAt:   timeFromProto(o.GetUpdateTime(), o.GetUpdatedAt()),
+		Version:     o.GetVersion(),
+		CouponCode:  o.GetCouponCode(),
+		Region:      o.GetRegion(),
+		Pricing:     pricing,
+	}
+	if o.GetDeleteTime() != nil || o.GetDeletedAt() != 0 {
+		deletedAt := timeFromProto(o.GetDeleteTime(), o.GetDeletedAt())
+		order.DeletedAt = &deletedAt
+	}
+	return order, nil
+}
+
+// createRequestToProto converts a new order to a CreateOrderRequest.
+func createRequestToProto(order *domain.Order) *pb.CreateOrderRequest {
+	return &pb.CreateOrderRequest{
+		Id:         order.ID,
+		CustomerId: order.CustomerID,
+		Items:      lineItemsToProto(order.Items),
+		CouponCode: order.CouponCode,
+		Region:     order.Region,
+	}
+}
+
+// eventTypes maps protobuf event types to domain ones.
+var eventTypes = map[pb.OrderEventType]domain.EventType{
+	pb.OrderEventType_ORDER_CREATED:        domain.EventOrderCreated,
+	pb.OrderEventType_ORDER_UPDATED:        domain.EventOrderUpdated,
+	pb.OrderEventType_ORDER_STATUS_CHANGED: domain.EventOrderStatusChanged,
+	pb.OrderEventType_ORDER_DELETED:        domain.EventOrderDeleted,
+	pb.OrderEventType_ORDER_RESTORED:       domain.EventOrderRestored,
+	pb.OrderEventType_ORDER_PURGED:         domain.EventOrderPurged,
+}
+
+// eventFromProto converts a protobuf OrderEvent to a domain Event.
+func eventFromProto(e *pb.OrderEvent) (domain.Event, error) {
+	event := domain.Event{
+		ID:         e.GetId(),
+		Type:       eventTypes[e.GetType()],
+		OrderID:    e.GetOrderId(),
+		OccurredAt: timeFromProto(e.GetEventTime(), e.GetOccurredAt()),
+	}
+	if e.GetOrder() != nil {
+		order, err := orderFromProto(e.GetOrder())
+		if err != nil {
+			return domain.Event{}, err
+		}
+		event.Order = order
+	}
+	if e.GetStatusChange() != nil {
+		change := statusChangeFromProto(e.GetStatusChange())
+		event.StatusChange = &change
+	}
+	return event, nil
+}
+
+// timeFromProto prefers ts and falls back to Unix seconds; both unset is the zero time.
+func timeFromProto(ts *timestamppb.Timestamp, unix int64) time.Time {
+	if ts != nil {
+		return ts.AsTime()
+	}
+	if unix != 0 {
+		return time.Unix(unix, 0).UTC()
+	}
+	return time.Time{}
+}
+
+// priceBreakdownFromProto converts a price breakdown; nil stays nil.
+func priceBreakdownFromProto(b *pb.PriceBreakdown) (*domain.PriceBreakdown, error) {
+	if b == nil {
+		return nil, nil
+	}
+	var breakdown domain.PriceBreakdown
+	var err error
+	for _, m := range []struct {
+		dst *domain.Money
+		src *pb.Money
+	}{
+		{&breakdown.Subtotal, b.GetSubtotal()},
+		{&breakdown.DiscountTotal, b.GetDiscountTotal()},
+		{&breakdown.Tax, b.GetTax()},
+		{&breakdown.Total, b.GetTotal()},
+	} {
+		if *m.dst, err = moneyFromProto(m.src); err != nil {
+			return nil, fmt.Errorf("client: pricing: %w", err)
+		}
+	}
+	if breakdown.Discounts, err = adjustmentsFromProto(b.GetDiscounts()); err != nil {
+		return nil, err
+	}
+	if breakdown.Taxes, err = adjustmentsFromProto(b.GetTaxes()); err != nil {
+		return nil, err
+	}
+	return &breakdown, nil
+}
+
+// adjustmentsFromProto converts protobuf PriceAdjustments to domain PriceAdjustments.
+func adjustmentsFromProto(adjustments []*pb.PriceAdjustment) ([]domain.PriceAdjustment, error) {
+	var result []domain.PriceAdjustment
+	for _, a := range adjustments {
+		amount, err := moneyFromProto(a.GetAmount())
+		if err != nil {
+			return nil, fmt.Errorf("client: pricing rule %s: %w", a.GetRule(), err)
+		}
+		result = append(result, domain.PriceAdjustment{Rule: a.GetRule(), Description: a.GetDescription(), Amount: amount})
+	}
+	return result, nil
+}
+
+// lineItemsFromProto converts protobuf LineItems to domain LineItems.
+func lineItemsFromProto(items []*pb.LineItem) ([]domain.LineItem, error) {
+	result := make([]domain.LineItem, 0, len(items))
+	for i, item := range items {
+		price, err := moneyFromProto(item.GetPrice())
+		if err != nil {
+			return nil, fmt.Errorf("client: item %d: %w", i, err)
+		}
+		result = append(result, domain.LineItem{
+			ProductID:   item.GetProductId(),
+			ProductName: item.GetProductName(),
+			Quantity:    int(item.GetQuantity()),
+			UnitPrice:   price,
+		})
+	}
+	return result, nil
+}
+
+// lineItemsToProto converts domain LineItems to protobuf LineItems.
+func lineItemsToProto(items []domain.LineItem) []*pb.LineItem {
+	result := make([]*pb.LineItem, 0, len(items))
+	for _, item := range items {
+		pbItem := &pb.LineItem{
+			ProductId:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    int32(item.Quantity),
+		}
+		// Without a price the server takes the one from its catalog.
+		if item.UnitPrice != (domain.Money{}) {
+			pbItem.Price = moneyToProto(item.UnitPrice)
+		}
+		result = append(result, pbItem)
+	}
+	return result
+}
+
+// moneyToProto converts domain Money (minor units) to protobuf Money (units + nanos).
+func moneyToProto(m domain.Money) *pb.Money {
+	exp, err := domain.CurrencyExponent(m.Currency)
+	if err != nil {
+		return &pb.Money{CurrencyCode: m.Currency, Units: m.Amount}
+	}
+	scale := pow10(exp)
+	return &pb.Money{
+		CurrencyCode: m.Currency,
+		Units:        m.Amount / scale,
+		Nanos:        int32(m.Amount % scale * pow10(9-exp)),
+	}
+}
+
+// moneyFromProto converts protobuf Money to domain Money. The server never
+// sends nanos finer than the currency's minor unit, so they are truncated.
+func moneyFromProto(m *pb.Money) (domain.Money, error) {
+	if m == nil {
+		return domain.Money{}, nil
+	}
+	exp, err := domain.CurrencyExponent(m.GetCurrencyCode())
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return domain.Money{
+		Amount:   m.GetUnits()*pow10(exp) + int64(m.GetNanos())/pow10(9-exp),
+		Currency: m.GetCurrencyCode(),
+	}, nil
+}
+
+// pow10 returns 10^n for small non-negative n.
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// statusToProto converts domain OrderStatus to protobuf OrderStatus.
+// Unknown statuses are rejected here, since PENDING is the enum's zero value
+// and any unknown name would otherwise turn into it.
+// The enum names are the upper-case domain statuses.
+func statusToProto(status domain.OrderStatus) (pb.OrderStatus, error) {
+	value, ok := pb.OrderStatus_value[strings.ToUpper(string(status))]
+	if !ok || strings.ToLower(string(status)) != string(status) {
+		return 0, fromStatus(grpcstatus.Errorf(codes.InvalidArgument, "unknown order status %q", status))
+	}
+	return pb.OrderStatus(value), nil
+}
+
+// statusFromProto converts protobuf OrderStatus to domain OrderStatus.
+func statusFromProto(status pb.OrderStatus) domain.OrderStatus {
+	return domain.OrderStatus(strings.ToLower(status.String()))
+}
+
+// statusChangeFromProto converts a protobuf StatusChange to domain.
+func statusChangeFromProto(change *pb.StatusChange) domain.StatusChange {
+	return domain.StatusChange{
+		FromStatus: statusFromProto(change.GetFromStatus()),
+		ToStatus:   statusFromProto(change.GetToStatus()),
+		Actor:      change.GetActor(),
+		Reason:     change.GetReason(),
+		Timestamp:  timeFromProto(change.GetChangeTime(), change.GetTimestamp()),
+	}
+}
+
+// listRequestToProto converts list options to a ListOrdersRequest.
+func listRequestToProto(opts repository.ListOptions) (*pb.ListOrdersRequest, error) {
+	req := &pb.ListOrdersRequest{
+		PageSize:       int32(opts.PageSize),
+		PageToken:      opts.PageToken,
+		CustomerId:     opts.Filter.CustomerID,
+		SortBy:         string(opts.SortBy),
+		Descending:     opts.Descending,
+		IncludeDeleted: opts.Filter.IncludeDeleted,
+	}
+	if opts.Filter.Status != "" {
+		status, err := statusToProto(opts.Filter.Status)
+		if err != nil {
+			return nil, err
+		}
+		req.Status = &status
+	}
+	if !opts.Filter.CreatedAfter.IsZero() {
+		req.CreateTimeAfter = timestamppb.New(opts.Filter.CreatedAfter)
+	}
+	if !opts.Filter.CreatedBefore.IsZero() {
+		req.CreateTimeBefore = timestamppb.New(opts.Filter.CreatedBefore)
+	}
+	if opts.Filter.MinTotal != nil {
+		req.MinTotal = moneyToProto(*opts.Filter.MinTotal)
+	}
+	if opts.Filter.MaxTotal != nil {
+		req.MaxTotal = moneyToProto(*opts.Filter.MaxTotal)
+	}
+	return req, nil
+}
+
+// updateRequestToProto converts an OrderUpdate to an UpdateOrderRequest whose
+// mask names exactly the fields being changed.
+func updateRequestToProto(id string, update service.OrderUpdate) *pb.UpdateOrderRequest {
+	req := &pb.UpdateOrderRequest{
+		Order:           &pb.Order{Id: id},
+		UpdateMask:      &fieldmaskpb.FieldMask{},
+		ExpectedVersion: update.ExpectedVersion,
+	}
+	if update.CustomerID != nil {
+		req.Order.CustomerId = *update.CustomerID
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "customer_id")
+	}
+	if update.Items != nil {
+		req.Order.Items = lineItemsToProto(*update.Items)
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "items")
+	}
+	if update.CouponCode != nil {
+		req.Order.CouponCode = *update.CouponCode
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "coupon_code")
+	}
+	if update.Region != nil {
+		req.Order.Region = *update.Region
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "region")
+	}
+	return req
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+	pb "lab10/proto/orders"
+)
+
+// The errors calls fail with, the same ones the service returns in-process.
+var (
+	ErrNotFound                 = repository.ErrNotFound
+	ErrAlreadyExists            = repository.ErrAlreadyExists
+	ErrVersionConflict          = repository.ErrVersionConflict
+	ErrNotDeleted               = repository.ErrNotDeleted
+	ErrInvalidOrder             = service.ErrInvalidOrder
+	ErrInvalidStatusTransition  = service.ErrInvalidStatusTransition
+	ErrOrderNotEditable         = service.ErrOrderNotEditable
+	ErrInsufficientStock        = service.ErrInsufficientStock
+	ErrInvalidIdempotencyKey    = service.ErrInvalidIdempotencyKey
+	ErrIdempotencyKeyReused     = service.ErrIdempotencyKeyReused
+	ErrIdempotencyKeyInProgress = service.ErrIdempotencyKeyInProgress
+	ErrInvalidBatch             = service.ErrInvalidBatch
+	ErrBatchAborted             = service.ErrBatchAborted
+	ErrWatchUnavailable         = service.ErrWatchUnavailable
+	ErrInvalidListOptions       = service.ErrInvalidListOptions
+	ErrPermissionDenied         = service.ErrPermissionDenied
+)
+
+// Error is a call the server rejected. It unwraps to the service or
+// repository error the server reported, so callers check errors the same
+// way as against an in-process OrderService:
+//
+//	if errors.Is(err, client.ErrNotFound) { ... }
+//
+// Validation failures also unwrap to a *ValidationError listing the
+// offending fields.
+type Error struct {
+	Code    codes.Code
+	Message string
+
+	errs []error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return "client: " + e.Code.String() + ": " + e.Message
+}
+
+// Unwrap returns the service error and, for validation failures, the
+// *ValidationError.
+func (e *Error) Unwrap() []error {
+	return e.errs
+}
+
+// GRPCStatus returns the status the server replied with.
+func (e *Error) GRPCStatus() *status.Status {
+	return status.New(e.Code, e.Message)
+}
+
+// reasons maps the ErrorReason the server reports in the ErrorInfo detail of
+// an error to the service error behind it.
+var reasons = map[pb.ErrorReason]error{
+	pb.ErrorReason_ORDER_NOT_FOUND:             ErrNotFound,
+	pb.ErrorReason_ORDER_ALREADY_EXISTS:        ErrAlreadyExists,
+	pb.ErrorReason_VERSION_CONFLICT:            ErrVersionConflict,
+	pb.ErrorReason_ORDER_NOT_DELETED:           ErrNotDeleted,
+	pb.ErrorReason_INVALID_ORDER:               ErrInvalidOrder,
+	pb.ErrorReason_INVALID_STATUS_TRANSITION:   ErrInvalidStatusTransition,
+	pb.ErrorReason_ORDER_NOT_EDITABLE:          ErrOrderNotEditable,
+	pb.ErrorReason_INSUFFICIENT_STOCK:          ErrInsufficientStock,
+	pb.ErrorReason_INVALID_IDEMPOTENCY_KEY:     ErrInvalidIdempotencyKey,
+	pb.ErrorReason_IDEMPOTENCY_KEY_REUSED:      ErrIdempotencyKeyReused,
+	pb.ErrorReason_IDEMPOTENCY_KEY_IN_PROGRESS: ErrIdempotencyKeyInProgress,
+	pb.ErrorReason_INVALID_BATCH:               ErrInvalidBatch,
+	pb.ErrorReason_BATCH_ABORTED:               ErrBatchAborted,
+	pb.ErrorReason_WATCH_UNAVAILABLE:           ErrWatchUnavailable,
+	pb.ErrorReason_INVALID_LIST_OPTIONS:        ErrInvalidListOptions,
+	pb.ErrorReason_PERMISSION_DENIED:           ErrPermissionDenied,
+}
+
+// errorDomain is the domain of the ErrorInfo details the server attaches.
+const errorDomain = "orders.lab10"
+
+// fromStatus converts a failed call's error to an *Error. Errors that
+// aren't gRPC statuses (e.g. a broken response) are returned unchanged.
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.OK {
+		return err
+	}
+
+	e := &Error{Code: st.Code(), Message: st.Message()}
+	if sentinel, ok := reasons[errorReason(st)]; ok {
+		e.errs = append(e.errs, sentinel)
+	}
+	if verr := violations(st); verr != nil {
+		if len(e.errs) == 0 {
+			e.errs = append(e.errs, ErrInvalidOrder)
+		}
+		e.errs = append(e.errs, verr)
+	}
+	return e
+}
+
+// errorReason returns the reason of the ErrorInfo detail of st, or
+// ERROR_REASON_UNSPECIFIED if it has none.
+func errorReason(st *status.Status) pb.ErrorReason {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == errorDomain {
+			return pb.ErrorReason(pb.ErrorReason_value[info.GetReason()])
+		}
+	}
+	return pb.ErrorReason_ERROR_REASON_UNSPECIFIED
+}
+
+// itemError converts the failure of a batch item, which carries its reason
+// in a field rather than in a status detail.
+func itemError(code codes.Code, message, reason string) error {
+	st := status.New(code, message)
+	if reason != "" {
+		if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}); err == nil {
+			st = detailed
+		}
+	}
+	return fromStatus(st.Err())
+}
+
+// violations collects the field violations of google.rpc.BadRequest details.
+func violations(st *status.Status) *domain.ValidationError {
+	verr := &domain.ValidationError{}
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range badRequest.GetFieldViolations() {
+				verr.Add(v.GetField(), v.GetDescription())
+			}
+		}
+	}
+	if len(verr.Violations) == 0 {
+		return nil
+	}
+	return verr
+}
+
+// retryable reports whether a failed call may be tried again: the server
+// was unreachable or shutting down, so the request wasn't processed.
+func retryable(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "lab10/proto/orders"
+)
+
+// restClient calls the REST API generated from the google.api.http
+// annotations in orders.proto. Outgoing gRPC metadata is sent as headers,
+// and failures come back as the same gRPC statuses the gateway encodes in
+// its error bodies, so Client handles both transports alike.
+type restClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func newRESTClient(baseURL string, httpClient *http.Client) *restClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &restClient{baseURL: strings.TrimSuffix(baseURL, "/"), http: httpClient}
+}
+
+var (
+	marshalOptions   = protojson.MarshalOptions{UseProtoNames: true}
+	unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+func (r *restClient) CreateOrder(ctx context.Context, in *pb.CreateOrderRequest, _ ...grpc.CallOption) (*pb.CreateOrderResponse, error) {
+	resp := &pb.CreateOrderResponse{}
+	return resp, r.do(ctx, http.MethodPost, "/v1/orders", nil, in, resp)
+}
+
+func (r *restClient) GetOrder(ctx context.Context, in *pb.GetOrderRequest, _ ...grpc.CallOption) (*pb.GetOrderResponse, error) {
+	query := url.Values{}
+	if in.GetIncludeDeleted() {
+		query.Set("include_deleted", "true")
+	}
+	resp := &pb.GetOrderResponse{}
+	return resp, r.do(ctx, http.MethodGet, "/v1/orders/"+url.PathEscape(in.GetId()), query, nil, resp)
+}
+
+func (r *restClient) ListOrders(ctx context.Context, in *pb.ListOrdersRequest, _ ...grpc.CallOption) (*pb.ListOrdersResponse, error) {
+	resp := &pb.ListOrdersResponse{}
+	return resp, r.do(ctx, http.MethodGet, "/v1/orders", listQuery(in), nil, resp)
+}
+
+func (r *restClient) UpdateOrder(ctx context.Context, in *pb.UpdateOrderRequest, _ ...grpc.CallOption) (*pb.UpdateOrderResponse, error) {
+	query := url.Values{}
+	if paths := in.GetUpdateMask().GetPaths(); len(paths) > 0 {
+		query.Set("update_mask", strings.Join(paths, ","))
+	}
+	if in.GetExpectedVersion() != 0 {
+		query.Set("expected_version", strconv.FormatInt(in.GetExpectedVersion(), 10))
+	}
+	resp := &pb.UpdateOrderResponse{}
+	return resp, r.do(ctx, http.MethodPatch, "/v1/orders/"+url.PathEscape(in.GetOrder().GetId()), query, in.GetOrder(), resp)
+}
+
+func (r *restClient) UpdateOrderStatus(ctx context.Context, in *pb.UpdateOrderStatusRequest, _ ...grpc.CallOption) (*pb.UpdateOrderStatusResponse, error) {
+	resp := &pb.UpdateOrderStatusResponse{}
+	return resp, r.do(ctx, http.MethodPatch, "/v1/orders/"+url.PathEscape(in.GetId())+"/status", nil, in, resp)
+}
+
+func (r *restClient) GetOrderHistory(ctx context.Context, in *pb.GetOrderHistoryRequest, _ ...grpc.CallOption) (*pb.GetOrderHistoryResponse, error) {
+	resp := &pb.GetOrderHistoryResponse{}
+	return resp, r.do(ctx, http.MethodGet, "/v1/orders/"+url.PathEscape(in.GetId())+"/history", nil, nil, resp)
+}
+
+func (r *restClient) DeleteOrder(ctx context.Context, in *pb.DeleteOrderRequest, _ ...grpc.CallOption) (*pb.DeleteOrderResponse, error) {
+	resp := &pb.DeleteOrderResponse{}
+	return resp, r.do(ctx, http.MethodDelete, "/v1/orders/"+url.PathEscape(in.GetId()), nil, nil, resp)
+}
+
+func (r *restClient) RestoreOrder(ctx context.Context, in *pb.RestoreOrderRequest, _ ...grpc.CallOption) (*pb.RestoreOrderResponse, error) {
+	resp := &pb.RestoreOrderResponse{}
+	return resp, r.do(ctx, http.MethodPost, "/v1/orders/"+url.PathEscape(in.GetId())+"/restore", nil, &pb.RestoreOrderRequest{}, resp)
+}
+
+func (r *restClient) BatchCreateOrders(ctx context.Context, in *pb.BatchCreateOrdersRequest, _ ...grpc.CallOption) (*pb.BatchOrdersResponse, error) {
+	resp := &pb.BatchOrdersResponse{}
+	return resp, r.do(ctx, http.MethodPost, "/v1/orders:batchCreate", nil, in, resp)
+}
+
+// WatchOrders is a server stream, which the REST API doesn't offer.
+func (r *restClient) WatchOrders(context.Context, *pb.WatchOrdersRequest, ...grpc.CallOption) (grpc.ServerStreamingClient[pb.OrderEvent], error) {
+	return nil, status.Error(codes.Unimplemented, "watching orders is only available over gRPC")
+}
+
+// do sends one request and decodes the response into resp. Network failures
+// are reported as codes.Unavailable so they are retried like gRPC ones.
+func (r *restClient) do(ctx context.Context, method, path string, query url.Values, body, resp proto.Message) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := marshalOptions.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("client: encoding request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	target := r.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("client: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for key, values := range md {
+		for _, value := range values {
+			req.Header.Add(textproto.CanonicalMIMEHeaderKey(key), value)
+		}
+	}
+
+	httpResp, err := r.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer httpResp.Body.Close()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return decodeError(httpResp.StatusCode, data)
+	}
+	if err := unmarshalOptions.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("client: decoding response: %w", err)
+	}
+	return nil
+}
+
+// decodeError turns an error response into a gRPC status error. Bodies that
+// aren't a google.rpc.Status (e.g. from a proxy) are mapped by HTTP status.
+func decodeError(statusCode int, data []byte) error {
+	var st spb.Status
+	if err := unmarshalOptions.Unmarshal(data, &st); err == nil && st.GetCode() != 0 {
+		return status.ErrorProto(&st)
+	}
+
+	code := codes.Unknown
+	switch statusCode {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		code = codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		code = codes.Unavailable
+	}
+	return status.Errorf(code, "HTTP %d: %s", statusCode, strings.TrimSpace(string(data)))
+}
+
+// listQuery encodes a ListOrdersRequest as query parameters.
+func listQuery(in *pb.ListOrdersRequest) url.Values {
+	query := url.Values{}
+	setNonZero := func(key string, value int64) {
+		if value != 0 {
+			query.Set(key, strconv.FormatInt(value, 10))
+		}
+	}
+	setNonZero("page_size", int64(in.GetPageSize()))
+	setNonZero("created_after", in.GetCreatedAfter())
+	setNonZero("created_before", in.GetCreatedBefore())
+	for key, ts := range map[string]*timestamppb.Timestamp{"create_time_after": in.GetCreateTimeAfter(), "create_time_before": in.GetCreateTimeBefore()} {
+		if ts != nil {
+			query.Set(key, ts.AsTime().Format(time.RFC3339Nano))
+		}
+	}
+	if in.GetPageToken() != "" {
+		query.Set("page_token", in.GetPageToken())
+	}
+	if in.GetCustomerId() != "" {
+		query.Set("customer_id", in.GetCustomerId())
+	}
+	if in.Status != nil {
+		query.Set("status", in.GetStatus().String())
+	}
+	for key, m := range map[string]*pb.Money{"min_total": in.GetMinTotal(), "max_total": in.GetMaxTotal()} {
+		if m != nil {
+			query.Set(key+".currency_code", m.GetCurrencyCode())
+			query.Set(key+".units", strconv.FormatInt(m.GetUnits(), 10))
+			query.Set(key+".nanos", strconv.FormatInt(int64(m.GetNanos()), 10))
+		}
+	}
+	if in.GetSortBy() != "" {
+		query.Set("sort_by", in.GetSortBy())
+	}
+	if in.GetDescending() {
+		query.Set("descending", "true")
+	}
+	if in.GetIncludeDeleted() {
+		query.Set("include_deleted", "true")
+	}
+	return query
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+)
+
+// The types of OrderService, re-exported so that code outside this module,
+// which can't import the service's internal packages, can use the SDK. They
+// are aliases: values can be passed to and from an in-process
+// service.OrderService unchanged.
+type (
+	Order           = domain.Order
+	LineItem        = domain.LineItem
+	Money           = domain.Money
+	OrderStatus     = domain.OrderStatus
+	StatusChange    = domain.StatusChange
+	PriceBreakdown  = domain.PriceBreakdown
+	PriceAdjustment = domain.PriceAdjustment
+	Event           = domain.Event
+	EventType       = domain.EventType
+	ValidationError = domain.ValidationError
+	FieldViolation  = domain.FieldViolation
+
+	ListOptions = repository.ListOptions
+	ListFilter  = repository.ListFilter
+	ListResult  = repository.ListResult
+	SortField   = repository.SortField
+
+	OrderUpdate  = service.OrderUpdate
+	StatusUpdate = service.StatusUpdate
+	BatchResult  = service.BatchResult
+	WatchFilter  = service.WatchFilter
+)
+
+// Order statuses.
+const (
+	StatusPending          = domain.StatusPending
+	StatusConfirmed        = domain.StatusConfirmed
+	StatusShipped          = domain.StatusShipped
+	StatusDelivered        = domain.StatusDelivered
+	StatusCancelled        = domain.StatusCancelled
+	StatusPartiallyShipped = domain.StatusPartiallyShipped
+	StatusReturned         = domain.StatusReturned
+	StatusRefunded         = domain.StatusRefunded
+)
+
+// Event types.
+const (
+	EventOrderCreated       = domain.EventOrderCreated
+	EventOrderUpdated       = domain.EventOrderUpdated
+	EventOrderStatusChanged = domain.EventOrderStatusChanged
+	EventOrderDeleted       = domain.EventOrderDeleted
+	EventOrderRestored      = domain.EventOrderRestored
+	EventOrderPurged        = domain.EventOrderPurged
+)
+
+// Fields ListOrders can sort by.
+const (
+	SortByCreatedAt   = repository.SortByCreatedAt
+	SortByUpdatedAt   = repository.SortByUpdatedAt
+	SortByTotalAmount = repository.SortByTotalAmount
+	SortByID          = repository.SortByID
+)
+
+// NewMoney parses a decimal string such as "12.34" into Money of an ISO 4217
+// currency, like domain.NewMoney.
+func NewMoney(decimal, currency string) (Money, error) {
+	return domain.NewMoney(decimal, currency)
+}
+
+// MustMoney is like NewMoney but panics on invalid input. For constants and tests.
+func MustMoney(decimal, currency string) Money {
+	return domain.MustMoney(decimal, currency)
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"gopkg.in/yaml.v3"
+
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+)
+
+// command is one orderctl subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(e *env, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"create", "create orders from a file or stdin", runCreate},
+		{"get", "show orders by ID", runGet},
+		{"list", "list orders", runList},
+		{"set-status", "change the status of an order", runSetStatus},
+		{"delete", "delete orders by ID", runDelete},
+		{"watch", "print order events as they happen", runWatch},
+		{"export", "write orders as NDJSON, JSON or YAML", runExport},
+		{"import", "create orders from a file in batches", runImport},
+	}
+}
+
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// newFlagSet returns the flag set of a subcommand.
+func newFlagSet(e *env, name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, strings.TrimSpace(fmt.Sprintf("usage: orderctl %s [flags] %s", name, args)))
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args into fs and checks the number of positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if n := fs.NArg(); n < minArgs || (maxArgs >= 0 && n > maxArgs) {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// listFlags are the filter and sort flags shared by list and export.
+type listFlags struct {
+	customer, status, createdAfter, createdBefore, sortBy string
+	descending, includeDeleted                            bool
+}
+
+func (f *listFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.customer, "customer", "", "only orders of this customer `ID`")
+	fs.StringVar(&f.status, "status", "", "only orders with this `status`")
+	fs.StringVar(&f.createdAfter, "created-after", "", "only orders created at or after `time` (RFC 3339 or YYYY-MM-DD)")
+	fs.StringVar(&f.createdBefore, "created-before", "", "only orders created before `time` (RFC 3339 or YYYY-MM-DD)")
+	fs.StringVar(&f.sortBy, "sort", "", "sort by `field`: created_at, updated_at, total_amount or id")
+	fs.BoolVar(&f.descending, "desc", false, "sort in descending order")
+	fs.BoolVar(&f.includeDeleted, "include-deleted", false, "include deleted orders")
+}
+
+func (f *listFlags) options() (repository.ListOptions, error) {
+	opts := repository.ListOptions{
+		Filter: repository.ListFilter{
+			CustomerID:     f.customer,
+			Status:         domain.OrderStatus(f.status),
+			IncludeDeleted: f.includeDeleted,
+		},
+		SortBy:     repository.SortField(f.sortBy),
+		Descending: f.descending,
+	}
+	if f.sortBy != "" && !opts.SortBy.IsValid() {
+		return opts, usageError("invalid sort field %q", f.sortBy)
+	}
+	var err error
+	if f.createdAfter != "" {
+		if opts.Filter.CreatedAfter, err = parseTime(f.createdAfter); err != nil {
+			return opts, usageError("invalid -created-after: %v", err)
+		}
+	}
+	if f.createdBefore != "" {
+		if opts.Filter.CreatedBefore, err = parseTime(f.createdBefore); err != nil {
+			return opts, usageError("invalid -created-before: %v", err)
+		}
+	}
+	return opts, nil
+}
+
+func runCreate(e *env, args []string) error {
+	fs := newFlagSet(e, "create", "")
+	file := fs.String("f", "-", "read orders from `file`; - is stdin")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	in, err := openInput(*file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	ctx := e.ctx
+	var created []*domain.Order
+	err = decodeOrders(in, func(order *domain.Order) error {
+		if err := e.client.CreateOrder(ctx, order); err != nil {
+			return fmt.Errorf("creating order for customer %s: %w", order.CustomerID, err)
+		}
+		// Read it back for the timestamps the server stamped on it.
+		stored, err := e.client.GetOrder(ctx, order.ID)
+		if err != nil {
+			return fmt.Errorf("reading created order %s: %w", order.ID, err)
+		}
+		created = append(created, stored)
+		return nil
+	})
+	if len(created) == 1 {
+		if perr := e.out.order(created[0]); perr != nil {
+			return perr
+		}
+	} else if len(created) > 1 {
+		if perr := e.out.orders(created); perr != nil {
+			return perr
+		}
+	}
+	return err
+}
+
+func runGet(e *env, args []string) error {
+	fs := newFlagSet(e, "get", "ID...")
+	includeDeleted := fs.Bool("include-deleted", false, "also show deleted orders")
+	if err := parseFlags(fs, args, 1, -1); err != nil {
+		return err
+	}
+
+	ctx := e.ctx
+	var orders []*domain.Order
+	for _, id := range fs.Args() {
+		get := e.client.GetOrder
+		if *includeDeleted {
+			get = e.client.GetOrderIncludingDeleted
+		}
+		order, err := get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		orders = append(orders, order)
+	}
+	if len(orders) == 1 {
+		return e.out.order(orders[0])
+	}
+	return e.out.orders(orders)
+}
+
+func runList(e *env, args []string) error {
+	fs := newFlagSet(e, "list", "")
+	var lf listFlags
+	lf.register(fs)
+	limit := fs.Int("limit", 50, "orders per page")
+	pageToken := fs.String("page-token", "", "continue from a previous page")
+	all := fs.Bool("all", false, "fetch all pages")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	opts, err := lf.options()
+	if err != nil {
+		return err
+	}
+	opts.PageSize = *limit
+	opts.PageToken = *pageToken
+
+	ctx := e.ctx
+	var orders []*domain.Order
+	for {
+		result, err := e.client.ListOrders(ctx, opts)
+		if err != nil {
+			return err
+		}
+		orders = append(orders, result.Orders...)
+		opts.PageToken = result.NextPageToken
+		if !*all || opts.PageToken == "" {
+			break
+		}
+	}
+	if err := e.out.orders(orders); err != nil {
+		return err
+	}
+	if opts.PageToken != "" {
+		fmt.Fprintf(e.stderr, "more orders: -page-token %s\n", opts.PageToken)
+	}
+	return nil
+}
+
+func runSetStatus(e *env, args []string) error {
+	fs := newFlagSet(e, "set-status", "ID STATUS")
+	reason := fs.String("reason", "", "reason recorded in the order history")
+	actor := fs.String("actor", "", "who made the change (default: the server records the authenticated caller, or else the local user)")
+	expectedVersion := fs.Int64("expected-version", 0, "fail unless the order is at this version")
+	if err := parseFlags(fs, args, 2, 2); err != nil {
+		return err
+	}
+
+	if *actor == "" && !e.authenticated {
+		*actor = defaultActor()
+	}
+	order, err := e.client.UpdateOrderStatus(e.ctx, fs.Arg(0), service.StatusUpdate{
+		Status:          domain.OrderStatus(fs.Arg(1)),
+		ExpectedVersion: *expectedVersion,
+		Actor:           *actor,
+		Reason:          *reason,
+	})
+	if err != nil {
+		return err
+	}
+	return e.out.order(order)
+}
+
+// defaultActor names the local user in the order history.
+func defaultActor() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "orderctl"
+}
+
+func runDelete(e *env, args []string) error {
+	fs := newFlagSet(e, "delete", "ID...")
+	if err := parseFlags(fs, args, 1, -1); err != nil {
+		return err
+	}
+
+	ctx := e.ctx
+	for _, id := range fs.Args() {
+		if err := e.client.DeleteOrder(ctx, id); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		fmt.Fprintf(e.stderr, "deleted %s\n", id)
+	}
+	return nil
+}
+
+func runWatch(e *env, args []string) error {
+	fs := newFlagSet(e, "watch", "")
+	customer := fs.String("customer", "", "only events of this customer `ID`")
+	orderID := fs.String("order", "", "only events of this order `ID`")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(e.ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	filter := service.WatchFilter{CustomerID: *customer, OrderID: *orderID}
+	err := e.client.Watch(ctx, filter, e.out.event)
+	if ctx.Err() != nil {
+		// Interrupted by the user: not a failure.
+		return nil
+	}
+	return err
+}
+
+func runExport(e *env, args []string) error {
+	fs := newFlagSet(e, "export", "")
+	var lf listFlags
+	lf.register(fs)
+	file := fs.String("f", "-", "write to `file`; - is stdout")
+	format := fs.String("format", "ndjson", "`format`: ndjson, json or yaml")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	opts, err := lf.options()
+	if err != nil {
+		return err
+	}
+	opts.PageSize = service.MaxPageSize
+
+	var write func(*domain.Order) error
+	var out io.Writer = e.stdout
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	first := true
+	switch *format {
+	case "ndjson":
+		enc := json.NewEncoder(out)
+		write = func(order *domain.Order) error { return enc.Encode(order) }
+	case "json":
+		// One order per element, written as pages arrive.
+		write = func(order *domain.Order) error {
+			data, err := json.Marshal(order)
+			if err != nil {
+				return err
+			}
+			sep := ",\n  "
+			if first {
+				sep, first = "[\n  ", false
+			}
+			_, err = fmt.Fprintf(out, "%s%s", sep, data)
+			return err
+		}
+	case "yaml":
+		enc := yaml.NewEncoder(out)
+		enc.SetIndent(2)
+		defer enc.Close()
+		write = func(order *domain.Order) error {
+			generic, err := toGeneric(order)
+			if err != nil {
+				return err
+			}
+			return enc.Encode(generic)
+		}
+	default:
+		return usageError("invalid format %q: must be ndjson, json or yaml", *format)
+	}
+
+	ctx := e.ctx
+	count := 0
+	for {
+		result, err := e.client.ListOrders(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, order := range result.Orders {
+			if err := write(order); err != nil {
+				return err
+			}
+			count++
+		}
+		opts.PageToken = result.NextPageToken
+		if opts.PageToken == "" {
+			break
+		}
+	}
+	if *format == "json" {
+		closing := "\n]\n"
+		if first {
+			closing = "[]\n"
+		}
+		if _, err := io.WriteString(out, closing); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(e.stderr, "exported %d orders\n", count)
+	return nil
+}
+
+func runImport(e *env, args []string) error {
+	fs := newFlagSet(e, "import", "")
+	file := fs.String("f", "-", "read orders from `file`; - is stdin")
+	batchSize := fs.Int("batch-size", 100, "orders per batch request")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *batchSize < 1 || *batchSize > service.MaxBatchSize {
+		return usageError("-batch-size must be between 1 and %d", service.MaxBatchSize)
+	}
+
+	in, err := openInput(*file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	ctx := e.ctx
+	var batch []*domain.Order
+	total, failed := 0, 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := e.client.BatchCreateOrders(ctx, batch, false)
+		if err != nil {
+			return fmt.Errorf("orders %d-%d: %w", total+1, total+len(batch), err)
+		}
+		for i, result := range results {
+			if result.Err != nil {
+				failed++
+				fmt.Fprintf(e.stderr, "order %d (customer %s): %v\n", total+i+1, batch[i].CustomerID, result.Err)
+			}
+		}
+		total += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	err = decodeOrders(in, func(order *domain.Order) error {
+		batch = append(batch, order)
+		if len(batch) == *batchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	fmt.Fprintf(e.stderr, "imported %d of %d orders\n", total-failed, total)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d orders failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"gopkg.in/yaml.v3"
+
+	commonconfig "golang-for-java-developers-training/common/config"
+)
+
+// settings says where the server is and how to talk to it.
+// Precedence: flags, then ORDERCTL_* env vars, then the config file.
+type settings struct {
+	Address string        `yaml:"address"` // host:port of the gRPC API
+	APIKey  string        `yaml:"api_key"` // sent as x-api-key
+	Token   string        `yaml:"token"`   // bearer token (JWT), sent as authorization
+	TLS     bool          `yaml:"tls"`     // use TLS instead of plaintext
+	CAFile  string        `yaml:"ca_file"` // PEM roots to verify the server with; empty uses the system roots
+	Timeout time.Duration `yaml:"timeout"` // per call, retries included
+	Output  string        `yaml:"output"`  // table, json, yaml
+}
+
+// defaultSettings reach a server started with `make run`.
+var defaultSettings = settings{
+	Address: "localhost:9090",
+	Timeout: 10 * time.Second,
+	Output:  "table",
+}
+
+// defaultConfigPath is where the config file is looked for when neither
+// -config nor ORDERCTL_CONFIG names one, e.g. ~/.config/orderctl/config.yaml.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "orderctl", "config.yaml")
+}
+
+// loadSettings reads the config file at path, if any, and applies env vars
+// on top. A missing file is only an error if it was asked for explicitly.
+func loadSettings(path string) (settings, error) {
+	s := defaultSettings
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && !explicit:
+		case err != nil:
+			return settings{}, fmt.Errorf("reading config: %w", err)
+		default:
+			if err := yaml.Unmarshal(data, &s); err != nil {
+				return settings{}, fmt.Errorf("parsing config %s: %w", path, err)
+			}
+		}
+	}
+
+	s.Address = commonconfig.GetEnv("ORDERCTL_ADDR", s.Address)
+	s.APIKey = commonconfig.GetEnv("ORDERCTL_API_KEY", s.APIKey)
+	s.Token = commonconfig.GetEnv("ORDERCTL_TOKEN", s.Token)
+	s.TLS = commonconfig.GetBoolEnv("ORDERCTL_TLS", s.TLS)
+	s.CAFile = commonconfig.GetEnv("ORDERCTL_CA_FILE", s.CAFile)
+	s.Timeout = commonconfig.GetDurationEnv("ORDERCTL_TIMEOUT", s.Timeout)
+	s.Output = commonconfig.GetEnv("ORDERCTL_OUTPUT", s.Output)
+	return s, nil
+}
+
+// validate checks the settings once flags have been applied.
+func (s settings) validate() error {
+	if s.Address == "" {
+		return errors.New("no server address: set -addr, ORDERCTL_ADDR or address in the config file")
+	}
+	switch s.Output {
+	case "table", "json", "yaml":
+	default:
+		return fmt.Errorf("invalid output format %q: must be table, json or yaml", s.Output)
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("invalid timeout %v: must not be negative", s.Timeout)
+	}
+	return nil
+}
+
+// dial connects to the server. Credentials are attached to every call.
+func (s settings) dial() (*grpc.ClientConn, error) {
+	transport := insecure.NewCredentials()
+	if s.TLS {
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if s.CAFile != "" {
+			pem, err := os.ReadFile(s.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading CA file: %w", err)
+			}
+			config.RootCAs = x509.NewCertPool()
+			if !config.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA file %s", s.CAFile)
+			}
+		}
+		transport = credentials.NewTLS(config)
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(transport)}
+	if s.APIKey != "" || s.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(callCredentials{apiKey: s.APIKey, token: s.Token}))
+	}
+	return grpc.NewClient(s.Address, opts...)
+}
+
+// callCredentials sends the API key and bearer token as request metadata.
+type callCredentials struct {
+	apiKey string
+	token  string
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (c callCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	md := make(map[string]string)
+	if c.apiKey != "" {
+		md["x-api-key"] = c.apiKey
+	}
+	if c.token != "" {
+		md["authorization"] = "Bearer " + c.token
+	}
+	return md, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+// Plaintext is allowed so local development servers work without TLS.
+func (c callCredentials) RequireTransportSecurity() bool {
+	return false
+}
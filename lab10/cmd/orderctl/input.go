@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"lab10/internal/domain"
+)
+
+// openInput opens the named file, or stdin for "-".
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+// decodeOrders reads orders in the JSON layout of the HTTP API and calls fn
+// for each one as it is decoded. Accepted are a JSON array, a stream of JSON
+// objects (one object, or NDJSON as written by export), and YAML documents
+// holding an order or a list of orders.
+//
+// Only the fields a client may set are kept, so the output of get, list and
+// export can be read back in: the server assigns IDs, status and totals anew.
+func decodeOrders(r io.Reader, fn func(*domain.Order) error) error {
+	br := bufio.NewReader(r)
+	first, err := firstNonSpace(br)
+	if errors.Is(err, io.EOF) {
+		return errors.New("no orders in input")
+	}
+	if err != nil {
+		return err
+	}
+
+	switch first {
+	case '[':
+		dec := json.NewDecoder(br)
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		for n := 1; dec.More(); n++ {
+			if err := decodeJSONOrder(dec, n, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	case '{':
+		dec := json.NewDecoder(br)
+		for n := 1; ; n++ {
+			if err := decodeJSONOrder(dec, n, fn); errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+	default:
+		return decodeYAMLOrders(yaml.NewDecoder(br), fn)
+	}
+}
+
+// decodeJSONOrder decodes the next order of dec.
+func decodeJSONOrder(dec *json.Decoder, n int, fn func(*domain.Order) error) error {
+	var order domain.Order
+	if err := dec.Decode(&order); err != nil {
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+		return fmt.Errorf("order %d: %w", n, err)
+	}
+	return fn(inputFields(&order))
+}
+
+// decodeYAMLOrders decodes YAML documents of orders. Each document is
+// converted to JSON first, so field names and money amounts are read
+// exactly like JSON input.
+func decodeYAMLOrders(dec *yaml.Decoder, fn func(*domain.Order) error) error {
+	n := 0
+	for {
+		var doc interface{}
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		docs, ok := doc.([]interface{})
+		if !ok {
+			docs = []interface{}{doc}
+		}
+		for _, d := range docs {
+			n++
+			data, err := json.Marshal(d)
+			if err != nil {
+				return fmt.Errorf("order %d: %w", n, err)
+			}
+			var order domain.Order
+			if err := json.Unmarshal(data, &order); err != nil {
+				return fmt.Errorf("order %d: %w", n, err)
+			}
+			if err := fn(inputFields(&order)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// inputFields returns a new order with only the fields of order that are
+// inputs to CreateOrder.
+func inputFields(order *domain.Order) *domain.Order {
+	return &domain.Order{
+		CustomerID: order.CustomerID,
+		Items:      order.Items,
+		CouponCode: order.CouponCode,
+		Region:     order.Region,
+	}
+}
+
+// firstNonSpace returns the first non-whitespace byte of br without consuming it.
+func firstNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(rune(b)) {
+			return b, br.UnreadByte()
+		}
+	}
+}
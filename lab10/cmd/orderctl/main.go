@@ -0,0 +1,161 @@
+// Command orderctl operates the order service over its gRPC API.
+//
+// Usage:
+//
+//	orderctl [flags] <command> [command flags] [args]
+//
+// Commands:
+//
+//	create      create orders from a file or stdin
+//	get         show orders by ID
+//	list        list orders
+//	set-status  change the status of an order
+//	delete      delete orders by ID
+//	watch       print order events as they happen
+//	export      write orders as NDJSON, JSON or YAML
+//	import      create orders from a file in batches
+//
+// The server address and credentials come from flags, ORDERCTL_* env vars
+// or the config file (-config, ORDERCTL_CONFIG, or orderctl/config.yaml in
+// the user config directory), in that order of precedence.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"lab10/client"
+)
+
+// errUsage marks errors caused by bad command-line arguments; they exit
+// with 2. It is returned bare once the flag package has printed the problem.
+var errUsage = errors.New("usage error")
+
+// env is what every command runs with.
+type env struct {
+	ctx    context.Context // ends the command's calls when done
+	client *client.Client
+	out    printer
+	stdout io.Writer
+	stderr io.Writer
+
+	// authenticated is set if calls carry an API key or token, so the
+	// server records the caller's identity as the actor of changes.
+	authenticated bool
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit code. Calls to
+// the server end with ctx.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("orderctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", os.Getenv("ORDERCTL_CONFIG"), "config file `path`")
+	addr := fs.String("addr", "", "server `host:port` (default from config)")
+	output := fs.String("o", "", "output `format`: table, json or yaml")
+	timeout := fs.Duration("timeout", 0, "per-call timeout, retries included")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: orderctl [flags] <command> [command flags] [args]")
+		fmt.Fprintln(stderr, "\ncommands:")
+		for _, cmd := range commands {
+			fmt.Fprintf(stderr, "  %-11s %s\n", cmd.name, cmd.summary)
+		}
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cmd, ok := lookupCommand(fs.Arg(0))
+	if !ok {
+		fmt.Fprintf(stderr, "orderctl: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return 2
+	}
+
+	s, err := loadSettings(*configPath)
+	if err != nil {
+		fmt.Fprintln(stderr, "orderctl:", err)
+		return 1
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			s.Address = *addr
+		case "o":
+			s.Output = *output
+		case "timeout":
+			s.Timeout = *timeout
+		}
+	})
+	if err := s.validate(); err != nil {
+		fmt.Fprintln(stderr, "orderctl:", err)
+		return 2
+	}
+
+	conn, err := s.dial()
+	if err != nil {
+		fmt.Fprintln(stderr, "orderctl:", err)
+		return 1
+	}
+	defer conn.Close()
+
+	var opts []client.Option
+	if s.Timeout > 0 {
+		opts = append(opts, client.WithTimeout(s.Timeout))
+	}
+	e := &env{
+		ctx:    ctx,
+		client: client.NewGRPC(conn, opts...),
+		out:    printer{w: stdout, format: s.Output},
+		stdout: stdout,
+		stderr: stderr,
+
+		authenticated: s.APIKey != "" || s.Token != "",
+	}
+
+	err = cmd.run(e, fs.Args()[1:])
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		if err != errUsage {
+			fmt.Fprintf(stderr, "orderctl %s: %v\n", cmd.name, err)
+		}
+		return 2
+	default:
+		fmt.Fprintf(stderr, "orderctl %s: %v\n", cmd.name, err)
+		return 1
+	}
+}
+
+// usageError reports a bad command line.
+func usageError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// parseTime accepts RFC 3339 timestamps and plain dates for list filters.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
+}
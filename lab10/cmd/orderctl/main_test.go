@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"lab10/internal/domain"
+	"lab10/internal/events"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+	grpcTransport "lab10/internal/transport/grpc"
+	pb "lab10/proto/orders"
+)
+
+// startServer serves the gRPC API of an in-memory order service, with its
+// events relayed to watchers, on a local port and returns its address and the
+// service.
+func startServer(t *testing.T) (string, *service.OrderService) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	repo := repository.NewMemoryRepository()
+	eventBus := events.NewInProcessPublisher()
+	svc := service.NewOrderService(repo, service.WithEventSubscriber(eventBus))
+	relay := events.NewRelay(repo, eventBus, events.WithPollInterval(10*time.Millisecond))
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	go relay.Run(relayCtx)
+
+	orders := grpcTransport.NewOrderServer(svc)
+	server := grpc.NewServer()
+	pb.RegisterOrderServiceServer(server, orders)
+	go server.Serve(listener)
+	t.Cleanup(func() {
+		orders.Shutdown()
+		server.Stop()
+		stopRelay()
+	})
+	return listener.Addr().String(), svc
+}
+
+// isolate keeps the user's config file and ORDERCTL_* variables out of a test.
+func isolate(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, name := range []string{
+		"ORDERCTL_CONFIG", "ORDERCTL_ADDR", "ORDERCTL_API_KEY", "ORDERCTL_TOKEN",
+		"ORDERCTL_TLS", "ORDERCTL_CA_FILE", "ORDERCTL_TIMEOUT", "ORDERCTL_OUTPUT",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+// syncBuffer is a bytes.Buffer that a running command and a test can share.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// orderctl runs a command line and returns its exit code, stdout and stderr.
+func orderctl(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr syncBuffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func newOrder(customerID string) *domain.Order {
+	return &domain.Order{
+		CustomerID: customerID,
+		Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 1, UnitPrice: domain.MustMoney("10.00", "USD")}},
+	}
+}
+
+// createOrders creates n orders, in batches as large as the service allows.
+func createOrders(t *testing.T, svc *service.OrderService, n int) {
+	t.Helper()
+	for n > 0 {
+		batch := make([]*domain.Order, min(n, service.MaxBatchSize))
+		for i := range batch {
+			batch[i] = newOrder(fmt.Sprintf("CUST-%d", n-i))
+		}
+		if _, err := svc.BatchCreateOrders(context.Background(), batch, true); err != nil {
+			t.Fatalf("BatchCreateOrders() error = %v", err)
+		}
+		n -= len(batch)
+	}
+}
+
+// TestExport checks every order is exported, across pages, in each format.
+func TestExport(t *testing.T) {
+	isolate(t)
+	addr, svc := startServer(t)
+	createOrders(t, svc, service.MaxPageSize+1)
+
+	file := filepath.Join(t.TempDir(), "orders.ndjson")
+	code, _, stderr := orderctl(t, "-addr", addr, "export", "-f", file)
+	if code != 0 {
+		t.Fatalf("export exit code = %d, stderr %q", code, stderr)
+	}
+	if want := fmt.Sprintf("exported %d orders", service.MaxPageSize+1); !strings.Contains(stderr, want) {
+		t.Errorf("export stderr = %q, want %q", stderr, want)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != service.MaxPageSize+1 {
+		t.Errorf("export has %d lines, want %d", lines, service.MaxPageSize+1)
+	}
+
+	code, stdout, stderr := orderctl(t, "-addr", addr, "export", "-format", "json", "-customer", "CUST-1")
+	var orders []domain.Order
+	if code != 0 || json.Unmarshal([]byte(stdout), &orders) != nil || len(orders) != 1 {
+		t.Errorf("JSON export = %d %q %q, want an array of one order", code, stdout, stderr)
+	}
+
+	if code, _, _ := orderctl(t, "-addr", addr, "export", "-format", "csv"); code != 2 {
+		t.Errorf("export with unknown format exit code = %d, want 2", code)
+	}
+}
+
+// TestImport checks orders are created in batches, failed orders are
+// reported with their position, and an export can be imported again.
+func TestImport(t *testing.T) {
+	isolate(t)
+	addr, svc := startServer(t)
+	dir := t.TempDir()
+
+	input := filepath.Join(dir, "input.ndjson")
+	lines := []string{
+		`{"customer_id": "CUST-1", "items": [{"product_id": "SKU-1", "product_name": "Widget", "quantity": 1, "unit_price": {"amount": "10.00", "currency": "USD"}}]}`,
+		`{"customer_id": "", "items": []}`,
+		`{"customer_id": "CUST-2", "items": [{"product_id": "SKU-1", "product_name": "Widget", "quantity": 2, "unit_price": {"amount": "10.00", "currency": "USD"}}]}`,
+	}
+	if err := os.WriteFile(input, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	code, _, stderr := orderctl(t, "-addr", addr, "import", "-f", input, "-batch-size", "2")
+	if code != 1 {
+		t.Errorf("import with a bad order exit code = %d, want 1", code)
+	}
+	for _, want := range []string{"order 2 (customer )", "imported 2 of 3 orders", "1 orders failed"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("import stderr = %q, want %q", stderr, want)
+		}
+	}
+	page, err := svc.ListOrders(context.Background(), repository.ListOptions{})
+	if err != nil || len(page.Orders) != 2 {
+		t.Fatalf("ListOrders() after import = %v, %v, want 2 orders", page, err)
+	}
+
+	// Round trip into a second server
+	exported := filepath.Join(dir, "export.ndjson")
+	if code, _, stderr := orderctl(t, "-addr", addr, "export", "-f", exported); code != 0 {
+		t.Fatalf("export exit code = %d, stderr %q", code, stderr)
+	}
+	otherAddr, other := startServer(t)
+	if code, _, stderr := orderctl(t, "-addr", otherAddr, "import", "-f", exported); code != 0 || !strings.Contains(stderr, "imported 2 of 2 orders") {
+		t.Fatalf("import of export = %d, stderr %q", code, stderr)
+	}
+	imported, err := other.ListOrders(context.Background(), repository.ListOptions{SortBy: repository.SortByCreatedAt})
+	if err != nil || len(imported.Orders) != 2 {
+		t.Fatalf("ListOrders() after round trip = %v, %v, want 2 orders", imported, err)
+	}
+	for i, order := range imported.Orders {
+		if want := page.Orders[i]; order.CustomerID != want.CustomerID || order.TotalAmount != want.TotalAmount {
+			t.Errorf("imported order %d = %s %s, want %s %s", i, order.CustomerID, order.TotalAmount, want.CustomerID, want.TotalAmount)
+		}
+	}
+
+	if code, _, _ := orderctl(t, "-addr", addr, "import", "-batch-size", "0"); code != 2 {
+		t.Errorf("import with -batch-size 0 exit code = %d, want 2", code)
+	}
+}
+
+// TestWatch checks events are printed until the context ends, which is
+// not a failure.
+func TestWatch(t *testing.T) {
+	isolate(t)
+	addr, svc := startServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var stdout, stderr syncBuffer
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, []string{"-addr", addr, "-o", "json", "watch", "-customer", "CUST-W"}, &stdout, &stderr)
+	}()
+
+	// Orders created before the stream is open aren't seen, so keep creating
+	// until one is; orders of other customers must never show up
+	deadline := time.After(5 * time.Second)
+	for !strings.Contains(stdout.String(), "CUST-W") {
+		if err := svc.CreateOrder(context.Background(), newOrder("CUST-OTHER")); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		if err := svc.CreateOrder(context.Background(), newOrder("CUST-W")); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("no event printed; stdout %q, stderr %q", stdout.String(), stderr.String())
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	cancel()
+	if code := <-done; code != 0 {
+		t.Errorf("watch exit code = %d, stderr %q", code, stderr.String())
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(stdout.String()))
+	for scanner.Scan() {
+		var event domain.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("watch line %q is not a JSON event: %v", scanner.Text(), err)
+		}
+		if event.Type != domain.EventOrderCreated || event.Order == nil || event.Order.CustomerID != "CUST-W" {
+			t.Errorf("watch printed %+v, want order.created events of CUST-W", event)
+		}
+	}
+}
+
+// TestConfigPrecedence checks flags override ORDERCTL_* variables, which
+// override the config file.
+func TestConfigPrecedence(t *testing.T) {
+	isolate(t)
+	addr, svc := startServer(t)
+	order := newOrder("CUST-1")
+	if err := svc.CreateOrder(context.Background(), order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	data := "address: file.invalid:1\noutput: yaml\ntimeout: 3s\napi_key: file-key\n"
+	if err := os.WriteFile(config, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ORDERCTL_CONFIG", config)
+
+	s, err := loadSettings(config)
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+	if s.Address != "file.invalid:1" || s.Output != "yaml" || s.Timeout != 3*time.Second || s.APIKey != "file-key" {
+		t.Errorf("settings from file = %+v", s)
+	}
+
+	t.Setenv("ORDERCTL_ADDR", "env.invalid:1")
+	t.Setenv("ORDERCTL_OUTPUT", "json")
+	s, err = loadSettings(config)
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+	if s.Address != "env.invalid:1" || s.Output != "json" || s.Timeout != 3*time.Second || s.APIKey != "file-key" {
+		t.Errorf("settings from env and file = %+v, want address and output from env, the rest from the file", s)
+	}
+
+	// The -addr flag beats the environment; the output format still comes from it
+	code, stdout, stderr := orderctl(t, "-addr", addr, "get", order.ID)
+	var got domain.Order
+	if code != 0 || json.Unmarshal([]byte(stdout), &got) != nil || got.ID != order.ID {
+		t.Fatalf("get = %d %q %q, want the order as JSON", code, stdout, stderr)
+	}
+	code, stdout, _ = orderctl(t, "-addr", addr, "-o", "yaml", "get", order.ID)
+	if code != 0 || !strings.Contains(stdout, "customer_id: CUST-1") {
+		t.Errorf("get -o yaml = %d %q, want YAML", code, stdout)
+	}
+
+	t.Setenv("ORDERCTL_OUTPUT", "xml")
+	if code, _, stderr := orderctl(t, "-addr", addr, "get", order.ID); code != 2 || !strings.Contains(stderr, "invalid output format") {
+		t.Errorf("get with ORDERCTL_OUTPUT=xml = %d %q, want exit code 2", code, stderr)
+	}
+
+	if _, err := loadSettings(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loadSettings() of a missing explicit config succeeded, want error")
+	}
+}
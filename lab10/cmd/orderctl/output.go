@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"lab10/internal/domain"
+)
+
+// printer writes results in the chosen output format. JSON and YAML use the
+// field names of the HTTP API, so output can be fed back to create and import.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// value writes v as JSON or YAML; table output is written by table instead.
+func (p printer) value(v interface{}, table func(tw *tabwriter.Writer)) error {
+	switch p.format {
+	case "json":
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		generic, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(p.w)
+		enc.SetIndent(2)
+		if err := enc.Encode(generic); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	}
+}
+
+// orders writes a list of orders.
+func (p printer) orders(orders []*domain.Order) error {
+	if orders == nil {
+		orders = []*domain.Order{} // [] rather than null
+	}
+	return p.value(orders, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ID\tCUSTOMER\tSTATUS\tITEMS\tTOTAL\tCREATED")
+		for _, order := range orders {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+				order.ID, order.CustomerID, statusLabel(order), len(order.Items), order.TotalAmount, formatTime(order.CreatedAt))
+		}
+	})
+}
+
+// order writes a single order with its items and price breakdown.
+func (p printer) order(order *domain.Order) error {
+	return p.value(order, func(tw *tabwriter.Writer) {
+		fmt.Fprintf(tw, "ID:\t%s\n", order.ID)
+		fmt.Fprintf(tw, "Customer:\t%s\n", order.CustomerID)
+		fmt.Fprintf(tw, "Status:\t%s\n", statusLabel(order))
+		fmt.Fprintf(tw, "Version:\t%d\n", order.Version)
+		fmt.Fprintf(tw, "Created:\t%s\n", formatTime(order.CreatedAt))
+		fmt.Fprintf(tw, "Updated:\t%s\n", formatTime(order.UpdatedAt))
+		if order.CouponCode != "" {
+			fmt.Fprintf(tw, "Coupon:\t%s\n", order.CouponCode)
+		}
+		if order.Region != "" {
+			fmt.Fprintf(tw, "Region:\t%s\n", order.Region)
+		}
+		fmt.Fprintln(tw, "Items:")
+		for _, item := range order.Items {
+			fmt.Fprintf(tw, "  %s\t%s\t%d x %s\n", item.ProductID, item.ProductName, item.Quantity, item.UnitPrice)
+		}
+		if order.Pricing != nil {
+			fmt.Fprintf(tw, "Subtotal:\t%s\n", order.Pricing.Subtotal)
+			for _, d := range order.Pricing.Discounts {
+				fmt.Fprintf(tw, "  -%s\t%s\t(%s)\n", d.Amount, d.Description, d.Rule)
+			}
+			for _, t := range order.Pricing.Taxes {
+				fmt.Fprintf(tw, "  +%s\t%s\t(%s)\n", t.Amount, t.Description, t.Rule)
+			}
+		}
+		fmt.Fprintf(tw, "Total:\t%s\n", order.TotalAmount)
+	})
+}
+
+// event writes one watched event. JSON events are written one per line and
+// YAML events as separate documents, so the output can be processed as a stream.
+func (p printer) event(event domain.Event) error {
+	switch p.format {
+	case "json":
+		return json.NewEncoder(p.w).Encode(event)
+	case "yaml":
+		if _, err := fmt.Fprintln(p.w, "---"); err != nil {
+			return err
+		}
+		return p.value(event, nil)
+	default:
+		status := ""
+		if event.Order != nil {
+			status = string(event.Order.Status)
+		}
+		if change := event.StatusChange; change != nil {
+			status = fmt.Sprintf("%s -> %s by %s", change.FromStatus, change.ToStatus, change.Actor)
+		}
+		_, err := fmt.Fprintf(p.w, "%s  %-22s %s  %s\n", formatTime(event.OccurredAt), event.Type, event.OrderID, status)
+		return err
+	}
+}
+
+// statusLabel is the order status, marked if the order is deleted.
+func statusLabel(order *domain.Order) string {
+	if order.IsDeleted() {
+		return string(order.Status) + " (deleted)"
+	}
+	return string(order.Status)
+}
+
+// formatTime formats t in local time; the zero time is "-".
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+// toGeneric converts v to maps and slices through its JSON encoding, so
+// YAML output uses the same field names and money format as JSON.
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return numbersToYAML(generic), nil
+}
+
+// numbersToYAML replaces json.Numbers, which YAML would quote, with int64
+// or float64 values.
+func numbersToYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = numbersToYAML(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = numbersToYAML(e)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
@@ -15,14 +15,23 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 
+	"golang-for-java-developers-training/common/external"
 	"lab10/config"
+	"lab10/internal/auth"
+	"lab10/internal/events"
+	"lab10/internal/idgen"
+	"lab10/internal/inventory"
 	"lab10/internal/repository"
 	"lab10/internal/service"
 	httpTransport "lab10/internal/transport/http"
+	"lab10/internal/transport/gateway"
 	grpcTransport "lab10/internal/transport/grpc"
 	pb "lab10/proto/orders"
 )
 
+// webhookTimeout bounds a single event webhook delivery.
+const webhookTimeout = 10 * time.Second
+
 // version information injected at build time via -ldflags
 var (
 	version   = "dev"
@@ -43,6 +52,8 @@ func main() {
 	fmt.Printf("Commit: %s\n", commit)
 	fmt.Printf("Build Time: %s\n", buildTime)
 	fmt.Printf("Environment: %s\n", cfg.Environment)
+	fmt.Printf("Storage: %s\n", cfg.StorageBackend)
+	fmt.Printf("Inventory: %s\n", cfg.InventoryBackend)
 	fmt.Printf("HTTP Port: %s\n", cfg.HTTPPort)
 	if cfg.Features.EnableGRPC {
 		fmt.Printf("gRPC Port: %s\n", cfg.GRPCPort)
@@ -59,10 +70,86 @@ func main() {
 // TODO: Part 4 - Implement graceful shutdown
 func runServer(cfg *config.Config) error {
 	// Create dependencies (dependency injection)
-	repo := repository.NewMemoryRepository()
-	orderService := service.NewOrderService(repo)
+	repo, closeRepo, err := newRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+	
+	machine, err := config.LoadStateMachine(cfg.StateMachineFile)
+	if err != nil {
+		return err
+	}
+	pipeline, err := config.LoadPricing(cfg.PricingRulesFile)
+	if err != nil {
+		return err
+	}
+	authn, err := newAuthenticator(cfg)
+	if err != nil {
+		return err
+	}
+	if !authn.Enabled() {
+		fmt.Println("Warning: none of API_KEY, JWT_SECRET and JWKS_FILE is set; requests are not authenticated")
+	}
+	
+	// Relay domain events from the outbox to in-process subscribers (such as
+	// WatchOrders streams) and, if configured, a webhook
+	eventBus := events.NewInProcessPublisher()
+	orderService := service.NewOrderService(repo,
+		service.WithCustomers(repo),
+		service.WithCatalog(repo),
+		service.WithInventory(newInventory(cfg)),
+		service.WithStateMachine(machine),
+		service.WithPricing(pipeline),
+		service.WithEventSubscriber(eventBus),
+		service.WithIdempotency(repository.NewMemoryIdempotencyStore(), cfg.IdempotencyTTL),
+		service.WithIDGenerator(newIDGenerator(cfg)),
+		service.WithClientOrderIDs(cfg.AllowClientOrderIDs),
+	)
+	
+	// Stock reservations are kept in memory: reserve stock again for the
+	// orders confirmed before this start
+	held, err := orderService.RestoreReservations(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to restore stock reservations: %w", err)
+	}
+	fmt.Printf("Restored stock reservations of %d orders\n", held)
+	
+	var publisher events.Publisher = eventBus
+	relayOptions := []events.RelayOption{events.WithPollInterval(cfg.OutboxPollInterval)}
+	if cfg.EventWebhookURL != "" {
+		// Watchers get events right away, not only once the webhook took them
+		publisher = events.NewWebhookPublisher(cfg.EventWebhookURL, webhookTimeout)
+		relayOptions = append(relayOptions, events.WithLocalPublisher(eventBus))
+	}
+	relay := events.NewRelay(repo, publisher, relayOptions...)
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(relayCtx)
+	}()
+	
+	// Permanently remove soft-deleted orders once their retention has passed
+	purger := service.NewPurger(repo, cfg.DeletedOrderRetention, service.WithPurgeInterval(cfg.PurgeInterval))
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	purgeDone := make(chan struct{})
+	go func() {
+		defer close(purgeDone)
+		purger.Run(purgeCtx)
+	}()
+	
+	customerService := service.NewCustomerService(repo, orderService,
+		service.WithCustomerIDGenerator(newIDGenerator(cfg)),
+	)
+	catalogService := service.NewCatalogService(repo)
+	
 	httpHandler := httpTransport.NewOrderHandler(orderService)
 	grpcServer := grpcTransport.NewOrderServer(orderService)
+	customerServer := grpcTransport.NewCustomerServer(customerService)
+	productServer := grpcTransport.NewProductServer(catalogService)
 	
 	// Setup HTTP server
 	httpMux := http.NewServeMux()
@@ -74,55 +161,49 @@ func runServer(cfg *config.Config) error {
 		httpMux.HandleFunc("/version", handleVersion)
 	}
 	
-	// Register HTTP routes
-	httpMux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodPost:
-			httpHandler.CreateOrder(w, r)
-		case http.MethodGet:
-			httpHandler.ListOrders(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-	
-	httpMux.HandleFunc("/orders/", func(w http.ResponseWriter, r *http.Request) {
-		if len(r.URL.Path) > len("/orders/") && r.URL.Path[len(r.URL.Path)-7:] == "/status" {
-			httpHandler.UpdateOrderStatus(w, r)
-			return
-		}
-		
-		switch r.Method {
-		case http.MethodGet:
-			httpHandler.GetOrder(w, r)
-		case http.MethodDelete:
-			httpHandler.DeleteOrder(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+	// REST API generated from the google.api.http annotations in orders.proto,
+	// served by the gRPC servers in-process, plus the order endpoints the
+	// annotations can't describe (event stream, bulk export and import). The
+	// other patterns on httpMux are more specific, so this catch-all only
+	// gets what they don't match
+	gatewayHandler, err := gateway.NewHandler(context.Background(), grpcServer, customerServer, productServer)
+	if err != nil {
+		return fmt.Errorf("failed to set up REST gateway: %w", err)
+	}
+	apiPatterns, err := gateway.Patterns()
+	if err != nil {
+		return fmt.Errorf("failed to set up REST routes: %w", err)
+	}
+	httpMux.Handle("/", httpTransport.NewRouter(httpHandler, gatewayHandler, apiPatterns))
+	httpMux.HandleFunc("/openapi.json", gateway.OpenAPIHandler)
 	
 	httpServer := &http.Server{
 		Addr:         ":" + cfg.HTTPPort,
-		Handler:      httpMux,
+		Handler:      httpTransport.RequireAuth(authn, httpMux, "/health", "/ready", "/version"),
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
 	}
+	// Shutdown waits for active requests, so end WatchOrders streams first
+	httpServer.RegisterOnShutdown(httpHandler.Shutdown)
 	
 	// TODO: Part 2 - Setup gRPC server only if feature flag is enabled
 	var grpcListener net.Listener
 	var grpcSrv *grpc.Server
 	
 	if cfg.Features.EnableGRPC {
-		var err error
 		grpcListener, err = net.Listen("tcp", ":"+cfg.GRPCPort)
 		if err != nil {
 			return fmt.Errorf("failed to listen on gRPC port: %w", err)
 		}
 		
-		grpcSrv = grpc.NewServer()
+		grpcSrv = grpc.NewServer(
+			grpc.ChainUnaryInterceptor(grpcTransport.UnaryAuthInterceptor(authn)),
+			grpc.ChainStreamInterceptor(grpcTransport.StreamAuthInterceptor(authn)),
+		)
 		pb.RegisterOrderServiceServer(grpcSrv, grpcServer)
+		pb.RegisterCustomerServiceServer(grpcSrv, customerServer)
+		pb.RegisterProductServiceServer(grpcSrv, productServer)
 		reflection.Register(grpcSrv)
 	}
 	
@@ -164,14 +245,83 @@ func runServer(cfg *config.Config) error {
 	
 	// Shutdown gRPC server gracefully
 	if cfg.Features.EnableGRPC && grpcSrv != nil {
+		grpcServer.Shutdown()
 		grpcSrv.GracefulStop()
 		fmt.Println("gRPC server stopped gracefully")
 	}
 	
+	// Stop the event relay; undelivered events stay in the outbox
+	stopRelay()
+	<-relayDone
+	fmt.Println("Event relay stopped")
+	
+	stopPurge()
+	<-purgeDone
+	fmt.Println("Order purge stopped")
+	
 	fmt.Println("Service shutdown complete")
 	return nil
 }
 
+// newRepository creates the order, customer and product repository selected by cfg.StorageBackend.
+// The returned close function releases any resources held by the repository.
+func newRepository(cfg *config.Config) (repository.Store, func(), error) {
+	switch cfg.StorageBackend {
+	case "sqlite":
+		repo, err := repository.NewSQLiteRepository(cfg.DatabasePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open SQLite repository: %w", err)
+		}
+		return repo, func() {
+			if err := repo.Close(); err != nil {
+				log.Printf("SQLite repository close error: %v", err)
+			}
+		}, nil
+	default:
+		return repository.NewMemoryRepository(), func() {}, nil
+	}
+}
+
+// newAuthenticator creates the authenticator for the credentials in cfg:
+// the API key, the HS256 secret and the RS256 keys of cfg.JWKSFile.
+func newAuthenticator(cfg *config.Config) (*auth.Authenticator, error) {
+	opts := []auth.Option{
+		auth.WithAPIKey(cfg.APIKey),
+		auth.WithHS256Secret(cfg.JWTSecret),
+	}
+	if cfg.JWKSFile != "" {
+		keys, err := auth.LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, auth.WithRSAKeys(keys))
+	}
+	return auth.NewAuthenticator(opts...), nil
+}
+
+// newIDGenerator creates the order ID generator selected by cfg.OrderIDFormat.
+func newIDGenerator(cfg *config.Config) service.IDGenerator {
+	switch cfg.OrderIDFormat {
+	case "ulid":
+		return idgen.NewULID()
+	default:
+		return idgen.NewUUIDv7()
+	}
+}
+
+// newInventory creates the stock reservation backend selected by cfg.InventoryBackend,
+// or nil to skip stock checks.
+func newInventory(cfg *config.Config) service.InventoryService {
+	switch cfg.InventoryBackend {
+	case "external":
+		return inventory.NewExternal(external.FetchInventoryLevel)
+	case "none":
+		return nil
+	default:
+		return inventory.NewMemory(cfg.InventoryInitialStock)
+	}
+}
+
 // handleHealth returns basic health status.
 // TODO: Part 8 - Implement health check endpoint
 func handleHealth(w http.ResponseWriter, r *http.Request) {
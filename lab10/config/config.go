@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	commonconfig "golang-for-java-developers-training/common/config"
 )
@@ -23,12 +24,44 @@ type Config struct {
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
 	
+	// Storage
+	StorageBackend string // memory, sqlite
+	DatabasePath   string // SQLite database file, used when StorageBackend is sqlite
+	
+	// Order lifecycle
+	StateMachineFile string // JSON/YAML state machine definition; empty uses the built-in lifecycle
+	
+	// Pricing
+	PricingRulesFile string // JSON/YAML discount and tax rules; empty prices orders at their item subtotal
+	
+	// Domain events
+	EventWebhookURL    string        // events are POSTed here when set
+	OutboxPollInterval time.Duration // how often the outbox relay checks for new events
+	
+	// Order IDs
+	OrderIDFormat       string // uuidv7, ulid
+	AllowClientOrderIDs bool   // accept client-chosen IDs on CreateOrder instead of rejecting them
+	
+	// Soft deletes
+	DeletedOrderRetention time.Duration // deleted orders are purged for good after this long
+	PurgeInterval         time.Duration // how often the purge job runs
+	
+	// Inventory
+	InventoryBackend      string // memory, external, none
+	InventoryInitialStock int    // stock on hand of every SKU when InventoryBackend is memory
+	
+	// Idempotency
+	IdempotencyTTL time.Duration // how long CreateOrder results are kept for Idempotency-Key replays (in memory, whatever the storage backend)
+	
 	// Feature flags
 	Features FeatureFlags
 	
+	// Authentication; requests are let through unauthenticated if none is set
+	JWKSFile string // JSON Web Key Set with RS256 keys for bearer tokens
+	
 	// Secrets (never log these)
-	APIKey    string
-	JWTSecret string
+	APIKey    string // accepted in the X-API-Key header
+	JWTSecret string // HS256 key for bearer tokens
 }
 
 // FeatureFlags contains toggleable features.
@@ -41,23 +74,125 @@ type FeatureFlags struct {
 }
 
 // LoadConfig loads configuration from environment variables with defaults.
-// TODO: Part 1 - Implement configuration loading
 func LoadConfig() (*Config, error) {
-	// TODO: Create Config struct with values from environment variables
-	// TODO: Use commonconfig.GetEnv(), commonconfig.GetBoolEnv(), commonconfig.GetDurationEnv() helpers
-	// TODO: Set sensible defaults for all fields
-	// TODO: Call cfg.Validate() before returning
-	// TODO: Return config and any validation errors
-	return nil, fmt.Errorf("not implemented")
+	cfg := &Config{
+		HTTPPort:     commonconfig.GetEnv("HTTP_PORT", "8080"),
+		GRPCPort:     commonconfig.GetEnv("GRPC_PORT", "9090"),
+		Environment:  commonconfig.GetEnv("ENVIRONMENT", "development"),
+		LogLevel:     commonconfig.GetEnv("LOG_LEVEL", "info"),
+		ReadTimeout:  commonconfig.GetDurationEnv("READ_TIMEOUT", 15*time.Second),
+		WriteTimeout: commonconfig.GetDurationEnv("WRITE_TIMEOUT", 15*time.Second),
+		IdleTimeout:  commonconfig.GetDurationEnv("IDLE_TIMEOUT", 60*time.Second),
+		
+		StorageBackend: commonconfig.GetEnv("STORAGE_BACKEND", "memory"),
+		DatabasePath:   commonconfig.GetEnv("DATABASE_PATH", "orders.db"),
+		
+		StateMachineFile: commonconfig.GetEnv("STATE_MACHINE_FILE", ""),
+		
+		PricingRulesFile: commonconfig.GetEnv("PRICING_RULES_FILE", ""),
+		
+		EventWebhookURL:    commonconfig.GetEnv("EVENT_WEBHOOK_URL", ""),
+		OutboxPollInterval: commonconfig.GetDurationEnv("OUTBOX_POLL_INTERVAL", time.Second),
+		
+		OrderIDFormat:       commonconfig.GetEnv("ORDER_ID_FORMAT", "uuidv7"),
+		AllowClientOrderIDs: commonconfig.GetBoolEnv("ALLOW_CLIENT_ORDER_IDS", false),
+		
+		DeletedOrderRetention: commonconfig.GetDurationEnv("DELETED_ORDER_RETENTION", 90*24*time.Hour),
+		PurgeInterval:         commonconfig.GetDurationEnv("PURGE_INTERVAL", time.Hour),
+		
+		InventoryBackend:      commonconfig.GetEnv("INVENTORY_BACKEND", "memory"),
+		InventoryInitialStock: commonconfig.GetIntEnv("INVENTORY_INITIAL_STOCK", 100),
+		
+		IdempotencyTTL: commonconfig.GetDurationEnv("IDEMPOTENCY_TTL", 24*time.Hour),
+		
+		Features: FeatureFlags{
+			EnableGRPC:      commonconfig.GetBoolEnv("ENABLE_GRPC", true),
+			EnableMetrics:   commonconfig.GetBoolEnv("ENABLE_METRICS", false),
+			EnableHealthz:   commonconfig.GetBoolEnv("ENABLE_HEALTHZ", true),
+			EnableDebugMode: commonconfig.GetBoolEnv("ENABLE_DEBUG", false),
+		},
+		
+		JWKSFile: commonconfig.GetEnv("JWKS_FILE", ""),
+		
+		APIKey:    commonconfig.GetEnv("API_KEY", ""),
+		JWTSecret: commonconfig.GetEnv("JWT_SECRET", ""),
+	}
+	
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
 // Validate checks that required configuration is present and valid.
-// TODO: Part 1 - Implement configuration validation
 func (c *Config) Validate() error {
-	// TODO: Validate ports are numeric using strconv.Atoi()
-	// TODO: Validate environment is one of: development, staging, production
-	// TODO: Validate log level is one of: debug, info, warn, error
-	// TODO: In production, validate that APIKey and JWTSecret are set
+	if _, err := strconv.Atoi(c.HTTPPort); err != nil {
+		return fmt.Errorf("invalid HTTP_PORT %q: must be numeric", c.HTTPPort)
+	}
+	if _, err := strconv.Atoi(c.GRPCPort); err != nil {
+		return fmt.Errorf("invalid GRPC_PORT %q: must be numeric", c.GRPCPort)
+	}
+	
+	switch c.Environment {
+	case "development", "staging", "production":
+	default:
+		return fmt.Errorf("invalid ENVIRONMENT %q: must be development, staging or production", c.Environment)
+	}
+	
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("invalid LOG_LEVEL %q: must be debug, info, warn or error", c.LogLevel)
+	}
+	
+	switch c.StorageBackend {
+	case "memory":
+	case "sqlite":
+		if c.DatabasePath == "" {
+			return fmt.Errorf("DATABASE_PATH is required when STORAGE_BACKEND is sqlite")
+		}
+	default:
+		return fmt.Errorf("invalid STORAGE_BACKEND %q: must be memory or sqlite", c.StorageBackend)
+	}
+	
+	if c.OutboxPollInterval <= 0 {
+		return fmt.Errorf("invalid OUTBOX_POLL_INTERVAL %s: must be positive", c.OutboxPollInterval)
+	}
+	
+	switch c.OrderIDFormat {
+	case "uuidv7", "ulid":
+	default:
+		return fmt.Errorf("invalid ORDER_ID_FORMAT %q: must be uuidv7 or ulid", c.OrderIDFormat)
+	}
+	
+	if c.DeletedOrderRetention <= 0 {
+		return fmt.Errorf("invalid DELETED_ORDER_RETENTION %s: must be positive", c.DeletedOrderRetention)
+	}
+	if c.PurgeInterval <= 0 {
+		return fmt.Errorf("invalid PURGE_INTERVAL %s: must be positive", c.PurgeInterval)
+	}
+	
+	switch c.InventoryBackend {
+	case "memory", "external", "none":
+	default:
+		return fmt.Errorf("invalid INVENTORY_BACKEND %q: must be memory, external or none", c.InventoryBackend)
+	}
+	if c.InventoryInitialStock < 0 {
+		return fmt.Errorf("invalid INVENTORY_INITIAL_STOCK %d: cannot be negative", c.InventoryInitialStock)
+	}
+	
+	if c.IdempotencyTTL <= 0 {
+		return fmt.Errorf("invalid IDEMPOTENCY_TTL %s: must be positive", c.IdempotencyTTL)
+	}
+	
+	if c.IsProduction() {
+		if c.APIKey == "" {
+			return fmt.Errorf("API_KEY is required in production")
+		}
+		if c.JWTSecret == "" {
+			return fmt.Errorf("JWT_SECRET is required in production")
+		}
+	}
 	return nil
 }
 
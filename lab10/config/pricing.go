@@ -0,0 +1,43 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"lab10/internal/pricing"
+)
+
+// LoadPricing builds the order pricing pipeline from a JSON or YAML file,
+// chosen by the file extension (.json, .yaml, .yml).
+// An empty path returns a pipeline without rules, which prices every order
+// at its item subtotal.
+func LoadPricing(path string) (*pricing.Pipeline, error) {
+	if path == "" {
+		return pricing.NewPipeline(nil, nil), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing rules file: %w", err)
+	}
+
+	var def pricing.Definition
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &def)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &def)
+	default:
+		return nil, fmt.Errorf("unsupported pricing rules file %q: must be .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pricing rules file %q: %w", path, err)
+	}
+
+	return pricing.FromDefinition(def)
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"lab10/internal/domain"
+)
+
+// LoadStateMachine builds the order state machine from a JSON or YAML file,
+// chosen by the file extension (.json, .yaml, .yml).
+// An empty path returns the built-in default lifecycle.
+func LoadStateMachine(path string) (*domain.StateMachine, error) {
+	if path == "" {
+		return domain.DefaultStateMachine(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state machine file: %w", err)
+	}
+
+	var def domain.StateMachineDefinition
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &def)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &def)
+	default:
+		return nil, fmt.Errorf("unsupported state machine file %q: must be .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse state machine file %q: %w", path, err)
+	}
+
+	return domain.NewStateMachine(def)
+}
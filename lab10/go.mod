@@ -1,18 +1,22 @@
 module lab10
 
-go 1.22
+go 1.22.0
 
 require (
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.25.1
+	github.com/mattn/go-sqlite3 v1.14.24
 	golang-for-java-developers-training/common v0.0.0
+	google.golang.org/genproto/googleapis/api v0.0.0-20241219192143-6b3ec007d9bb
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20241219192143-6b3ec007d9bb
 	google.golang.org/grpc v1.70.0
 	google.golang.org/protobuf v1.36.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	golang.org/x/net v0.32.0 // indirect
+	golang.org/x/net v0.33.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
-	google.golang.org/genproto/googleapis/rpc v0.0.0-20241202173237-19429a94021a // indirect
 )
 
 replace golang-for-java-developers-training/common => ../common
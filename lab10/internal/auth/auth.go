@@ -0,0 +1,167 @@
+// Package auth authenticates API callers by API key or bearer JWT.
+//
+// The transports extract the credentials (the X-API-Key header or metadata,
+// and the bearer token of the Authorization header) and hand them to an
+// Authenticator. The Principal it returns is stored in the request context,
+// where handlers and the service layer can read it with FromContext.
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMissingCredentials indicates a request without an API key or bearer token.
+	ErrMissingCredentials = errors.New("missing credentials: send an API key or a bearer token")
+
+	// ErrInvalidCredentials indicates an API key or token that was rejected.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Method says how a principal authenticated.
+type Method string
+
+const (
+	MethodAPIKey Method = "api_key"
+	MethodJWT    Method = "jwt"
+)
+
+// APIKeySubject is the Subject of principals authenticated by API key.
+const APIKeySubject = "api-key"
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Subject string // the JWT "sub" claim, or APIKeySubject
+	Method  Method
+
+	// Claims holds all claims of the caller's JWT; nil for API keys.
+	Claims map[string]interface{}
+}
+
+// AdminRole is the role that lets a JWT caller use admin-only features.
+const AdminRole = "admin"
+
+// IsAdmin reports whether p may use admin-only features, such as reading
+// soft-deleted orders: callers with the API key, and JWT callers whose
+// "role" claim is AdminRole or whose "roles" claim lists it.
+func (p *Principal) IsAdmin() bool {
+	if p.Method == MethodAPIKey {
+		return true
+	}
+	if role, _ := p.Claims["role"].(string); role == AdminRole {
+		return true
+	}
+	roles, _ := p.Claims["roles"].([]interface{})
+	for _, role := range roles {
+		if role == AdminRole {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx by the transport, if any.
+// There is none when authentication is disabled or the path is exempt.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Authenticator checks API keys and JWTs. The zero value accepts no
+// credentials; configure it with options.
+type Authenticator struct {
+	apiKey  string
+	secret  []byte                    // HS256 key
+	rsaKeys map[string]*rsa.PublicKey // RS256 keys by key ID
+	leeway  time.Duration
+	now     func() time.Time
+}
+
+// Option configures an Authenticator.
+type Option func(*Authenticator)
+
+// WithAPIKey accepts key in the X-API-Key header.
+func WithAPIKey(key string) Option {
+	return func(a *Authenticator) {
+		a.apiKey = key
+	}
+}
+
+// WithHS256Secret accepts JWTs signed with HMAC-SHA256 using secret.
+func WithHS256Secret(secret string) Option {
+	return func(a *Authenticator) {
+		if secret != "" {
+			a.secret = []byte(secret)
+		}
+	}
+}
+
+// WithRSAKeys accepts JWTs signed with RS256 by any of keys, which are
+// looked up by the token's "kid" header. See LoadJWKS.
+func WithRSAKeys(keys map[string]*rsa.PublicKey) Option {
+	return func(a *Authenticator) {
+		a.rsaKeys = keys
+	}
+}
+
+// WithLeeway allows for clock skew when checking a token's exp and nbf claims.
+func WithLeeway(d time.Duration) Option {
+	return func(a *Authenticator) {
+		a.leeway = d
+	}
+}
+
+// DefaultLeeway is the clock skew allowed unless WithLeeway says otherwise.
+const DefaultLeeway = time.Minute
+
+// NewAuthenticator creates an Authenticator accepting the configured credentials.
+func NewAuthenticator(opts ...Option) *Authenticator {
+	a := &Authenticator{leeway: DefaultLeeway, now: time.Now}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Enabled reports whether any credentials are configured. Without them
+// the transports let every request through.
+func (a *Authenticator) Enabled() bool {
+	return a.apiKey != "" || a.secret != nil || len(a.rsaKeys) > 0
+}
+
+// Authenticate checks the API key, if one was sent, or else the bearer token
+// of the Authorization header value. A credential that is sent but invalid
+// is rejected even if the other one would have been accepted.
+func (a *Authenticator) Authenticate(apiKey, authorization string) (*Principal, error) {
+	if apiKey != "" {
+		if a.apiKey == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(a.apiKey)) != 1 {
+			return nil, ErrInvalidCredentials
+		}
+		return &Principal{Subject: APIKeySubject, Method: MethodAPIKey}, nil
+	}
+
+	if authorization == "" {
+		return nil, ErrMissingCredentials
+	}
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, ErrInvalidCredentials
+	}
+	claims, err := a.verifyJWT(strings.TrimSpace(token))
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{Subject: claims.subject, Method: MethodJWT, Claims: claims.all}, nil
+}
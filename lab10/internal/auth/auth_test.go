@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// signJWT returns a compact JWT with the given header and claims, signed
+// with key: a []byte for HS256, an *rsa.PrivateKey for RS256, nil for none.
+func signJWT(t *testing.T, header, claims map[string]interface{}, key interface{}) string {
+	t.Helper()
+	segment := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	input := segment(header) + "." + segment(claims)
+
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(input))
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func newTestAuthenticator(opts ...Option) *Authenticator {
+	a := NewAuthenticator(opts...)
+	a.now = func() time.Time { return testNow }
+	return a
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	a := newTestAuthenticator(WithAPIKey("s3cret"), WithHS256Secret("jwt-secret"))
+
+	p, err := a.Authenticate("s3cret", "")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if p.Subject != APIKeySubject || p.Method != MethodAPIKey {
+		t.Errorf("Authenticate() = %+v, want API key principal", p)
+	}
+
+	// A wrong key is rejected even alongside a valid token
+	token := signJWT(t, map[string]interface{}{"alg": "HS256"}, map[string]interface{}{"sub": "u1"}, []byte("jwt-secret"))
+	if _, err := a.Authenticate("wrong", "Bearer "+token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Authenticate(wrong key) error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := a.Authenticate("", ""); !errors.Is(err, ErrMissingCredentials) {
+		t.Errorf("Authenticate() without credentials error = %v, want ErrMissingCredentials", err)
+	}
+	if _, err := newTestAuthenticator(WithHS256Secret("jwt-secret")).Authenticate("s3cret", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Authenticate() without configured key error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestAuthenticateHS256(t *testing.T) {
+	secret := []byte("jwt-secret")
+	a := newTestAuthenticator(WithHS256Secret(string(secret)))
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	exp := testNow.Add(time.Hour).Unix()
+
+	token := signJWT(t, hs256, map[string]interface{}{"sub": "user-1", "exp": exp, "role": "admin"}, secret)
+	p, err := a.Authenticate("", "Bearer "+token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if p.Subject != "user-1" || p.Method != MethodJWT || p.Claims["role"] != "admin" {
+		t.Errorf("Authenticate() = %+v, want JWT principal user-1 with role claim", p)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{"wrong secret", "Bearer " + signJWT(t, hs256, map[string]interface{}{"sub": "user-1"}, []byte("other"))},
+		{"expired", "Bearer " + signJWT(t, hs256, map[string]interface{}{"sub": "user-1", "exp": testNow.Add(-2 * DefaultLeeway).Unix()}, secret)},
+		{"not yet valid", "Bearer " + signJWT(t, hs256, map[string]interface{}{"sub": "user-1", "nbf": testNow.Add(2 * DefaultLeeway).Unix()}, secret)},
+		{"exp not a number", "Bearer " + signJWT(t, hs256, map[string]interface{}{"sub": "user-1", "exp": "tomorrow"}, secret)},
+		{"no subject", "Bearer " + signJWT(t, hs256, map[string]interface{}{"exp": exp}, secret)},
+		{"alg none", "Bearer " + signJWT(t, map[string]interface{}{"alg": "none"}, map[string]interface{}{"sub": "user-1"}, nil)},
+		{"RS256 not configured", "Bearer " + signJWT(t, map[string]interface{}{"alg": "RS256"}, map[string]interface{}{"sub": "user-1"}, secret)},
+		{"malformed", "Bearer not-a-jwt"},
+		{"basic scheme", "Basic dXNlcjpwYXNz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.Authenticate("", tt.authorization); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("Authenticate() error = %v, want ErrInvalidCredentials", err)
+			}
+		})
+	}
+
+	// Within the leeway an expired token is still accepted
+	token = signJWT(t, hs256, map[string]interface{}{"sub": "user-1", "exp": testNow.Add(-DefaultLeeway / 2).Unix()}, secret)
+	if _, err := a.Authenticate("", "bearer "+token); err != nil {
+		t.Errorf("Authenticate() within leeway error = %v", err)
+	}
+}
+
+func TestAuthenticateRS256WithJWKS(t *testing.T) {
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256", "n": %q, "e": %q},
+		{"kty": "RSA", "kid": "k2", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": "AA", "y": "AA"},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AA", "e": "AQAB"}
+	]}`, encodeInt(key1.N), encodeInt(big.NewInt(int64(key1.E))), encodeInt(key2.N), encodeInt(big.NewInt(int64(key2.E))))
+
+	keys, err := ParseJWKS([]byte(jwks))
+	if err != nil {
+		t.Fatalf("ParseJWKS() error = %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("ParseJWKS() returned %d keys, want 2", len(keys))
+	}
+
+	a := newTestAuthenticator(WithRSAKeys(keys))
+	claims := map[string]interface{}{"sub": "svc", "exp": testNow.Add(time.Minute).Unix()}
+
+	for kid, key := range map[string]*rsa.PrivateKey{"k1": key1, "k2": key2} {
+		token := signJWT(t, map[string]interface{}{"alg": "RS256", "kid": kid}, claims, key)
+		if p, err := a.Authenticate("", "Bearer "+token); err != nil || p.Subject != "svc" {
+			t.Errorf("Authenticate() with kid %s = %+v, %v", kid, p, err)
+		}
+	}
+
+	rejected := map[string]string{
+		"wrong key":   signJWT(t, map[string]interface{}{"alg": "RS256", "kid": "k1"}, claims, key2),
+		"unknown kid": signJWT(t, map[string]interface{}{"alg": "RS256", "kid": "k3"}, claims, key1),
+		"no kid":      signJWT(t, map[string]interface{}{"alg": "RS256"}, claims, key1),
+		// The public key must not double as an HMAC secret
+		"HS256 with public key": signJWT(t, map[string]interface{}{"alg": "HS256", "kid": "k1"}, claims, key1.N.Bytes()),
+	}
+	for name, token := range rejected {
+		if _, err := a.Authenticate("", "Bearer "+token); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Authenticate(%s) error = %v, want ErrInvalidCredentials", name, err)
+		}
+	}
+}
+
+func TestParseJWKSErrors(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"not JSON":      `{`,
+		"no RSA keys":   `{"keys": [{"kty": "EC", "kid": "ec"}]}`,
+		"short key":     fmt.Sprintf(`{"keys": [{"kty": "RSA", "kid": "k", "n": %q, "e": "AQAB"}]}`, encodeInt(small.N)),
+		"bad modulus":   `{"keys": [{"kty": "RSA", "kid": "k", "n": "!!", "e": "AQAB"}]}`,
+		"duplicate kid": fmt.Sprintf(`{"keys": [{"kty": "RSA", "kid": "k", "n": %[1]q, "e": "AQAB"}, {"kty": "RSA", "kid": "k", "n": %[1]q, "e": "AQAB"}]}`, encodeInt(new(big.Int).Lsh(big.NewInt(1), 2048))),
+	}
+	for name, jwks := range tests {
+		if _, err := ParseJWKS([]byte(jwks)); err == nil {
+			t.Errorf("ParseJWKS(%s) succeeded, want error", name)
+		}
+	}
+}
+
+func TestAuthenticatorEnabled(t *testing.T) {
+	if NewAuthenticator().Enabled() {
+		t.Error("Enabled() = true without credentials")
+	}
+	if NewAuthenticator(WithAPIKey(""), WithHS256Secret("")).Enabled() {
+		t.Error("Enabled() = true with empty credentials")
+	}
+	if !NewAuthenticator(WithHS256Secret("s")).Enabled() {
+		t.Error("Enabled() = false with an HS256 secret")
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("FromContext() found a principal in an empty context")
+	}
+	want := &Principal{Subject: "u1", Method: MethodJWT}
+	if got, ok := FromContext(NewContext(context.Background(), want)); !ok || got != want {
+		t.Errorf("FromContext() = %v, %v, want %v", got, ok, want)
+	}
+}
+
+func TestPrincipalIsAdmin(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal Principal
+		want      bool
+	}{
+		{"API key", Principal{Subject: APIKeySubject, Method: MethodAPIKey}, true},
+		{"JWT without roles", Principal{Subject: "u1", Method: MethodJWT, Claims: map[string]interface{}{}}, false},
+		{"JWT admin role", Principal{Subject: "u1", Method: MethodJWT, Claims: map[string]interface{}{"role": "admin"}}, true},
+		{"JWT other role", Principal{Subject: "u1", Method: MethodJWT, Claims: map[string]interface{}{"role": "viewer"}}, false},
+		{"JWT admin in roles", Principal{Subject: "u1", Method: MethodJWT, Claims: map[string]interface{}{"roles": []interface{}{"viewer", "admin"}}}, true},
+		{"JWT roles without admin", Principal{Subject: "u1", Method: MethodJWT, Claims: map[string]interface{}{"roles": []interface{}{"viewer"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.IsAdmin(); got != tt.want {
+				t.Errorf("IsAdmin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func encodeInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// jwtHeader is the JOSE header of a JWT.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// verifiedClaims are the claims of a token whose signature and lifetime checked out.
+type verifiedClaims struct {
+	subject string
+	all     map[string]interface{}
+}
+
+// verifyJWT checks a compact-serialized JWT: its signature with the key for
+// its alg (HS256 or RS256; "none" and everything else is rejected), its exp
+// and nbf claims, and that it names a subject.
+func (a *Authenticator) verifyJWT(token string) (verifiedClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return verifiedClaims{}, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	signingInput := parts[0] + "." + parts[1]
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return verifiedClaims{}, fmt.Errorf("%w: malformed token header", ErrInvalidCredentials)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return verifiedClaims{}, fmt.Errorf("%w: malformed token signature", ErrInvalidCredentials)
+	}
+
+	switch header.Alg {
+	case "HS256":
+		if a.secret == nil {
+			return verifiedClaims{}, fmt.Errorf("%w: HS256 tokens are not accepted", ErrInvalidCredentials)
+		}
+		mac := hmac.New(sha256.New, a.secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return verifiedClaims{}, fmt.Errorf("%w: bad token signature", ErrInvalidCredentials)
+		}
+	case "RS256":
+		key, err := a.rsaKey(header.Kid)
+		if err != nil {
+			return verifiedClaims{}, err
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return verifiedClaims{}, fmt.Errorf("%w: bad token signature", ErrInvalidCredentials)
+		}
+	default:
+		return verifiedClaims{}, fmt.Errorf("%w: unsupported token algorithm %q", ErrInvalidCredentials, header.Alg)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return verifiedClaims{}, fmt.Errorf("%w: malformed token claims", ErrInvalidCredentials)
+	}
+	if err := a.checkLifetime(claims); err != nil {
+		return verifiedClaims{}, err
+	}
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return verifiedClaims{}, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+	return verifiedClaims{subject: subject, all: claims}, nil
+}
+
+// rsaKey returns the RS256 key with the given key ID. A token without a
+// key ID is accepted if there is exactly one key.
+func (a *Authenticator) rsaKey(kid string) (*rsa.PublicKey, error) {
+	if len(a.rsaKeys) == 0 {
+		return nil, fmt.Errorf("%w: RS256 tokens are not accepted", ErrInvalidCredentials)
+	}
+	if kid == "" && len(a.rsaKeys) == 1 {
+		for _, key := range a.rsaKeys {
+			return key, nil
+		}
+	}
+	key, ok := a.rsaKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown token key %q", ErrInvalidCredentials, kid)
+	}
+	return key, nil
+}
+
+// checkLifetime rejects tokens past their exp or before their nbf claim.
+// Both are optional.
+func (a *Authenticator) checkLifetime(claims map[string]interface{}) error {
+	now := a.now()
+	if exp, ok, err := numericDate(claims, "exp"); err != nil {
+		return err
+	} else if ok && !now.Before(exp.Add(a.leeway)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidCredentials)
+	}
+	if nbf, ok, err := numericDate(claims, "nbf"); err != nil {
+		return err
+	} else if ok && now.Add(a.leeway).Before(nbf) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// numericDate reads a NumericDate claim (seconds since the epoch).
+func numericDate(claims map[string]interface{}, name string) (time.Time, bool, error) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: %s claim is not a number", ErrInvalidCredentials, name)
+	}
+	f, err := n.Float64()
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return time.Time{}, false, fmt.Errorf("%w: %s claim is not a number", ErrInvalidCredentials, name)
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), true, nil
+}
+
+// decodeSegment decodes a base64url-encoded JSON segment of a token.
+// Numbers are kept as json.Number so large claim values aren't rounded.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
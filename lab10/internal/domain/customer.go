@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CustomerStatus tells whether a customer may place new orders.
+type CustomerStatus string
+
+const (
+	CustomerActive   CustomerStatus = "active"
+	CustomerInactive CustomerStatus = "inactive"
+)
+
+// MaxCustomerIDLength caps the length of customer IDs.
+const MaxCustomerIDLength = 128
+
+// Customer is the party an order is placed for. Orders refer to it by ID.
+// Inactive customers keep their existing orders but can't place new ones.
+type Customer struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Email     string         `json:"email"`
+	Status    CustomerStatus `json:"status"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+
+	// Version starts at 1 and is incremented on every update, like Order.Version.
+	Version int64 `json:"version"`
+}
+
+// IsActive reports whether the customer may place new orders.
+func (c *Customer) IsActive() bool {
+	return c.Status == CustomerActive
+}
+
+// ValidateCustomerID checks a customer ID follows the same rules as order IDs.
+func ValidateCustomerID(id string) error {
+	return validateID("customer", id, MaxCustomerIDLength)
+}
+
+// Validate checks if the customer meets business rules.
+func (c *Customer) Validate() error {
+	if err := ValidateCustomerID(c.ID); err != nil {
+		return err
+	}
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("customer name is required")
+	}
+	if c.Email == "" {
+		return errors.New("customer email is required")
+	}
+	if addr, err := mail.ParseAddress(c.Email); err != nil || addr.Address != c.Email {
+		return fmt.Errorf("invalid customer email %q", c.Email)
+	}
+	switch c.Status {
+	case CustomerActive, CustomerInactive:
+	default:
+		return fmt.Errorf("invalid customer status %q: must be %s or %s", c.Status, CustomerActive, CustomerInactive)
+	}
+	return nil
+}
+
+// CustomerStats summarizes the orders of one customer. Deleted orders are
+// not counted.
+type CustomerStats struct {
+	CustomerID string `json:"customer_id"`
+	OrderCount int    `json:"order_count"`
+
+	// LifetimeValue is the total of all orders that weren't cancelled, with
+	// one amount per currency (sorted by currency code). Empty if there are none.
+	LifetimeValue []Money `json:"lifetime_value"`
+
+	// LastOrderAt is when the most recent order was created; nil if there are none.
+	LastOrderAt *time.Time `json:"last_order_at,omitempty"`
+}
+
+// AddOrder counts order towards the stats.
+func (s *CustomerStats) AddOrder(order *Order) error {
+	s.OrderCount++
+	if s.LastOrderAt == nil || order.CreatedAt.After(*s.LastOrderAt) {
+		createdAt := order.CreatedAt
+		s.LastOrderAt = &createdAt
+	}
+	if order.Status == StatusCancelled {
+		return nil
+	}
+	return s.addValue(order.TotalAmount)
+}
+
+// addValue adds amount to the lifetime value in its currency.
+func (s *CustomerStats) addValue(amount Money) error {
+	i, found := slices.BinarySearchFunc(s.LifetimeValue, amount.Currency, func(m Money, currency string) int {
+		return strings.Compare(m.Currency, currency)
+	})
+	if !found {
+		s.LifetimeValue = slices.Insert(s.LifetimeValue, i, Zero(amount.Currency))
+	}
+	sum, err := s.LifetimeValue[i].Add(amount)
+	if err != nil {
+		return err
+	}
+	s.LifetimeValue[i] = sum
+	return nil
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+// EventType identifies what happened to an order.
+type EventType string
+
+const (
+	EventOrderCreated       EventType = "order.created"
+	EventOrderUpdated       EventType = "order.updated"
+	EventOrderStatusChanged EventType = "order.status_changed"
+	EventOrderDeleted       EventType = "order.deleted"
+	EventOrderRestored      EventType = "order.restored"
+	EventOrderPurged        EventType = "order.purged"
+)
+
+// Event is a domain event describing a change to an order.
+// Events are recorded in the repository's outbox in the same transaction as
+// the change itself, then delivered to subscribers by a relay. Delivery is
+// at-least-once, so consumers should de-duplicate by ID.
+type Event struct {
+	// ID is the outbox sequence number, assigned by the repository.
+	// IDs increase in the order the changes were committed.
+	ID int64 `json:"id"`
+
+	Type       EventType `json:"type"`
+	OrderID    string    `json:"order_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+
+	// Order is the order as it is after the change, or for EventOrderPurged
+	// as it was just before it was permanently removed.
+	Order *Order `json:"order,omitempty"`
+
+	// StatusChange is set for EventOrderStatusChanged.
+	StatusChange *StatusChange `json:"status_change,omitempty"`
+}
+
+// NewOrderEvent builds an event of type t for the order's current state.
+// The order is copied so later changes to it don't leak into the event.
+func NewOrderEvent(t EventType, order *Order, occurredAt time.Time) Event {
+	orderCopy := *order
+	orderCopy.Items = append([]LineItem{}, order.Items...)
+	return Event{
+		Type:       t,
+		OrderID:    order.ID,
+		OccurredAt: occurredAt,
+		Order:      &orderCopy,
+	}
+}
@@ -0,0 +1,270 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is assumed for legacy amounts sent as bare numbers without a currency.
+const DefaultCurrency = "USD"
+
+var (
+	// ErrUnknownCurrency indicates a currency code that isn't a supported ISO 4217 code.
+	ErrUnknownCurrency = errors.New("unknown currency")
+
+	// ErrCurrencyMismatch indicates arithmetic or comparison between different currencies.
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+
+	// ErrAmountOverflow indicates an amount too large to represent in minor units.
+	ErrAmountOverflow = errors.New("amount overflow")
+)
+
+// currencyExponents maps supported ISO 4217 currency codes to the number of
+// digits after the decimal point in their minor unit (e.g. USD cents = 2).
+var currencyExponents = map[string]int{
+	"AUD": 2, "BRL": 2, "CAD": 2, "CHF": 2, "CNY": 2, "DKK": 2, "EUR": 2,
+	"GBP": 2, "HKD": 2, "INR": 2, "MXN": 2, "NOK": 2, "NZD": 2, "PLN": 2,
+	"SEK": 2, "SGD": 2, "USD": 2, "ZAR": 2,
+	"CLP": 0, "ISK": 0, "JPY": 0, "KRW": 0, "VND": 0,
+	"BHD": 3, "JOD": 3, "KWD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent returns the number of minor-unit digits for an ISO 4217 currency.
+func CurrencyExponent(currency string) (int, error) {
+	exp, ok := currencyExponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return exp, nil
+}
+
+// Money is an exact monetary amount: an integer count of the currency's minor
+// units (cents for USD, yen for JPY, fils for KWD) plus its ISO 4217 code.
+// Never use float64 for money - it can't represent most decimal fractions exactly.
+type Money struct {
+	Amount   int64  // minor units
+	Currency string // ISO 4217 code, e.g. "USD"
+}
+
+// NewMoney parses a decimal string such as "12.34" into Money.
+// Extra fractional digits beyond the currency's minor unit are rounded half
+// away from zero (e.g. "0.125" USD becomes 0.13).
+func NewMoney(decimal, currency string) (Money, error) {
+	exp, err := CurrencyExponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	s := strings.TrimSpace(decimal)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("invalid amount %q", decimal)
+	}
+
+	// Pad or cut the fraction to exactly exp digits, remembering the first dropped digit
+	roundUp := len(frac) > exp && frac[exp] >= '5'
+	if len(frac) > exp {
+		frac = frac[:exp]
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if whole+frac == "" {
+		amount, err = 0, nil
+	}
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrAmountOverflow, decimal)
+	}
+	if roundUp {
+		if amount == math.MaxInt64 {
+			return Money{}, fmt.Errorf("%w: %q", ErrAmountOverflow, decimal)
+		}
+		amount++
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// MustMoney is like NewMoney but panics on error. For constants and tests only.
+func MustMoney(decimal, currency string) Money {
+	m, err := NewMoney(decimal, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Zero returns a zero amount in the given currency.
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + other. Both must be in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m - other. Both must be in the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Mul returns m multiplied by an integer factor (e.g. a quantity).
+func (m Money) Mul(n int64) (Money, error) {
+	// The product overflowed unless dividing it by each factor gives the
+	// other; MinInt64 * -1 wraps to MinInt64 and only fails one of the checks
+	product := m.Amount * n
+	if m.Amount != 0 && n != 0 && (product/n != m.Amount || product/m.Amount != n) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+
+// Percent returns basisPoints hundredths of a percent of m (2500 is 25%),
+// rounded half away from zero to the currency's minor unit.
+func (m Money) Percent(basisPoints int64) (Money, error) {
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(basisPoints))
+	quotient, remainder := new(big.Int).QuoRem(product, big.NewInt(10000), new(big.Int))
+	if remainder.Abs(remainder).Cmp(big.NewInt(5000)) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(product.Sign())))
+	}
+	if !quotient.IsInt64() {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: quotient.Int64(), Currency: m.Currency}, nil
+}
+
+// Compare returns -1, 0 or 1 as m is less than, equal to or greater than other.
+// Both must be in the same currency.
+func (m Money) Compare(other Money) (int, error) {
+	if m.Currency != other.Currency {
+		return 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Validate checks the currency is a supported ISO 4217 code.
+func (m Money) Validate() error {
+	_, err := CurrencyExponent(m.Currency)
+	return err
+}
+
+// Decimal formats the amount as a decimal string with exactly the currency's
+// number of fraction digits, e.g. "12.30" for USD or "1230" for JPY.
+func (m Money) Decimal() string {
+	exp, err := CurrencyExponent(m.Currency)
+	if err != nil {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		abs = uint64(-m.Amount)
+	}
+	digits := strconv.FormatUint(abs, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String formats the amount for display, e.g. "12.30 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// moneyJSON is the wire form of Money: the amount is a decimal string so no
+// JSON client ever parses it into a float.
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes Money as {"amount": "12.30", "currency": "USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON decodes {"amount": "12.30", "currency": "USD"}.
+// For compatibility with older clients a bare JSON number is also accepted
+// and read exactly (not via float64) in DefaultCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "null" {
+		return nil
+	}
+	if !strings.HasPrefix(trimmed, "{") {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid money value %s", trimmed)
+		}
+		parsed, err := NewMoney(n.String(), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parsed, err := NewMoney(raw.Amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// isDigits reports whether s contains only ASCII digits (empty is allowed).
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
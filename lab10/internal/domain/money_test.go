@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestNewMoney checks decimal parsing and per-currency rounding.
+func TestNewMoney(t *testing.T) {
+	tests := []struct {
+		decimal   string
+		currency  string
+		want      int64
+		wantError bool
+	}{
+		{"12.34", "USD", 1234, false},
+		{"12.3", "USD", 1230, false},
+		{"12", "USD", 1200, false},
+		{"0.125", "USD", 13, false}, // half away from zero
+		{"0.124", "USD", 12, false},
+		{"-0.125", "USD", -13, false},
+		{"1234.5", "JPY", 1235, false},
+		{"1.2345", "KWD", 1235, false},
+		{".5", "USD", 50, false},
+		{"abc", "USD", 0, true},
+		{"1.2.3", "USD", 0, true},
+		{"", "USD", 0, true},
+		{"10.00", "XXX", 0, true},
+		{"99999999999999999999", "USD", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.decimal+" "+tt.currency, func(t *testing.T) {
+			got, err := NewMoney(tt.decimal, tt.currency)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("NewMoney() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewMoney() error = %v", err)
+			}
+			if got.Amount != tt.want {
+				t.Errorf("NewMoney() amount = %d, want %d", got.Amount, tt.want)
+			}
+		})
+	}
+}
+
+// TestMoneyDecimal checks formatting uses the currency's minor-unit digits.
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{Amount: 1234, Currency: "USD"}, "12.34"},
+		{Money{Amount: 5, Currency: "USD"}, "0.05"},
+		{Money{Amount: -5, Currency: "USD"}, "-0.05"},
+		{Money{Amount: 1234, Currency: "JPY"}, "1234"},
+		{Money{Amount: 1234, Currency: "BHD"}, "1.234"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.Decimal(); got != tt.want {
+			t.Errorf("%#v.Decimal() = %q, want %q", tt.money, got, tt.want)
+		}
+	}
+}
+
+// TestMoneyJSON checks the JSON round trip and the legacy bare-number form.
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(MustMoney("0.10", "EUR"))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != `{"amount":"0.10","currency":"EUR"}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+
+	var m Money
+	if err := json.Unmarshal(data, &m); err != nil || m != MustMoney("0.10", "EUR") {
+		t.Errorf("Unmarshal() = %v, %v", m, err)
+	}
+
+	if err := json.Unmarshal([]byte(`0.1`), &m); err != nil || m != MustMoney("0.10", DefaultCurrency) {
+		t.Errorf("Unmarshal(legacy number) = %v, %v", m, err)
+	}
+}
+
+// TestCalculateTotalExact checks totals don't drift the way float64 sums do.
+func TestCalculateTotalExact(t *testing.T) {
+	order := &Order{}
+	for i := 0; i < 10; i++ {
+		order.Items = append(order.Items, LineItem{ProductID: "SKU", Quantity: 1, UnitPrice: MustMoney("0.10", "USD")})
+	}
+	if err := order.CalculateTotal(); err != nil {
+		t.Fatalf("CalculateTotal() error = %v", err)
+	}
+	if order.TotalAmount != MustMoney("1.00", "USD") {
+		t.Errorf("TotalAmount = %v, want 1.00 USD", order.TotalAmount)
+	}
+
+	order.Items = append(order.Items, LineItem{ProductID: "SKU", Quantity: 1, UnitPrice: MustMoney("1", "EUR")})
+	if err := order.CalculateTotal(); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("CalculateTotal() error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+}
+
+// TestMoneyPercent checks basis-point percentages round half away from zero.
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		money       Money
+		basisPoints int64
+		want        int64
+	}{
+		{MustMoney("100.00", "USD"), 1000, 1000},
+		{MustMoney("85.50", "USD"), 725, 620}, // 6.19875
+		{MustMoney("0.10", "USD"), 2500, 3},   // 0.025
+		{MustMoney("-0.10", "USD"), 2500, -3},
+		{MustMoney("0.10", "USD"), 2400, 2},
+		{MustMoney("999", "JPY"), 1000, 100},
+		{MustMoney("12.34", "USD"), 0, 0},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.money.Percent(tt.basisPoints)
+		if err != nil {
+			t.Fatalf("%v.Percent(%d) error = %v", tt.money, tt.basisPoints, err)
+		}
+		if got.Amount != tt.want || got.Currency != tt.money.Currency {
+			t.Errorf("%v.Percent(%d) = %v, want %d minor units", tt.money, tt.basisPoints, got, tt.want)
+		}
+	}
+
+	if _, err := (Money{Amount: math.MaxInt64, Currency: "USD"}).Percent(20000); !errors.Is(err, ErrAmountOverflow) {
+		t.Errorf("Percent() error = %v, want %v", err, ErrAmountOverflow)
+	}
+}
+
+func TestMoneyMul(t *testing.T) {
+	tests := []struct {
+		amount       int64
+		n            int64
+		want         int64
+		wantOverflow bool
+	}{
+		{1234, 3, 3702, false},
+		{-1234, 3, -3702, false},
+		{1234, 0, 0, false},
+		{0, math.MinInt64, 0, false},
+		{math.MaxInt64, 1, math.MaxInt64, false},
+		{math.MaxInt64, -1, -math.MaxInt64, false},
+		{math.MinInt64, 1, math.MinInt64, false},
+		{math.MinInt64, -1, 0, true},
+		{-1, math.MinInt64, 0, true},
+		{math.MaxInt64, 2, 0, true},
+		{math.MinInt64 / 2, 3, 0, true},
+		{1 << 32, 1 << 31, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := (Money{Amount: tt.amount, Currency: "USD"}).Mul(tt.n)
+		if tt.wantOverflow {
+			if !errors.Is(err, ErrAmountOverflow) {
+				t.Errorf("%d.Mul(%d) = %v, %v; want %v", tt.amount, tt.n, got, err, ErrAmountOverflow)
+			}
+			continue
+		}
+		if err != nil || got.Amount != tt.want || got.Currency != "USD" {
+			t.Errorf("%d.Mul(%d) = %v, %v; want %d minor units", tt.amount, tt.n, got, err, tt.want)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package domain
 
 import (
-	"errors"
+	"fmt"
 	"time"
 )
 
@@ -18,15 +18,15 @@ const (
 
 // LineItem represents a single item in an order with quantity and price.
 type LineItem struct {
-	ProductID   string  `json:"product_id"`
-	ProductName string  `json:"product_name"`
-	Quantity    int     `json:"quantity"`
-	UnitPrice   float64 `json:"unit_price"`
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   Money  `json:"unit_price"`
 }
 
 // Subtotal calculates the total price for this line item (quantity * unit price).
-func (li LineItem) Subtotal() float64 {
-	return float64(li.Quantity) * li.UnitPrice
+func (li LineItem) Subtotal() (Money, error) {
+	return li.UnitPrice.Mul(int64(li.Quantity))
 }
 
 // Order represents a customer order with line items and status tracking.
@@ -36,62 +36,241 @@ type Order struct {
 	CustomerID  string      `json:"customer_id"`
 	Items       []LineItem  `json:"items"`
 	Status      OrderStatus `json:"status"`
-	TotalAmount float64     `json:"total_amount"`
+	TotalAmount Money       `json:"total_amount"`
 	CreatedAt   time.Time   `json:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at"`
+
+	// Version starts at 1 and is incremented on every update.
+	// Used for optimistic concurrency control (compare-and-swap updates).
+	Version int64 `json:"version"`
+
+	// DeletedAt is set when the order is soft-deleted. Deleted orders are
+	// hidden from normal reads until restored or permanently purged.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// CouponCode and Region are inputs to pricing: an optional discount code
+	// and the region whose tax rate applies.
+	CouponCode string `json:"coupon_code,omitempty"`
+	Region     string `json:"region,omitempty"`
+
+	// Pricing explains how TotalAmount was reached. Nil for orders priced
+	// before the pricing pipeline existed, whose total is the plain item sum.
+	Pricing *PriceBreakdown `json:"pricing,omitempty"`
+}
+
+// IsDeleted reports whether the order has been soft-deleted.
+func (o *Order) IsDeleted() bool {
+	return o.DeletedAt != nil
+}
+
+// StatusChange records a single status transition of an order.
+// Changes are append-only: once recorded they are never modified or removed
+// (except when the order itself is deleted), giving an audit trail of who
+// moved the order through its lifecycle and why.
+type StatusChange struct {
+	FromStatus OrderStatus `json:"from_status"`
+	ToStatus   OrderStatus `json:"to_status"`
+	Actor      string      `json:"actor"`
+	Reason     string      `json:"reason,omitempty"`
+	Timestamp  time.Time   `json:"timestamp"`
+}
+
+// Currency returns the order's currency, taken from its first line item.
+// Validate guarantees every line item uses the same currency.
+func (o *Order) Currency() string {
+	if len(o.Items) > 0 {
+		return o.Items[0].UnitPrice.Currency
+	}
+	if o.TotalAmount.Currency != "" {
+		return o.TotalAmount.Currency
+	}
+	return DefaultCurrency
 }
 
 // CalculateTotal computes the total amount from all line items.
 // Called when order is created or items are modified.
-func (o *Order) CalculateTotal() {
-	total := 0.0
+// Fails if line items use different currencies or the total overflows.
+func (o *Order) CalculateTotal() error {
+	total := Zero(o.Currency())
 	for _, item := range o.Items {
-		total += item.Subtotal()
+		subtotal, err := item.Subtotal()
+		if err != nil {
+			return err
+		}
+		if total, err = total.Add(subtotal); err != nil {
+			return err
+		}
 	}
 	o.TotalAmount = total
+	return nil
+}
+
+// ItemEditOp is the kind of change made to an order's line items.
+type ItemEditOp string
+
+const (
+	ItemEditAdd         ItemEditOp = "add"
+	ItemEditRemove      ItemEditOp = "remove"
+	ItemEditSetQuantity ItemEditOp = "set_quantity"
+)
+
+// ItemEdit is a single change to an order's line items.
+// Add uses Item; remove uses ProductID; set_quantity uses ProductID and Quantity.
+type ItemEdit struct {
+	Op        ItemEditOp `json:"op"`
+	Item      *LineItem  `json:"item,omitempty"`
+	ProductID string     `json:"product_id,omitempty"`
+	Quantity  int        `json:"quantity,omitempty"`
+}
+
+// ApplyItemEdits applies edits to the order's line items in order.
+// Products are identified by ProductID, so each product may appear only once.
+// The caller should Validate and CalculateTotal afterwards.
+func (o *Order) ApplyItemEdits(edits []ItemEdit) error {
+	items := append([]LineItem{}, o.Items...)
+	indexOf := func(productID string) int {
+		for i, item := range items {
+			if item.ProductID == productID {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for n, edit := range edits {
+		switch edit.Op {
+		case ItemEditAdd:
+			if edit.Item == nil {
+				return fmt.Errorf("edit %d: add requires an item", n)
+			}
+			if indexOf(edit.Item.ProductID) >= 0 {
+				return fmt.Errorf("edit %d: product %s is already in the order, use set_quantity", n, edit.Item.ProductID)
+			}
+			items = append(items, *edit.Item)
+		case ItemEditRemove:
+			i := indexOf(edit.ProductID)
+			if i < 0 {
+				return fmt.Errorf("edit %d: product %s is not in the order", n, edit.ProductID)
+			}
+			items = append(items[:i], items[i+1:]...)
+		case ItemEditSetQuantity:
+			i := indexOf(edit.ProductID)
+			if i < 0 {
+				return fmt.Errorf("edit %d: product %s is not in the order", n, edit.ProductID)
+			}
+			if edit.Quantity <= 0 {
+				return fmt.Errorf("edit %d: quantity must be positive, use remove to drop an item", n)
+			}
+			items[i].Quantity = edit.Quantity
+		default:
+			return fmt.Errorf("edit %d: unknown operation %q", n, edit.Op)
+		}
+	}
+
+	o.Items = items
+	return nil
+}
+
+// MaxOrderIDLength caps the length of order IDs.
+const MaxOrderIDLength = 128
+
+const (
+	// MaxCouponCodeLength caps the length of coupon codes.
+	MaxCouponCodeLength = 64
+
+	// MaxRegionLength caps the length of region codes such as "US-CA".
+	MaxRegionLength = 16
+)
+
+// reservedOrderIDs name endpoints under /v1/orders/, such as GET
+// /v1/orders/watch, so orders with these IDs couldn't be addressed.
+var reservedOrderIDs = map[string]bool{"watch": true, "export": true, "import": true}
+
+// ValidateOrderID checks an order ID is non-empty, not too long and uses only
+// URL-safe characters (letters, digits, '-', '_', '.' and '~'), so it can be
+// used as a path segment without escaping. IDs that are paths of other
+// endpoints, like "watch", are reserved.
+func ValidateOrderID(id string) error {
+	if err := validateID("order", id, MaxOrderIDLength); err != nil {
+		return err
+	}
+	if reservedOrderIDs[id] {
+		return fmt.Errorf("order ID %q is reserved", id)
+	}
+	return nil
+}
+
+// validateID applies the ValidateOrderID rules to the ID of any entity kind.
+func validateID(kind, id string, maxLength int) error {
+	if id == "" {
+		return fmt.Errorf("%s ID is required", kind)
+	}
+	if len(id) > maxLength {
+		return fmt.Errorf("%s ID must be at most %d characters", kind, maxLength)
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == '~':
+		default:
+			return fmt.Errorf("%s ID contains invalid character %q", kind, c)
+		}
+	}
+	if id == "." || id == ".." {
+		return fmt.Errorf("%s ID %q is reserved", kind, id)
+	}
+	return nil
 }
 
 // Validate checks if the order meets business rules.
-// Returns error if validation fails.
+// Returns a *ValidationError listing every violation, with field paths as in
+// the order's JSON form (e.g. "items[2].quantity"), or nil if the order is valid.
 func (o *Order) Validate() error {
+	verr := &ValidationError{}
+	if err := ValidateOrderID(o.ID); err != nil {
+		verr.Add("id", err.Error())
+	}
 	if o.CustomerID == "" {
-		return errors.New("customer ID is required")
+		verr.Add("customer_id", "customer ID is required")
 	}
 	if len(o.Items) == 0 {
-		return errors.New("order must have at least one item")
+		verr.Add("items", "order must have at least one item")
+	}
+	if len(o.CouponCode) > MaxCouponCodeLength {
+		verr.Addf("coupon_code", "coupon code must be at most %d characters", MaxCouponCodeLength)
 	}
+	if len(o.Region) > MaxRegionLength {
+		verr.Addf("region", "region must be at most %d characters", MaxRegionLength)
+	}
+
+	currency := ""
 	for i, item := range o.Items {
+		field := fmt.Sprintf("items[%d].", i)
 		if item.ProductID == "" {
-			return errors.New("product ID is required for all items")
+			verr.Add(field+"product_id", "product ID is required")
+		}
+		if item.ProductName == "" {
+			verr.Add(field+"product_name", "product name is required")
 		}
 		if item.Quantity <= 0 {
-			return errors.New("quantity must be positive")
+			verr.Add(field+"quantity", "quantity must be positive")
 		}
-		if item.UnitPrice < 0 {
-			return errors.New("unit price cannot be negative")
+		if err := item.UnitPrice.Validate(); err != nil {
+			verr.Add(field+"unit_price.currency", err.Error())
+		} else if currency == "" {
+			currency = item.UnitPrice.Currency
+		} else if item.UnitPrice.Currency != currency {
+			verr.Addf(field+"unit_price.currency", "all items must use the same currency, expected %s", currency)
 		}
-		// Validate product name
-		if item.ProductName == "" {
-			return errors.New("product name is required")
+		if item.UnitPrice.IsNegative() {
+			verr.Add(field+"unit_price.amount", "unit price cannot be negative")
 		}
-		_ = i // Suppress unused variable warning if needed
 	}
-	return nil
+	return verr.Err()
 }
 
-// CanTransitionTo checks if order can transition to a new status.
-// Enforces valid state transitions (e.g., can't ship a cancelled order).
+// CanTransitionTo checks if order can transition to a new status under the
+// default state machine. Services with a custom lifecycle use StateMachine directly.
 func (o *Order) CanTransitionTo(newStatus OrderStatus) bool {
-	switch o.Status {
-	case StatusPending:
-		return newStatus == StatusConfirmed || newStatus == StatusCancelled
-	case StatusConfirmed:
-		return newStatus == StatusShipped || newStatus == StatusCancelled
-	case StatusShipped:
-		return newStatus == StatusDelivered
-	case StatusDelivered, StatusCancelled:
-		return false // Terminal states
-	default:
-		return false
-	}
+	return DefaultStateMachine().CanTransition(o, newStatus) == nil
 }
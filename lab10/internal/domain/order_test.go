@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestOrderValidateCollectsViolations checks every invalid field is reported
+// with its JSON path, not just the first one.
+func TestOrderValidateCollectsViolations(t *testing.T) {
+	order := &Order{
+		ID: "ORD-1",
+		Items: []LineItem{
+			{ProductID: "SKU-1", ProductName: "Widget", Quantity: 1, UnitPrice: MustMoney("1.00", "USD")},
+			{ProductID: "", ProductName: "Gadget", Quantity: 0, UnitPrice: MustMoney("-1.00", "USD")},
+			{ProductID: "SKU-3", ProductName: "Gizmo", Quantity: 1, UnitPrice: MustMoney("1.00", "EUR")},
+		},
+	}
+
+	err := order.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+	want := []string{
+		"customer_id",
+		"items[1].product_id",
+		"items[1].quantity",
+		"items[1].unit_price.amount",
+		"items[2].unit_price.currency",
+	}
+	if len(verr.Violations) != len(want) {
+		t.Fatalf("Validate() violations = %+v, want fields %v", verr.Violations, want)
+	}
+	for i, field := range want {
+		if verr.Violations[i].Field != field {
+			t.Errorf("violation %d field = %q, want %q", i, verr.Violations[i].Field, field)
+		}
+	}
+
+	order.CustomerID = "CUST-001"
+	order.Items = order.Items[:1]
+	if err := order.Validate(); err != nil {
+		t.Errorf("Validate() of valid order error = %v", err)
+	}
+}
+
+func TestValidateOrderID(t *testing.T) {
+	valid := []string{"ORD-1", "a.b_c~d", "Watch", "watch-1", "exports"}
+	for _, id := range valid {
+		if err := ValidateOrderID(id); err != nil {
+			t.Errorf("ValidateOrderID(%q) error = %v", id, err)
+		}
+	}
+	invalid := []string{"", "a/b", "a b", ".", "..", "watch", "export", "import"}
+	for _, id := range invalid {
+		if err := ValidateOrderID(id); err == nil {
+			t.Errorf("ValidateOrderID(%q) succeeded, want error", id)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package domain
+
+// PriceAdjustment is one discount or tax line of a price breakdown.
+type PriceAdjustment struct {
+	Rule        string `json:"rule"`        // name of the pricing rule that produced it
+	Description string `json:"description"` // human-readable explanation, e.g. "10% off orders over 100.00 USD"
+	Amount      Money  `json:"amount"`      // always positive: subtracted for discounts, added for tax
+}
+
+// PriceBreakdown records how an order's total was calculated:
+// Total = Subtotal - DiscountTotal + Tax.
+type PriceBreakdown struct {
+	Subtotal      Money             `json:"subtotal"` // sum of quantity * unit price over all items
+	Discounts     []PriceAdjustment `json:"discounts,omitempty"`
+	DiscountTotal Money             `json:"discount_total"`
+	Taxes         []PriceAdjustment `json:"taxes,omitempty"`
+	Tax           Money             `json:"tax"` // sum of Taxes
+	Total         Money             `json:"total"`
+}
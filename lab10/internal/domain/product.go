@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	commondomain "golang-for-java-developers-training/common/domain"
+)
+
+// ProductStatus tells whether a product can be ordered.
+type ProductStatus string
+
+const (
+	ProductActive       ProductStatus = "active"
+	ProductDiscontinued ProductStatus = "discontinued"
+)
+
+// MaxSKULength caps the length of product SKUs.
+const MaxSKULength = 128
+
+// Product is a catalog entry that order line items are priced from.
+// It extends the shared commondomain.Product (SKU, Name and BasePrice) with an
+// exact Price and a lifecycle status. BasePrice is kept in step with Price by
+// SetPrice for code that still reads the shared type; never compute with it.
+type Product struct {
+	commondomain.Product
+
+	Price     Money
+	Status    ProductStatus
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Version starts at 1 and is incremented on every update, like Order.Version.
+	Version int64
+}
+
+// SetPrice sets the exact price and the derived float BasePrice.
+func (p *Product) SetPrice(price Money) {
+	p.Price = price
+	p.BasePrice, _ = strconv.ParseFloat(price.Decimal(), 64)
+}
+
+// IsOrderable reports whether new orders may include the product.
+func (p *Product) IsOrderable() bool {
+	return p.Status == ProductActive
+}
+
+// ValidateSKU checks a SKU follows the same rules as order IDs.
+func ValidateSKU(sku string) error {
+	return validateID("product", sku, MaxSKULength)
+}
+
+// Validate checks if the product meets business rules.
+func (p *Product) Validate() error {
+	if err := ValidateSKU(p.SKU); err != nil {
+		return err
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("product name is required")
+	}
+	if err := p.Price.Validate(); err != nil {
+		return err
+	}
+	if p.Price.IsNegative() {
+		return errors.New("product price cannot be negative")
+	}
+	switch p.Status {
+	case ProductActive, ProductDiscontinued:
+	default:
+		return fmt.Errorf("invalid product status %q: must be %s or %s", p.Status, ProductActive, ProductDiscontinued)
+	}
+	return nil
+}
+
+// productJSON is the wire form of Product. The shared type has no JSON tags
+// and a float price, so its fields are spelled out here and BasePrice is left off.
+type productJSON struct {
+	SKU       string        `json:"sku"`
+	Name      string        `json:"name"`
+	Price     Money         `json:"price"`
+	Status    ProductStatus `json:"status"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Version   int64         `json:"version"`
+}
+
+// MarshalJSON encodes the product with snake_case keys and an exact price.
+func (p Product) MarshalJSON() ([]byte, error) {
+	return json.Marshal(productJSON{
+		SKU:       p.SKU,
+		Name:      p.Name,
+		Price:     p.Price,
+		Status:    p.Status,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+		Version:   p.Version,
+	})
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON.
+func (p *Product) UnmarshalJSON(data []byte) error {
+	var wire productJSON
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*p = Product{
+		Product:   commondomain.Product{SKU: wire.SKU, Name: wire.Name},
+		Status:    wire.Status,
+		CreatedAt: wire.CreatedAt,
+		UpdatedAt: wire.UpdatedAt,
+		Version:   wire.Version,
+	}
+	p.SetPrice(wire.Price)
+	return nil
+}
@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Additional lifecycle states used by fulfilment. They are not part of the
+// default state machine - enable them with a custom StateMachineDefinition.
+const (
+	StatusPartiallyShipped OrderStatus = "partially_shipped"
+	StatusReturned         OrderStatus = "returned"
+	StatusRefunded         OrderStatus = "refunded"
+)
+
+// statuses are the statuses a state machine may use: the ones the APIs can
+// represent (see the OrderStatus enum in orders.proto). A new status must be
+// added there too.
+var statuses = []OrderStatus{
+	StatusPending, StatusConfirmed, StatusShipped, StatusDelivered, StatusCancelled,
+	StatusPartiallyShipped, StatusReturned, StatusRefunded,
+}
+
+// Statuses returns every order status a state machine may use.
+func Statuses() []OrderStatus {
+	return append([]OrderStatus{}, statuses...)
+}
+
+// ErrTransitionNotAllowed indicates the state machine has no transition between two states.
+var ErrTransitionNotAllowed = errors.New("transition not allowed")
+
+// Guard is a condition an order must meet before a transition may happen.
+// Returns nil if the transition may proceed, or an error explaining why not.
+type Guard func(order *Order) error
+
+// guards are the named guard conditions a StateMachineDefinition can reference.
+var guards = map[string]Guard{
+	"has_items": func(order *Order) error {
+		if len(order.Items) == 0 {
+			return errors.New("order has no items")
+		}
+		return nil
+	},
+	"multiple_items": func(order *Order) error {
+		if len(order.Items) < 2 {
+			return errors.New("order has fewer than two items")
+		}
+		return nil
+	},
+	"positive_total": func(order *Order) error {
+		if order.TotalAmount.Amount <= 0 {
+			return errors.New("order total is not positive")
+		}
+		return nil
+	},
+}
+
+// TransitionDefinition declares that an order may move From one status To another,
+// provided every named guard passes.
+type TransitionDefinition struct {
+	From   OrderStatus `json:"from" yaml:"from"`
+	To     OrderStatus `json:"to" yaml:"to"`
+	Guards []string    `json:"guards,omitempty" yaml:"guards,omitempty"`
+}
+
+// StateMachineDefinition is the declarative (JSON/YAML) form of an order state machine.
+// Statuses with no outgoing transitions are terminal.
+type StateMachineDefinition struct {
+	Initial     OrderStatus            `json:"initial" yaml:"initial"`
+	Transitions []TransitionDefinition `json:"transitions" yaml:"transitions"`
+}
+
+// defaultDefinition is the built-in order lifecycle:
+// pending -> confirmed -> shipped -> delivered, with cancellation before shipping.
+var defaultDefinition = StateMachineDefinition{
+	Initial: StatusPending,
+	Transitions: []TransitionDefinition{
+		{From: StatusPending, To: StatusConfirmed},
+		{From: StatusPending, To: StatusCancelled},
+		{From: StatusConfirmed, To: StatusShipped},
+		{From: StatusConfirmed, To: StatusCancelled},
+		{From: StatusShipped, To: StatusDelivered},
+	},
+}
+
+// transition is a validated TransitionDefinition with its guards resolved.
+type transition struct {
+	to         OrderStatus
+	guardNames []string
+	guards     []Guard
+}
+
+// StateMachine validates order status transitions.
+// Built from a StateMachineDefinition; immutable and safe for concurrent use.
+type StateMachine struct {
+	initial     OrderStatus
+	transitions map[OrderStatus][]transition
+}
+
+// NewStateMachine validates def and builds a state machine from it.
+func NewStateMachine(def StateMachineDefinition) (*StateMachine, error) {
+	if def.Initial == "" {
+		return nil, errors.New("state machine: initial status is required")
+	}
+	if !slices.Contains(statuses, def.Initial) {
+		return nil, fmt.Errorf("state machine: unknown initial status %q", def.Initial)
+	}
+
+	m := &StateMachine{
+		initial:     def.Initial,
+		transitions: make(map[OrderStatus][]transition),
+	}
+	for i, td := range def.Transitions {
+		if td.From == "" || td.To == "" {
+			return nil, fmt.Errorf("state machine: transition %d: from and to are required", i)
+		}
+		for _, status := range []OrderStatus{td.From, td.To} {
+			if !slices.Contains(statuses, status) {
+				return nil, fmt.Errorf("state machine: transition %d: unknown status %q", i, status)
+			}
+		}
+		if td.From == td.To {
+			return nil, fmt.Errorf("state machine: transition %d: %s cannot transition to itself", i, td.From)
+		}
+		for _, existing := range m.transitions[td.From] {
+			if existing.to == td.To {
+				return nil, fmt.Errorf("state machine: duplicate transition %s -> %s", td.From, td.To)
+			}
+		}
+
+		t := transition{to: td.To, guardNames: td.Guards}
+		for _, name := range td.Guards {
+			guard, ok := guards[name]
+			if !ok {
+				return nil, fmt.Errorf("state machine: transition %s -> %s: unknown guard %q", td.From, td.To, name)
+			}
+			t.guards = append(t.guards, guard)
+		}
+		m.transitions[td.From] = append(m.transitions[td.From], t)
+	}
+	return m, nil
+}
+
+// DefaultStateMachine returns the built-in order lifecycle:
+// pending -> confirmed -> shipped -> delivered, with cancellation before shipping.
+func DefaultStateMachine() *StateMachine {
+	return defaultStateMachine
+}
+
+var defaultStateMachine = func() *StateMachine {
+	m, err := NewStateMachine(defaultDefinition)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}()
+
+// InitialStatus returns the status new orders start in.
+func (m *StateMachine) InitialStatus() OrderStatus {
+	return m.initial
+}
+
+// IsTerminal reports whether no transitions leave status.
+func (m *StateMachine) IsTerminal(status OrderStatus) bool {
+	return len(m.transitions[status]) == 0
+}
+
+// CanTransition checks whether order may move to the given status.
+// Returns ErrTransitionNotAllowed if there is no such transition, or the
+// first failing guard's error.
+func (m *StateMachine) CanTransition(order *Order, to OrderStatus) error {
+	for _, t := range m.transitions[order.Status] {
+		if t.to != to {
+			continue
+		}
+		for i, guard := range t.guards {
+			if err := guard(order); err != nil {
+				return fmt.Errorf("guard %s failed: %w", t.guardNames[i], err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrTransitionNotAllowed, order.Status, to)
+}
+
+// AllowedTransitions returns the statuses order can move to right now
+// (transitions whose guards all pass), sorted by name.
+func (m *StateMachine) AllowedTransitions(order *Order) []OrderStatus {
+	allowed := make([]OrderStatus, 0)
+	for _, t := range m.transitions[order.Status] {
+		if m.CanTransition(order, t.to) == nil {
+			allowed = append(allowed, t.to)
+		}
+	}
+	sort.Slice(allowed, func(i, j int) bool { return allowed[i] < allowed[j] })
+	return allowed
+}
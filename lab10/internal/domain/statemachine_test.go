@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// TestDefaultStateMachine checks the built-in lifecycle matches the original transition rules.
+func TestDefaultStateMachine(t *testing.T) {
+	tests := []struct {
+		from OrderStatus
+		to   OrderStatus
+		want bool
+	}{
+		{StatusPending, StatusConfirmed, true},
+		{StatusPending, StatusCancelled, true},
+		{StatusPending, StatusShipped, false},
+		{StatusConfirmed, StatusShipped, true},
+		{StatusConfirmed, StatusCancelled, true},
+		{StatusShipped, StatusDelivered, true},
+		{StatusShipped, StatusCancelled, false},
+		{StatusDelivered, StatusReturned, false},
+		{StatusCancelled, StatusPending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			order := &Order{Status: tt.from}
+			if got := order.CanTransitionTo(tt.to); got != tt.want {
+				t.Errorf("CanTransitionTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestStateMachineGuards checks guards block transitions and are reflected in AllowedTransitions.
+func TestStateMachineGuards(t *testing.T) {
+	m, err := NewStateMachine(StateMachineDefinition{
+		Initial: StatusPending,
+		Transitions: []TransitionDefinition{
+			{From: StatusConfirmed, To: StatusPartiallyShipped, Guards: []string{"multiple_items"}},
+			{From: StatusConfirmed, To: StatusShipped},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewStateMachine() error = %v", err)
+	}
+
+	single := &Order{Status: StatusConfirmed, Items: []LineItem{{ProductID: "SKU-1"}}}
+	if err := m.CanTransition(single, StatusPartiallyShipped); err == nil {
+		t.Error("CanTransition() = nil, want guard failure for single-item order")
+	}
+	if got := m.AllowedTransitions(single); !slices.Equal(got, []OrderStatus{StatusShipped}) {
+		t.Errorf("AllowedTransitions() = %v, want [shipped]", got)
+	}
+
+	multi := &Order{Status: StatusConfirmed, Items: []LineItem{{ProductID: "SKU-1"}, {ProductID: "SKU-2"}}}
+	if got := m.AllowedTransitions(multi); !slices.Equal(got, []OrderStatus{StatusPartiallyShipped, StatusShipped}) {
+		t.Errorf("AllowedTransitions() = %v, want [partially_shipped shipped]", got)
+	}
+
+	if err := m.CanTransition(multi, StatusDelivered); !errors.Is(err, ErrTransitionNotAllowed) {
+		t.Errorf("CanTransition() error = %v, want %v", err, ErrTransitionNotAllowed)
+	}
+}
+
+// TestNewStateMachineInvalid checks malformed definitions are rejected.
+func TestNewStateMachineInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		def  StateMachineDefinition
+	}{
+		{"missing initial", StateMachineDefinition{}},
+		{"missing target", StateMachineDefinition{Initial: StatusPending, Transitions: []TransitionDefinition{{From: StatusPending}}}},
+		{"unknown initial status", StateMachineDefinition{Initial: "draft"}},
+		{"unknown status", StateMachineDefinition{Initial: StatusPending, Transitions: []TransitionDefinition{{From: StatusPending, To: "on_hold"}}}},
+		{"self transition", StateMachineDefinition{Initial: StatusPending, Transitions: []TransitionDefinition{{From: StatusPending, To: StatusPending}}}},
+		{"unknown guard", StateMachineDefinition{Initial: StatusPending, Transitions: []TransitionDefinition{{From: StatusPending, To: StatusConfirmed, Guards: []string{"nope"}}}}},
+		{"duplicate", StateMachineDefinition{Initial: StatusPending, Transitions: []TransitionDefinition{
+			{From: StatusPending, To: StatusConfirmed},
+			{From: StatusPending, To: StatusConfirmed},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewStateMachine(tt.def); err == nil {
+				t.Error("NewStateMachine() error = nil, want error")
+			}
+		})
+	}
+}
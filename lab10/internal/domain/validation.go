@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldViolation describes why one field is invalid. Field is the path of
+// the field in the entity's JSON form, e.g. "items[2].quantity".
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// ValidationError reports every field violation found by a validation,
+// rather than just the first one.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+// NewFieldError returns a ValidationError with a single violation.
+func NewFieldError(field, description string) *ValidationError {
+	return &ValidationError{Violations: []FieldViolation{{Field: field, Description: description}}}
+}
+
+// Add records a violation of field.
+func (e *ValidationError) Add(field, description string) {
+	e.Violations = append(e.Violations, FieldViolation{Field: field, Description: description})
+}
+
+// Addf records a violation of field with a formatted description.
+func (e *ValidationError) Addf(field, format string, args ...interface{}) {
+	e.Add(field, fmt.Sprintf(format, args...))
+}
+
+// Err returns e if any violation was recorded, otherwise nil.
+func (e *ValidationError) Err() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error lists the violations as "field: description", separated by "; ".
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.Field+": "+v.Description)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"lab10/internal/domain"
+)
+
+// Publisher delivers domain events to the outside world.
+// Publish must return an error unless the event was accepted; the relay will
+// then retry it. Events may be published more than once (at-least-once).
+type Publisher interface {
+	Publish(ctx context.Context, event domain.Event) error
+}
+
+// InProcessPublisher fans events out to handlers registered in this process.
+// Handlers run synchronously on the relay goroutine, so they must be quick and
+// must not block - hand work off to a channel or goroutine if needed.
+type InProcessPublisher struct {
+	mu       sync.RWMutex
+	handlers map[int]func(domain.Event)
+	nextID   int
+}
+
+// NewInProcessPublisher creates a publisher with no subscribers.
+func NewInProcessPublisher() *InProcessPublisher {
+	return &InProcessPublisher{handlers: make(map[int]func(domain.Event))}
+}
+
+// Subscribe registers handler for every published event.
+// Call the returned function to unsubscribe.
+func (p *InProcessPublisher) Subscribe(handler func(domain.Event)) (unsubscribe func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := p.nextID
+	p.nextID++
+	p.handlers[id] = handler
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.handlers, id)
+	}
+}
+
+// Publish passes the event to every current subscriber. It never fails.
+func (p *InProcessPublisher) Publish(ctx context.Context, event domain.Event) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, handler := range p.handlers {
+		handler(event)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"lab10/internal/repository"
+)
+
+// Default relay settings.
+const (
+	DefaultPollInterval = time.Second
+	DefaultBatchSize    = 100
+	DefaultMaxBackoff   = time.Minute
+)
+
+// Relay moves events from the repository outbox to a Publisher.
+// An event is only removed from the outbox after it was published, so a crash
+// or failed publish means it is sent again later: delivery is at-least-once.
+// Events are published one at a time in outbox order; a failure stops the
+// batch so later events never overtake an earlier one.
+type Relay struct {
+	outbox       repository.Outbox
+	publisher    Publisher
+	local        Publisher
+	pollInterval time.Duration
+	batchSize    int
+	maxBackoff   time.Duration
+
+	// localCursor is the ID of the last event passed to local.
+	localCursor int64
+}
+
+// RelayOption configures optional Relay settings.
+type RelayOption func(*Relay)
+
+// WithPollInterval sets how often the outbox is checked when it is empty.
+func WithPollInterval(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.pollInterval = d
+	}
+}
+
+// WithBatchSize sets how many events are read from the outbox at a time.
+func WithBatchSize(n int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = n
+	}
+}
+
+// WithMaxBackoff caps the wait between retries after a failed publish.
+// The wait starts at the poll interval and doubles on each consecutive failure.
+func WithMaxBackoff(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.maxBackoff = d
+	}
+}
+
+// WithLocalPublisher also passes every event to local, such as an
+// InProcessPublisher, as soon as the relay finds it in the outbox. local gets
+// each event once and isn't held up while the main publisher fails and is
+// retried; an event it fails to take is not offered again. Events are only
+// removed from the outbox once the main publisher has them.
+func WithLocalPublisher(local Publisher) RelayOption {
+	return func(r *Relay) {
+		r.local = local
+	}
+}
+
+// NewRelay creates a relay from outbox to publisher.
+func NewRelay(outbox repository.Outbox, publisher Publisher, opts ...RelayOption) *Relay {
+	r := &Relay{
+		outbox:       outbox,
+		publisher:    publisher,
+		pollInterval: DefaultPollInterval,
+		batchSize:    DefaultBatchSize,
+		maxBackoff:   DefaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run delivers events until ctx is cancelled. Call it in its own goroutine.
+func (r *Relay) Run(ctx context.Context) {
+	backoff := r.pollInterval
+	var retryAt time.Time
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		// The local publisher keeps up even while the main one backs off
+		more := false
+		if r.local != nil {
+			published, err := r.publishLocal(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Outbox relay: %v", err)
+			}
+			more = published == r.batchSize
+		}
+
+		if now := time.Now(); !now.Before(retryAt) {
+			delivered, err := r.deliverPending(ctx)
+			switch {
+			case err != nil:
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("Outbox relay: %v (retrying in %s)", err, backoff)
+				retryAt = now.Add(backoff)
+				backoff = min(backoff*2, r.maxBackoff)
+			case delivered == r.batchSize:
+				// The batch was full, so more events may be waiting
+				retryAt, backoff = time.Time{}, r.pollInterval
+				more = true
+			default:
+				retryAt, backoff = time.Time{}, r.pollInterval
+			}
+		}
+
+		wait := r.pollInterval
+		if !retryAt.IsZero() {
+			untilRetry := max(time.Until(retryAt), 0)
+			if r.local == nil || untilRetry < wait {
+				wait = untilRetry
+			}
+		}
+		if more {
+			wait = 0
+		}
+		timer.Reset(wait)
+	}
+}
+
+// publishLocal passes the events added to the outbox since the last call to
+// the local publisher, up to one batch, and returns how many there were.
+func (r *Relay) publishLocal(ctx context.Context) (int, error) {
+	events, err := r.outbox.PendingEvents(ctx, r.localCursor, r.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	for _, event := range events {
+		if err := r.local.Publish(ctx, event); err != nil {
+			log.Printf("Outbox relay: failed to publish event %d locally: %v", event.ID, err)
+		}
+		r.localCursor = event.ID
+	}
+	return len(events), nil
+}
+
+// deliverPending publishes one batch of pending events in order and returns
+// how many were delivered. It stops at the first failure, and at the first
+// event the local publisher hasn't had yet, since delivered events leave
+// the outbox.
+func (r *Relay) deliverPending(ctx context.Context) (int, error) {
+	events, err := r.outbox.PendingEvents(ctx, 0, r.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	for i, event := range events {
+		if r.local != nil && event.ID > r.localCursor {
+			return i, nil
+		}
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			if markErr := r.outbox.MarkFailed(ctx, event.ID, err.Error()); markErr != nil {
+				log.Printf("Outbox relay: failed to record failure of event %d: %v", event.ID, markErr)
+			}
+			return i, fmt.Errorf("failed to publish event %d: %w", event.ID, err)
+		}
+		// If this fails the event stays in the outbox and is published again
+		if err := r.outbox.MarkDelivered(ctx, event.ID); err != nil {
+			return i, fmt.Errorf("failed to mark event %d delivered: %w", event.ID, err)
+		}
+	}
+	return len(events), nil
+}
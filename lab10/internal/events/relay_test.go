@@ -0,0 +1,128 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+)
+
+// flakyPublisher fails the first failures calls, then records what it publishes.
+type flakyPublisher struct {
+	failures  int
+	published []domain.Event
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, event domain.Event) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("unavailable")
+	}
+	p.published = append(p.published, event)
+	return nil
+}
+
+// TestRelayRetriesInOrder checks a failed publish keeps the event (and
+// everything after it) in the outbox until a later attempt succeeds.
+func TestRelayRetriesInOrder(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	for _, id := range []string{"ORD-1", "ORD-2", "ORD-3"} {
+		order := &domain.Order{ID: id, CustomerID: "CUST-1", Status: domain.StatusPending, Version: 1}
+		if err := repo.Create(ctx, order); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	publisher := &flakyPublisher{failures: 1}
+	relay := NewRelay(repo, publisher)
+
+	if n, err := relay.deliverPending(ctx); err == nil || n != 0 {
+		t.Fatalf("deliverPending() = %d, %v; want 0 and an error", n, err)
+	}
+	if n, err := relay.deliverPending(ctx); err != nil || n != 3 {
+		t.Fatalf("deliverPending() = %d, %v; want 3, nil", n, err)
+	}
+
+	var ids []string
+	for _, event := range publisher.published {
+		ids = append(ids, event.OrderID)
+	}
+	if !slices.Equal(ids, []string{"ORD-1", "ORD-2", "ORD-3"}) {
+		t.Errorf("published orders = %v, want [ORD-1 ORD-2 ORD-3]", ids)
+	}
+
+	if pending, _ := repo.PendingEvents(ctx, 0, 10); len(pending) != 0 {
+		t.Errorf("pending events = %d, want 0", len(pending))
+	}
+}
+
+// TestRelayLocalPublisherNotHeldUp checks a failing main publisher (e.g. a
+// webhook that is down) neither delays events for the local publisher nor
+// makes it see them twice when the main publisher is retried.
+func TestRelayLocalPublisherNotHeldUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repo := repository.NewMemoryRepository()
+	createOrder := func(id string) {
+		t.Helper()
+		order := &domain.Order{ID: id, CustomerID: "CUST-1", Status: domain.StatusPending, Version: 1}
+		if err := repo.Create(ctx, order); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	createOrder("ORD-1")
+	createOrder("ORD-2")
+
+	local := NewInProcessPublisher()
+	received := make(chan domain.Event, 10)
+	local.Subscribe(func(event domain.Event) { received <- event })
+
+	webhook := &flakyPublisher{failures: 1 << 30}
+	relay := NewRelay(repo, webhook, WithLocalPublisher(local),
+		WithPollInterval(time.Millisecond), WithMaxBackoff(time.Hour))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(ctx)
+	}()
+
+	expect := func(orderID string) {
+		t.Helper()
+		select {
+		case event := <-received:
+			if event.OrderID != orderID {
+				t.Fatalf("local event for %s, want %s", event.OrderID, orderID)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no local event for %s while the main publisher fails", orderID)
+		}
+	}
+	expect("ORD-1")
+	expect("ORD-2")
+	// Also events recorded after the main publisher started failing
+	createOrder("ORD-3")
+	expect("ORD-3")
+
+	cancel()
+	<-done
+	if len(webhook.published) != 0 {
+		t.Fatalf("main publisher got %d events, want 0", len(webhook.published))
+	}
+
+	// Once the main publisher recovers it gets every event, the local one none again
+	webhook.failures = 0
+	if n, err := relay.deliverPending(context.Background()); err != nil || n != 3 {
+		t.Fatalf("deliverPending() = %d, %v; want 3, nil", n, err)
+	}
+	if n, err := relay.publishLocal(context.Background()); err != nil || n != 0 {
+		t.Fatalf("publishLocal() = %d, %v; want 0, nil", n, err)
+	}
+	if len(received) != 0 {
+		t.Errorf("local publisher got %d events again", len(received))
+	}
+}
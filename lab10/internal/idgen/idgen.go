@@ -0,0 +1,150 @@
+// Package idgen generates unique, time-ordered identifiers for new orders.
+//
+// IDs from one generator sort (as strings) in the order they were generated,
+// which keeps database indexes append-mostly and makes IDs roughly
+// chronological across instances.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// UUIDv7 generates RFC 9562 version 7 UUIDs in their canonical lower-case
+// form, e.g. "01928c3e-5f6a-7b2c-9d4e-0f1a2b3c4d5e". Within one millisecond a
+// 12-bit counter keeps IDs increasing; if it runs out the timestamp is
+// advanced by a millisecond rather than repeating.
+type UUIDv7 struct {
+	mu     sync.Mutex
+	lastMs int64
+	seq    uint16
+}
+
+// NewUUIDv7 creates a UUIDv7 generator.
+func NewUUIDv7() *UUIDv7 {
+	return &UUIDv7{}
+}
+
+// NewID returns the next UUIDv7. Safe for concurrent use.
+func (g *UUIDv7) NewID() string {
+	var b [16]byte
+	randomBytes(b[8:])
+
+	g.mu.Lock()
+	ms := time.Now().UnixMilli()
+	if ms > g.lastMs {
+		g.lastMs = ms
+		// Start low in the counter range so the millisecond has room to grow
+		var r [2]byte
+		randomBytes(r[:])
+		g.seq = (uint16(r[0])<<8 | uint16(r[1])) & 0x3ff
+	} else {
+		g.seq++
+		if g.seq > 0xfff {
+			g.lastMs++
+			g.seq = 0
+		}
+	}
+	ms, seq := g.lastMs, g.seq
+	g.mu.Unlock()
+
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = 0x70 | byte(seq>>8) // version 7
+	b[7] = byte(seq)
+	b[8] = 0x80 | b[8]&0x3f // RFC 9562 variant
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID generates 26-character ULIDs, e.g. "01J9ZK3Q8W5N7X2C4V6B8M0P1R".
+// IDs generated in the same millisecond increment the random part, so they
+// stay strictly increasing (the monotonic ULID variant).
+type ULID struct {
+	mu      sync.Mutex
+	lastMs  int64
+	entropy [10]byte
+}
+
+// NewULID creates a ULID generator.
+func NewULID() *ULID {
+	return &ULID{}
+}
+
+// NewID returns the next ULID. Safe for concurrent use.
+func (g *ULID) NewID() string {
+	g.mu.Lock()
+	ms := time.Now().UnixMilli()
+	if ms > g.lastMs {
+		g.lastMs = ms
+		randomBytes(g.entropy[:])
+	} else if !increment(g.entropy[:]) {
+		// 80 bits exhausted within a millisecond: move on to the next one
+		g.lastMs++
+		randomBytes(g.entropy[:])
+	}
+	var b [16]byte
+	b[0] = byte(g.lastMs >> 40)
+	b[1] = byte(g.lastMs >> 32)
+	b[2] = byte(g.lastMs >> 24)
+	b[3] = byte(g.lastMs >> 16)
+	b[4] = byte(g.lastMs >> 8)
+	b[5] = byte(g.lastMs)
+	copy(b[6:], g.entropy[:])
+	g.mu.Unlock()
+
+	// 128 bits as 26 base32 digits, most significant first (the top digit holds 3 bits)
+	var s [26]byte
+	var acc uint32
+	var bits uint
+	pos := len(s) - 1
+	for i := len(b) - 1; i >= 0; i-- {
+		acc |= uint32(b[i]) << bits
+		bits += 8
+		for bits >= 5 {
+			s[pos] = crockford[acc&0x1f]
+			pos--
+			acc >>= 5
+			bits -= 5
+		}
+	}
+	s[0] = crockford[acc&0x1f]
+	return string(s[:])
+}
+
+// increment adds one to a big-endian number, reporting false on overflow.
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// randomBytes fills b from crypto/rand, which never fails on supported platforms.
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic("idgen: crypto/rand failed: " + err.Error())
+	}
+}
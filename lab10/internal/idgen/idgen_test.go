@@ -0,0 +1,46 @@
+package idgen
+
+import (
+	"regexp"
+	"testing"
+)
+
+// TestGeneratorsSortable checks IDs are well-formed, unique and increasing,
+// including many IDs generated within the same millisecond.
+func TestGeneratorsSortable(t *testing.T) {
+	tests := []struct {
+		name    string
+		newID   func() string
+		pattern *regexp.Regexp
+	}{
+		{"uuidv7", NewUUIDv7().NewID, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{"ulid", NewULID().NewID, regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := ""
+			for i := 0; i < 10000; i++ {
+				id := tt.newID()
+				if !tt.pattern.MatchString(id) {
+					t.Fatalf("NewID() = %q, not a valid %s", id, tt.name)
+				}
+				if id <= prev {
+					t.Fatalf("NewID() = %q after %q, want increasing IDs", id, prev)
+				}
+				prev = id
+			}
+		})
+	}
+}
+
+func TestIncrementOverflow(t *testing.T) {
+	b := []byte{0x00, 0xff}
+	if !increment(b) || b[0] != 0x01 || b[1] != 0x00 {
+		t.Fatalf("increment() = %x, want 0100", b)
+	}
+	b = []byte{0xff, 0xff}
+	if increment(b) {
+		t.Fatal("increment() of all ones reported no overflow")
+	}
+}
@@ -0,0 +1,231 @@
+// Package inventory reserves stock for confirmed orders.
+//
+// Reservations are tracked in process: they are lost on restart and not
+// shared between instances. Hold rebuilds them from the stored orders at
+// startup. A stock level is what is on hand; what an order
+// can reserve is that level minus what other orders have already reserved.
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"lab10/internal/domain"
+)
+
+// ErrInsufficientStock indicates a product doesn't have enough unreserved
+// stock for an order.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ledger records which order holds how much of each SKU. Both
+// implementations embed it and differ only in where stock levels come from.
+type ledger struct {
+	mu       sync.Mutex
+	reserved map[string]int            // SKU -> quantity held by all orders
+	orders   map[string]map[string]int // order ID -> SKU -> quantity
+}
+
+func newLedger() ledger {
+	return ledger{
+		reserved: make(map[string]int),
+		orders:   make(map[string]map[string]int),
+	}
+}
+
+// reserve holds stock for every item of an order, or for none of them,
+// and reports whether it did. level reports the stock on hand of a SKU.
+// Callers must hold l.mu. Reserving again for an order that already holds
+// stock changes nothing and reports false, so only the call that made a
+// reservation releases it when it has to be undone.
+func (l *ledger) reserve(orderID string, items []domain.LineItem, level func(sku string) int) (bool, error) {
+	if _, held := l.orders[orderID]; held {
+		return false, nil
+	}
+
+	want := quantities(items)
+	skus := make([]string, 0, len(want))
+	for sku := range want {
+		skus = append(skus, sku)
+	}
+	slices.Sort(skus)
+
+	for _, sku := range skus {
+		if available := level(sku) - l.reserved[sku]; available < want[sku] {
+			return false, fmt.Errorf("%w: %s has %d available, %d requested",
+				ErrInsufficientStock, sku, max(available, 0), want[sku])
+		}
+	}
+	for _, sku := range skus {
+		l.reserved[sku] += want[sku]
+	}
+	l.orders[orderID] = want
+	return true, nil
+}
+
+// release returns the stock held by an order. Callers must hold l.mu.
+func (l *ledger) release(orderID string) {
+	for sku, qty := range l.orders[orderID] {
+		l.reserved[sku] -= qty
+		if l.reserved[sku] <= 0 {
+			delete(l.reserved, sku)
+		}
+	}
+	delete(l.orders, orderID)
+}
+
+// Hold records that an order holds stock for its items without checking
+// stock levels, to rebuild the reservations of orders confirmed before a
+// restart. Holding for an order that already holds stock changes nothing.
+func (l *ledger) Hold(ctx context.Context, orderID string, items []domain.LineItem) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, held := l.orders[orderID]; held {
+		return nil
+	}
+	want := quantities(items)
+	for sku, qty := range want {
+		l.reserved[sku] += qty
+	}
+	l.orders[orderID] = want
+	return nil
+}
+
+// quantities sums line item quantities per SKU.
+func quantities(items []domain.LineItem) map[string]int {
+	want := make(map[string]int, len(items))
+	for _, item := range items {
+		want[item.ProductID] += item.Quantity
+	}
+	return want
+}
+
+// Memory keeps stock levels in memory. SKUs without a level set start with
+// the default level given to NewMemory. Good for development and tests.
+type Memory struct {
+	ledger
+	levels       map[string]int
+	defaultLevel int
+}
+
+// NewMemory creates an in-memory inventory where every SKU starts with defaultLevel on hand.
+func NewMemory(defaultLevel int) *Memory {
+	return &Memory{
+		ledger:       newLedger(),
+		levels:       make(map[string]int),
+		defaultLevel: defaultLevel,
+	}
+}
+
+// SetLevel sets the stock on hand of a SKU.
+func (m *Memory) SetLevel(sku string, qty int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.levels[sku] = qty
+}
+
+// Available returns the stock of a SKU that isn't reserved.
+func (m *Memory) Available(sku string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.level(sku) - m.reserved[sku]
+}
+
+// Reserve holds stock for every item of the order, or returns an error
+// wrapping ErrInsufficientStock and holds nothing. It reports false if the
+// order already held stock.
+func (m *Memory) Reserve(ctx context.Context, orderID string, items []domain.LineItem) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reserve(orderID, items, m.level)
+}
+
+// Release returns the stock held by the order. Releasing an order that holds
+// nothing is a no-op.
+func (m *Memory) Release(ctx context.Context, orderID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.release(orderID)
+	return nil
+}
+
+// level returns the stock on hand of a SKU. Callers must hold m.mu.
+func (m *Memory) level(sku string) int {
+	if qty, ok := m.levels[sku]; ok {
+		return qty
+	}
+	return m.defaultLevel
+}
+
+// LevelFunc looks up the stock on hand of a SKU in an external system, with
+// the signature of external.FetchInventoryLevel.
+type LevelFunc func(sku string) int
+
+// External reads stock levels from an external system on every reservation
+// and subtracts what this process has reserved.
+type External struct {
+	ledger
+	lookup LevelFunc
+}
+
+// NewExternal creates an inventory backed by lookup.
+func NewExternal(lookup LevelFunc) *External {
+	return &External{
+		ledger: newLedger(),
+		lookup: lookup,
+	}
+}
+
+// Reserve looks up the level of every SKU of the order concurrently, then
+// holds stock for every item or returns an error wrapping ErrInsufficientStock.
+// It reports false if the order already held stock, and returns ctx.Err()
+// if ctx ends before the lookups finish.
+func (e *External) Reserve(ctx context.Context, orderID string, items []domain.LineItem) (bool, error) {
+	levels, err := e.fetchLevels(ctx, items)
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.reserve(orderID, items, func(sku string) int { return levels[sku] })
+}
+
+// Release returns the stock held by the order. Releasing an order that holds
+// nothing is a no-op.
+func (e *External) Release(ctx context.Context, orderID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.release(orderID)
+	return nil
+}
+
+// fetchLevels looks up each distinct SKU in its own goroutine.
+func (e *External) fetchLevels(ctx context.Context, items []domain.LineItem) (map[string]int, error) {
+	type result struct {
+		sku   string
+		level int
+	}
+
+	want := quantities(items)
+	// Buffered so lookups still running when ctx ends don't block forever
+	results := make(chan result, len(want))
+	for sku := range want {
+		go func(sku string) {
+			results <- result{sku: sku, level: e.lookup(sku)}
+		}(sku)
+	}
+
+	levels := make(map[string]int, len(want))
+	for range want {
+		select {
+		case r := <-results:
+			levels[r.sku] = r.level
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return levels, nil
+}
@@ -0,0 +1,74 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lab10/internal/domain"
+)
+
+func items(qty int) []domain.LineItem {
+	return []domain.LineItem{{ProductID: "SKU-1", Quantity: qty}}
+}
+
+// TestMemoryReservations checks stock held by one order isn't available to
+// another until it is released, and that a failed reservation holds nothing.
+func TestMemoryReservations(t *testing.T) {
+	ctx := context.Background()
+	inv := NewMemory(0)
+	inv.SetLevel("SKU-1", 5)
+
+	if created, err := inv.Reserve(ctx, "ORD-1", items(3)); err != nil || !created {
+		t.Fatalf("Reserve() = %v, %v, want true", created, err)
+	}
+	// A repeated reservation for the same order must not hold more stock,
+	// and must not claim the reservation as its own
+	if created, err := inv.Reserve(ctx, "ORD-1", items(3)); err != nil || created {
+		t.Fatalf("repeated Reserve() = %v, %v, want false", created, err)
+	}
+	if got := inv.Available("SKU-1"); got != 2 {
+		t.Fatalf("Available() = %d, want 2", got)
+	}
+
+	both := append(items(2), domain.LineItem{ProductID: "SKU-2", Quantity: 1})
+	if _, err := inv.Reserve(ctx, "ORD-2", both); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("Reserve() with unstocked SKU error = %v, want ErrInsufficientStock", err)
+	}
+	if got := inv.Available("SKU-1"); got != 2 {
+		t.Fatalf("Available() after failed reservation = %d, want 2", got)
+	}
+
+	if err := inv.Release(ctx, "ORD-1"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := inv.Reserve(ctx, "ORD-2", items(5)); err != nil {
+		t.Fatalf("Reserve() after release error = %v", err)
+	}
+}
+
+// TestExternalReservations checks looked-up levels are reduced by local
+// reservations and that a cancelled context stops a slow lookup.
+func TestExternalReservations(t *testing.T) {
+	ctx := context.Background()
+	inv := NewExternal(func(sku string) int { return 4 })
+
+	if created, err := inv.Reserve(ctx, "ORD-1", items(3)); err != nil || !created {
+		t.Fatalf("Reserve() = %v, %v, want true", created, err)
+	}
+	if created, err := inv.Reserve(ctx, "ORD-1", items(3)); err != nil || created {
+		t.Fatalf("repeated Reserve() = %v, %v, want false", created, err)
+	}
+	if _, err := inv.Reserve(ctx, "ORD-2", items(2)); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("Reserve() beyond level error = %v, want ErrInsufficientStock", err)
+	}
+
+	blocked := make(chan struct{})
+	defer close(blocked)
+	slow := NewExternal(func(sku string) int { <-blocked; return 10 })
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := slow.Reserve(cancelled, "ORD-1", items(1)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Reserve() with cancelled context error = %v, want context.Canceled", err)
+	}
+}
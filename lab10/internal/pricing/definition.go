@@ -0,0 +1,230 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"lab10/internal/domain"
+)
+
+// Definition is the declarative (JSON/YAML) form of a pricing pipeline.
+// Percentages are decimal strings such as "12.5"; amounts are decimal strings
+// in the currency of the discount, or of the definition if the discount
+// doesn't name one, or domain.DefaultCurrency.
+type Definition struct {
+	Currency  string               `json:"currency" yaml:"currency"`
+	Discounts []DiscountDefinition `json:"discounts" yaml:"discounts"`
+	Tax       *TaxDefinition       `json:"tax" yaml:"tax"`
+}
+
+// DiscountDefinition declares one discount rule. Type selects the rule and
+// which other fields it uses:
+//
+//	percent: Percent, MinSubtotal
+//	fixed:   Amount, MinSubtotal
+//	volume:  Tiers
+//	coupon:  Coupons
+type DiscountDefinition struct {
+	Name        string             `json:"name" yaml:"name"`
+	Type        string             `json:"type" yaml:"type"`
+	Currency    string             `json:"currency" yaml:"currency"`
+	Percent     string             `json:"percent" yaml:"percent"`
+	Amount      string             `json:"amount" yaml:"amount"`
+	MinSubtotal string             `json:"min_subtotal" yaml:"min_subtotal"`
+	Tiers       []TierDefinition   `json:"tiers" yaml:"tiers"`
+	Coupons     []CouponDefinition `json:"coupons" yaml:"coupons"`
+}
+
+// TierDefinition declares one volume tier.
+type TierDefinition struct {
+	MinQuantity int    `json:"min_quantity" yaml:"min_quantity"`
+	Percent     string `json:"percent" yaml:"percent"`
+}
+
+// CouponDefinition declares one coupon code, worth either Percent or Amount.
+type CouponDefinition struct {
+	Code        string `json:"code" yaml:"code"`
+	Percent     string `json:"percent" yaml:"percent"`
+	Amount      string `json:"amount" yaml:"amount"`
+	MinSubtotal string `json:"min_subtotal" yaml:"min_subtotal"`
+}
+
+// TaxDefinition declares region-based tax. Regions maps region codes to
+// percentages; DefaultRate applies everywhere else.
+type TaxDefinition struct {
+	Name        string            `json:"name" yaml:"name"`
+	DefaultRate string            `json:"default_rate" yaml:"default_rate"`
+	Regions     map[string]string `json:"regions" yaml:"regions"`
+}
+
+// FromDefinition validates def and builds a pipeline from it.
+func FromDefinition(def Definition) (*Pipeline, error) {
+	currency := def.Currency
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+
+	var discounts []Rule
+	names := make(map[string]bool)
+	for i, dd := range def.Discounts {
+		if dd.Name == "" {
+			dd.Name = dd.Type
+		}
+		if names[dd.Name] {
+			return nil, fmt.Errorf("pricing: duplicate discount name %q", dd.Name)
+		}
+		names[dd.Name] = true
+		if dd.Currency == "" {
+			dd.Currency = currency
+		}
+
+		rule, err := dd.rule()
+		if err != nil {
+			return nil, fmt.Errorf("pricing: discount %d (%s): %w", i, dd.Name, err)
+		}
+		discounts = append(discounts, rule)
+	}
+
+	var taxes []Rule
+	if def.Tax != nil {
+		rule, err := def.Tax.rule()
+		if err != nil {
+			return nil, fmt.Errorf("pricing: tax: %w", err)
+		}
+		taxes = append(taxes, rule)
+	}
+	return NewPipeline(discounts, taxes), nil
+}
+
+// rule builds the Rule declared by dd.
+func (dd DiscountDefinition) rule() (Rule, error) {
+	min, err := parseMinimum(dd.MinSubtotal, dd.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	switch dd.Type {
+	case "percent":
+		bp, err := ParsePercent(dd.Percent)
+		if err != nil {
+			return nil, err
+		}
+		return PercentDiscount{Name: dd.Name, BasisPoints: bp, MinSubtotal: min}, nil
+	case "fixed":
+		amount, err := parseDiscountAmount(dd.Amount, dd.Currency)
+		if err != nil {
+			return nil, err
+		}
+		return FixedDiscount{Name: dd.Name, Amount: amount, MinSubtotal: min}, nil
+	case "volume":
+		if len(dd.Tiers) == 0 {
+			return nil, errors.New("at least one tier is required")
+		}
+		rule := VolumeDiscount{Name: dd.Name}
+		for _, td := range dd.Tiers {
+			if td.MinQuantity <= 0 {
+				return nil, errors.New("tier min_quantity must be positive")
+			}
+			bp, err := ParsePercent(td.Percent)
+			if err != nil {
+				return nil, err
+			}
+			rule.Tiers = append(rule.Tiers, VolumeTier{MinQuantity: td.MinQuantity, BasisPoints: bp})
+		}
+		return rule, nil
+	case "coupon":
+		if len(dd.Coupons) == 0 {
+			return nil, errors.New("at least one coupon is required")
+		}
+		rule := Coupons{Name: dd.Name, Codes: make(map[string]Coupon)}
+		for _, cd := range dd.Coupons {
+			code := strings.ToUpper(strings.TrimSpace(cd.Code))
+			if code == "" {
+				return nil, errors.New("coupon code is required")
+			}
+			if _, ok := rule.Codes[code]; ok {
+				return nil, fmt.Errorf("duplicate coupon code %q", code)
+			}
+			coupon, err := cd.coupon(dd.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("coupon %s: %w", code, err)
+			}
+			rule.Codes[code] = coupon
+		}
+		return rule, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q: must be percent, fixed, volume or coupon", dd.Type)
+	}
+}
+
+// coupon builds the Coupon declared by cd.
+func (cd CouponDefinition) coupon(currency string) (Coupon, error) {
+	if (cd.Percent == "") == (cd.Amount == "") {
+		return Coupon{}, errors.New("exactly one of percent and amount is required")
+	}
+	min, err := parseMinimum(cd.MinSubtotal, currency)
+	if err != nil {
+		return Coupon{}, err
+	}
+	coupon := Coupon{MinSubtotal: min}
+	if cd.Amount != "" {
+		amount, err := parseDiscountAmount(cd.Amount, currency)
+		if err != nil {
+			return Coupon{}, err
+		}
+		coupon.Amount = &amount
+		return coupon, nil
+	}
+	if coupon.BasisPoints, err = ParsePercent(cd.Percent); err != nil {
+		return Coupon{}, err
+	}
+	return coupon, nil
+}
+
+// rule builds the RegionTax declared by td.
+func (td TaxDefinition) rule() (Rule, error) {
+	rule := RegionTax{Name: td.Name, Rates: make(map[string]int64)}
+	if rule.Name == "" {
+		rule.Name = "tax"
+	}
+	if td.DefaultRate != "" {
+		bp, err := ParsePercent(td.DefaultRate)
+		if err != nil {
+			return nil, fmt.Errorf("default_rate: %w", err)
+		}
+		rule.DefaultBasisPoints = bp
+	}
+	for _, region := range sortedKeys(td.Regions) {
+		bp, err := ParsePercent(td.Regions[region])
+		if err != nil {
+			return nil, fmt.Errorf("region %s: %w", region, err)
+		}
+		rule.Rates[strings.ToUpper(region)] = bp
+	}
+	return rule, nil
+}
+
+// parseMinimum parses an optional minimum subtotal.
+func parseMinimum(decimal, currency string) (*domain.Money, error) {
+	if decimal == "" {
+		return nil, nil
+	}
+	min, err := domain.NewMoney(decimal, currency)
+	if err != nil {
+		return nil, fmt.Errorf("min_subtotal: %w", err)
+	}
+	return &min, nil
+}
+
+// parseDiscountAmount parses a fixed discount, which must be positive.
+func parseDiscountAmount(decimal, currency string) (domain.Money, error) {
+	amount, err := domain.NewMoney(decimal, currency)
+	if err != nil {
+		return domain.Money{}, fmt.Errorf("amount: %w", err)
+	}
+	if amount.Amount <= 0 {
+		return domain.Money{}, errors.New("amount must be positive")
+	}
+	return amount, nil
+}
@@ -0,0 +1,149 @@
+// Package pricing turns an order's line items into a priced total.
+//
+// A Pipeline starts from the item subtotal, applies its discount rules in
+// order, then its tax rules, and records every step in a
+// domain.PriceBreakdown. Discounts see the amount left after earlier
+// discounts and can never take it below zero; taxes are charged on the
+// discounted amount.
+package pricing
+
+import (
+	"errors"
+	"fmt"
+
+	"lab10/internal/domain"
+)
+
+// ErrUnknownCoupon indicates an order carrying a coupon code that no rule accepted.
+var ErrUnknownCoupon = errors.New("unknown coupon code")
+
+// Rule is one step of a pricing pipeline. Discount rules call
+// Calculation.AddDiscount, tax rules Calculation.AddTax; a rule that doesn't
+// apply to the order simply does nothing.
+type Rule interface {
+	Apply(c *Calculation) error
+}
+
+// Calculation is the state of one pricing run, handed to each rule in turn.
+type Calculation struct {
+	Order     *domain.Order
+	Breakdown domain.PriceBreakdown
+
+	couponRedeemed bool
+}
+
+// Currency returns the currency the order is priced in.
+func (c *Calculation) Currency() string {
+	return c.Breakdown.Subtotal.Currency
+}
+
+// Discounted returns the subtotal minus the discounts applied so far.
+func (c *Calculation) Discounted() domain.Money {
+	return domain.Money{
+		Amount:   c.Breakdown.Subtotal.Amount - c.Breakdown.DiscountTotal.Amount,
+		Currency: c.Currency(),
+	}
+}
+
+// AddDiscount records a discount. Amounts larger than what is left of the
+// subtotal are capped; zero amounts are ignored.
+func (c *Calculation) AddDiscount(rule, description string, amount domain.Money) error {
+	if amount.Currency != c.Currency() {
+		return fmt.Errorf("pricing: rule %s: %w: %s and %s", rule, domain.ErrCurrencyMismatch, amount.Currency, c.Currency())
+	}
+	if remaining := c.Discounted(); amount.Amount > remaining.Amount {
+		amount = remaining
+	}
+	if amount.Amount <= 0 {
+		return nil
+	}
+	c.Breakdown.Discounts = append(c.Breakdown.Discounts, domain.PriceAdjustment{Rule: rule, Description: description, Amount: amount})
+	c.Breakdown.DiscountTotal.Amount += amount.Amount
+	return nil
+}
+
+// AddTax records a tax charge. Zero amounts are ignored.
+func (c *Calculation) AddTax(rule, description string, amount domain.Money) error {
+	if amount.Currency != c.Currency() {
+		return fmt.Errorf("pricing: rule %s: %w: %s and %s", rule, domain.ErrCurrencyMismatch, amount.Currency, c.Currency())
+	}
+	if amount.Amount <= 0 {
+		return nil
+	}
+	tax, err := c.Breakdown.Tax.Add(amount)
+	if err != nil {
+		return fmt.Errorf("pricing: rule %s: %w", rule, err)
+	}
+	c.Breakdown.Taxes = append(c.Breakdown.Taxes, domain.PriceAdjustment{Rule: rule, Description: description, Amount: amount})
+	c.Breakdown.Tax = tax
+	return nil
+}
+
+// RedeemCoupon marks the order's coupon code as accepted by a rule.
+func (c *Calculation) RedeemCoupon() {
+	c.couponRedeemed = true
+}
+
+// Pipeline prices orders with a fixed list of rules.
+// Immutable and safe for concurrent use.
+type Pipeline struct {
+	discounts []Rule
+	taxes     []Rule
+}
+
+// NewPipeline creates a pipeline that applies discounts in order, then taxes.
+// With no rules at all an order's total is its item subtotal.
+func NewPipeline(discounts []Rule, taxes []Rule) *Pipeline {
+	return &Pipeline{discounts: discounts, taxes: taxes}
+}
+
+// Price calculates the breakdown for an order without modifying it.
+// Fails if the items can't be summed, a rule fails, or the order has a
+// coupon code that no rule accepted (ErrUnknownCoupon).
+func (p *Pipeline) Price(order *domain.Order) (*domain.PriceBreakdown, error) {
+	items := domain.Order{Items: order.Items, TotalAmount: domain.Zero(order.Currency())}
+	if err := items.CalculateTotal(); err != nil {
+		return nil, err
+	}
+	currency := items.TotalAmount.Currency
+
+	c := &Calculation{
+		Order: order,
+		Breakdown: domain.PriceBreakdown{
+			Subtotal:      items.TotalAmount,
+			DiscountTotal: domain.Zero(currency),
+			Tax:           domain.Zero(currency),
+		},
+	}
+	for _, rule := range p.discounts {
+		if err := rule.Apply(c); err != nil {
+			return nil, err
+		}
+	}
+	if order.CouponCode != "" && !c.couponRedeemed {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCoupon, order.CouponCode)
+	}
+	for _, rule := range p.taxes {
+		if err := rule.Apply(c); err != nil {
+			return nil, err
+		}
+	}
+
+	total, err := c.Discounted().Add(c.Breakdown.Tax)
+	if err != nil {
+		return nil, err
+	}
+	c.Breakdown.Total = total
+	return &c.Breakdown, nil
+}
+
+// Apply prices the order and stores the result in its Pricing and TotalAmount.
+func (p *Pipeline) Apply(order *domain.Order) error {
+	breakdown, err := p.Price(order)
+	if err != nil {
+		return err
+	}
+	order.Pricing = breakdown
+	order.TotalAmount = breakdown.Total
+	return nil
+}
@@ -0,0 +1,166 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+
+	"lab10/internal/domain"
+)
+
+// testDefinition mirrors pricing.example.yaml.
+var testDefinition = Definition{
+	Currency: "USD",
+	Discounts: []DiscountDefinition{
+		{Name: "bulk", Type: "volume", Tiers: []TierDefinition{{MinQuantity: 10, Percent: "5"}, {MinQuantity: 50, Percent: "10"}}},
+		{Name: "coupons", Type: "coupon", Coupons: []CouponDefinition{
+			{Code: "WELCOME10", Percent: "10"},
+			{Code: "FIVEOFF", Amount: "5.00", MinSubtotal: "25.00"},
+		}},
+		{Name: "big-order", Type: "fixed", Amount: "20.00", MinSubtotal: "500.00"},
+	},
+	Tax: &TaxDefinition{Name: "sales-tax", Regions: map[string]string{"US-CA": "7.25", "DE": "19"}},
+}
+
+func newOrder(quantity int, unitPrice domain.Money, coupon, region string) *domain.Order {
+	return &domain.Order{
+		Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: quantity, UnitPrice: unitPrice}},
+		CouponCode: coupon,
+		Region:     region,
+	}
+}
+
+// TestPipelinePrice runs orders through a pipeline with every rule type.
+func TestPipelinePrice(t *testing.T) {
+	pipeline, err := FromDefinition(testDefinition)
+	if err != nil {
+		t.Fatalf("FromDefinition() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		order        *domain.Order
+		wantDiscount string
+		wantTax      string
+		wantTotal    string
+		wantRules    []string
+	}{
+		{"no rules apply", newOrder(2, domain.MustMoney("10.00", "USD"), "", ""), "0.00", "0.00", "20.00", nil},
+		// 100.00 - 5% volume = 95.00, - 10% coupon = 85.50, + 7.25% tax (6.19875) = 91.70
+		{"volume, coupon and tax", newOrder(10, domain.MustMoney("10.00", "USD"), "welcome10", "us-ca"), "14.50", "6.20", "91.70", []string{"bulk", "coupons", "sales-tax"}},
+		{"coupon below its minimum", newOrder(2, domain.MustMoney("10.00", "USD"), "FIVEOFF", ""), "0.00", "0.00", "20.00", nil},
+		{"fixed discount over minimum", newOrder(1, domain.MustMoney("600.00", "USD"), "FIVEOFF", "DE"), "25.00", "109.25", "684.25", []string{"coupons", "big-order", "sales-tax"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pipeline.Price(tt.order)
+			if err != nil {
+				t.Fatalf("Price() error = %v", err)
+			}
+			if got.DiscountTotal.Decimal() != tt.wantDiscount || got.Tax.Decimal() != tt.wantTax || got.Total.Decimal() != tt.wantTotal {
+				t.Errorf("Price() = discounts %v, tax %v, total %v; want %s, %s, %s",
+					got.DiscountTotal, got.Tax, got.Total, tt.wantDiscount, tt.wantTax, tt.wantTotal)
+			}
+			var rules []string
+			for _, a := range append(got.Discounts, got.Taxes...) {
+				rules = append(rules, a.Rule)
+			}
+			if len(rules) != len(tt.wantRules) {
+				t.Fatalf("applied rules = %v, want %v", rules, tt.wantRules)
+			}
+			for i := range rules {
+				if rules[i] != tt.wantRules[i] {
+					t.Errorf("applied rules = %v, want %v", rules, tt.wantRules)
+					break
+				}
+			}
+		})
+	}
+
+	// USD rules don't apply to EUR orders
+	got, err := pipeline.Price(newOrder(1, domain.MustMoney("600.00", "EUR"), "", ""))
+	if err != nil {
+		t.Fatalf("Price() error = %v", err)
+	}
+	if got.Total != domain.MustMoney("600.00", "EUR") || len(got.Discounts) != 0 {
+		t.Errorf("Price() of EUR order = %v with %d discounts, want 600.00 EUR without discounts", got.Total, len(got.Discounts))
+	}
+
+	if _, err := pipeline.Price(newOrder(1, domain.MustMoney("10.00", "USD"), "NOPE", "")); !errors.Is(err, ErrUnknownCoupon) {
+		t.Errorf("Price() with unknown coupon error = %v, want %v", err, ErrUnknownCoupon)
+	}
+}
+
+// TestPipelineDiscountCapped checks discounts never take the total below zero.
+func TestPipelineDiscountCapped(t *testing.T) {
+	pipeline := NewPipeline([]Rule{
+		FixedDiscount{Name: "first", Amount: domain.MustMoney("5.00", "USD")},
+		FixedDiscount{Name: "second", Amount: domain.MustMoney("5.00", "USD")},
+	}, nil)
+
+	order := newOrder(1, domain.MustMoney("3.00", "USD"), "", "")
+	if err := pipeline.Apply(order); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !order.TotalAmount.IsZero() || order.Pricing.DiscountTotal != domain.MustMoney("3.00", "USD") {
+		t.Errorf("Apply() total = %v with discounts %v, want 0.00 with 3.00", order.TotalAmount, order.Pricing.DiscountTotal)
+	}
+	if len(order.Pricing.Discounts) != 1 {
+		t.Errorf("Discounts = %v, want only the first rule once nothing is left", order.Pricing.Discounts)
+	}
+}
+
+func TestFromDefinitionInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		def  Definition
+	}{
+		{"unknown type", Definition{Discounts: []DiscountDefinition{{Type: "bogo"}}}},
+		{"duplicate name", Definition{Discounts: []DiscountDefinition{{Type: "percent", Percent: "5"}, {Type: "percent", Percent: "10"}}}},
+		{"percent over 100", Definition{Discounts: []DiscountDefinition{{Type: "percent", Percent: "150"}}}},
+		{"negative amount", Definition{Discounts: []DiscountDefinition{{Type: "fixed", Amount: "-1"}}}},
+		{"unknown currency", Definition{Currency: "XXX", Discounts: []DiscountDefinition{{Type: "fixed", Amount: "1"}}}},
+		{"no tiers", Definition{Discounts: []DiscountDefinition{{Type: "volume"}}}},
+		{"coupon without value", Definition{Discounts: []DiscountDefinition{{Type: "coupon", Coupons: []CouponDefinition{{Code: "X"}}}}}},
+		{"duplicate coupon", Definition{Discounts: []DiscountDefinition{{Type: "coupon", Coupons: []CouponDefinition{{Code: "x", Percent: "1"}, {Code: "X", Percent: "2"}}}}}},
+		{"bad tax rate", Definition{Tax: &TaxDefinition{Regions: map[string]string{"DE": "nineteen"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromDefinition(tt.def); err == nil {
+				t.Error("FromDefinition() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		in        string
+		want      int64
+		wantError bool
+	}{
+		{"10", 1000, false},
+		{"7.25", 725, false},
+		{"12.5%", 1250, false},
+		{"0", 0, false},
+		{"100", 10000, false},
+		{"100.01", 0, true},
+		{"0.125", 0, true},
+		{"-5", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePercent(tt.in)
+		if (err != nil) != tt.wantError || got != tt.want {
+			t.Errorf("ParsePercent(%q) = %d, %v; want %d, error %v", tt.in, got, err, tt.want, tt.wantError)
+		}
+		if err == nil {
+			if back, _ := ParsePercent(FormatPercent(got)); back != got {
+				t.Errorf("ParsePercent(FormatPercent(%d)) = %d", got, back)
+			}
+		}
+	}
+}
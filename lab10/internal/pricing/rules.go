@@ -0,0 +1,208 @@
+package pricing
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lab10/internal/domain"
+)
+
+// PercentDiscount takes a percentage off orders whose subtotal is at least
+// MinSubtotal (if set; orders in another currency don't qualify).
+type PercentDiscount struct {
+	Name        string
+	BasisPoints int64 // hundredths of a percent, 1000 = 10%
+	MinSubtotal *domain.Money
+}
+
+// Apply implements Rule.
+func (d PercentDiscount) Apply(c *Calculation) error {
+	if !meetsMinimum(c, d.MinSubtotal) {
+		return nil
+	}
+	amount, err := c.Discounted().Percent(d.BasisPoints)
+	if err != nil {
+		return fmt.Errorf("pricing: rule %s: %w", d.Name, err)
+	}
+	return c.AddDiscount(d.Name, FormatPercent(d.BasisPoints)+" off"+minimumSuffix(d.MinSubtotal), amount)
+}
+
+// FixedDiscount takes a fixed amount off orders in the amount's currency
+// whose subtotal is at least MinSubtotal (if set).
+type FixedDiscount struct {
+	Name        string
+	Amount      domain.Money
+	MinSubtotal *domain.Money
+}
+
+// Apply implements Rule.
+func (d FixedDiscount) Apply(c *Calculation) error {
+	if d.Amount.Currency != c.Currency() || !meetsMinimum(c, d.MinSubtotal) {
+		return nil
+	}
+	return c.AddDiscount(d.Name, d.Amount.String()+" off"+minimumSuffix(d.MinSubtotal), d.Amount)
+}
+
+// VolumeTier discounts a line item by BasisPoints once its quantity
+// reaches MinQuantity.
+type VolumeTier struct {
+	MinQuantity int
+	BasisPoints int64
+}
+
+// VolumeDiscount discounts each line item by the highest tier its quantity reaches.
+type VolumeDiscount struct {
+	Name  string
+	Tiers []VolumeTier
+}
+
+// Apply implements Rule.
+func (d VolumeDiscount) Apply(c *Calculation) error {
+	for _, item := range c.Order.Items {
+		var best *VolumeTier
+		for i, tier := range d.Tiers {
+			if item.Quantity >= tier.MinQuantity && (best == nil || tier.MinQuantity > best.MinQuantity) {
+				best = &d.Tiers[i]
+			}
+		}
+		if best == nil {
+			continue
+		}
+		subtotal, err := item.Subtotal()
+		if err != nil {
+			return err
+		}
+		amount, err := subtotal.Percent(best.BasisPoints)
+		if err != nil {
+			return fmt.Errorf("pricing: rule %s: %w", d.Name, err)
+		}
+		description := fmt.Sprintf("%s off %s for %d or more", FormatPercent(best.BasisPoints), item.ProductID, best.MinQuantity)
+		if err := c.AddDiscount(d.Name, description, amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Coupon is the discount granted by one coupon code: either a percentage
+// (BasisPoints) or a fixed Amount, for orders whose subtotal is at least
+// MinSubtotal (if set).
+type Coupon struct {
+	BasisPoints int64
+	Amount      *domain.Money
+	MinSubtotal *domain.Money
+}
+
+// Coupons applies the discount of the order's coupon code. Codes are
+// matched case-insensitively. A known code whose conditions the order
+// doesn't meet is still accepted, it just gives no discount.
+type Coupons struct {
+	Name  string
+	Codes map[string]Coupon // keyed by upper-case code
+}
+
+// Apply implements Rule.
+func (d Coupons) Apply(c *Calculation) error {
+	code := strings.ToUpper(c.Order.CouponCode)
+	coupon, ok := d.Codes[code]
+	if code == "" || !ok {
+		return nil
+	}
+	c.RedeemCoupon()
+	if !meetsMinimum(c, coupon.MinSubtotal) {
+		return nil
+	}
+
+	if coupon.Amount != nil {
+		if coupon.Amount.Currency != c.Currency() {
+			return nil
+		}
+		return c.AddDiscount(d.Name, fmt.Sprintf("coupon %s: %s off", code, coupon.Amount), *coupon.Amount)
+	}
+	amount, err := c.Discounted().Percent(coupon.BasisPoints)
+	if err != nil {
+		return fmt.Errorf("pricing: rule %s: %w", d.Name, err)
+	}
+	return c.AddDiscount(d.Name, fmt.Sprintf("coupon %s: %s off", code, FormatPercent(coupon.BasisPoints)), amount)
+}
+
+// RegionTax charges tax on the discounted amount at the rate of the order's
+// region, or DefaultBasisPoints for regions without a rate of their own
+// (including orders without a region).
+type RegionTax struct {
+	Name               string
+	Rates              map[string]int64 // basis points keyed by upper-case region code
+	DefaultBasisPoints int64
+}
+
+// Apply implements Rule.
+func (t RegionTax) Apply(c *Calculation) error {
+	region := strings.ToUpper(c.Order.Region)
+	rate, ok := t.Rates[region]
+	if !ok {
+		rate = t.DefaultBasisPoints
+	}
+	amount, err := c.Discounted().Percent(rate)
+	if err != nil {
+		return fmt.Errorf("pricing: rule %s: %w", t.Name, err)
+	}
+	description := FormatPercent(rate) + " tax"
+	if ok {
+		description += " for " + region
+	}
+	return c.AddTax(t.Name, description, amount)
+}
+
+// meetsMinimum reports whether the order's subtotal reaches min.
+// A nil minimum always passes; one in another currency never does.
+func meetsMinimum(c *Calculation, min *domain.Money) bool {
+	if min == nil {
+		return true
+	}
+	cmp, err := c.Breakdown.Subtotal.Compare(*min)
+	return err == nil && cmp >= 0
+}
+
+// minimumSuffix describes a minimum subtotal for rule descriptions.
+func minimumSuffix(min *domain.Money) string {
+	if min == nil {
+		return ""
+	}
+	return " orders of " + min.String() + " or more"
+}
+
+// ParsePercent parses a percentage such as "12.5" into basis points (1250).
+// At most two decimal places are allowed and the value must be 0-100.
+func ParsePercent(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(strings.TrimSuffix(strings.TrimSpace(s), "%"), ".")
+	if whole == "" || len(frac) > 2 {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	bp, err := strconv.ParseUint(whole+frac, 10, 32)
+	if err != nil || bp > 10000 {
+		return 0, fmt.Errorf("invalid percentage %q: must be between 0 and 100", s)
+	}
+	return int64(bp), nil
+}
+
+// FormatPercent formats basis points as a percentage, e.g. 1250 as "12.5%".
+func FormatPercent(basisPoints int64) string {
+	s := strconv.FormatInt(basisPoints/100, 10)
+	if frac := basisPoints % 100; frac != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%02d", frac), "0")
+	}
+	return s + "%"
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
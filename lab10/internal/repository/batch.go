@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"fmt"
+
+	"lab10/internal/domain"
+)
+
+// StatusWrite is one status change in an UpdateStatusBatch call.
+type StatusWrite struct {
+	ID              string
+	Change          domain.StatusChange
+	ExpectedVersion int64
+}
+
+// BatchError reports which item made an all-or-nothing batch fail.
+// Nothing in the batch was written.
+type BatchError struct {
+	// Index is the position of the failing item in the batch.
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
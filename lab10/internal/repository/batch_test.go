@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lab10/internal/domain"
+)
+
+// TestBatchesAreAtomic checks a failing item leaves both repositories
+// untouched, and that a clean batch is applied in full.
+func TestBatchesAreAtomic(t *testing.T) {
+	ctx := context.Background()
+
+	sqliteRepo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() error = %v", err)
+	}
+	defer sqliteRepo.Close()
+
+	repos := map[string]OrderRepository{
+		"memory": NewMemoryRepository(),
+		"sqlite": sqliteRepo,
+	}
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			// ORD-2 appears twice, so nothing may be created
+			err := repo.CreateBatch(ctx, []*domain.Order{newTestOrder("ORD-1"), newTestOrder("ORD-2"), newTestOrder("ORD-2")})
+			var batchErr *BatchError
+			if !errors.As(err, &batchErr) || batchErr.Index != 2 || !errors.Is(err, ErrAlreadyExists) {
+				t.Fatalf("CreateBatch() error = %v, want BatchError at index 2 wrapping ErrAlreadyExists", err)
+			}
+			if _, err := repo.Get(ctx, "ORD-1"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get() after failed batch error = %v, want ErrNotFound", err)
+			}
+
+			if err := repo.CreateBatch(ctx, []*domain.Order{newTestOrder("ORD-1"), newTestOrder("ORD-2")}); err != nil {
+				t.Fatalf("CreateBatch() error = %v", err)
+			}
+
+			confirm := domain.StatusChange{FromStatus: domain.StatusPending, ToStatus: domain.StatusConfirmed, Actor: "test", Timestamp: time.Now()}
+			ship := domain.StatusChange{FromStatus: domain.StatusConfirmed, ToStatus: domain.StatusShipped, Actor: "test", Timestamp: time.Now()}
+
+			// The stale version on ORD-2 must roll back the change to ORD-1
+			err = repo.UpdateStatusBatch(ctx, []StatusWrite{
+				{ID: "ORD-1", Change: confirm, ExpectedVersion: 1},
+				{ID: "ORD-2", Change: confirm, ExpectedVersion: 5},
+			})
+			if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, ErrVersionConflict) {
+				t.Fatalf("UpdateStatusBatch() error = %v, want BatchError at index 1 wrapping ErrVersionConflict", err)
+			}
+			if order, _ := repo.Get(ctx, "ORD-1"); order.Status != domain.StatusPending || order.Version != 1 {
+				t.Fatalf("ORD-1 after failed batch = %s v%d, want pending v1", order.Status, order.Version)
+			}
+
+			// Two writes to the same order chain their versions
+			err = repo.UpdateStatusBatch(ctx, []StatusWrite{
+				{ID: "ORD-1", Change: confirm, ExpectedVersion: 1},
+				{ID: "ORD-1", Change: ship, ExpectedVersion: 2},
+				{ID: "ORD-2", Change: confirm, ExpectedVersion: 1},
+			})
+			if err != nil {
+				t.Fatalf("UpdateStatusBatch() error = %v", err)
+			}
+			if order, _ := repo.Get(ctx, "ORD-1"); order.Status != domain.StatusShipped || order.Version != 3 {
+				t.Fatalf("ORD-1 = %s v%d, want shipped v3", order.Status, order.Version)
+			}
+			if history, _ := repo.GetHistory(ctx, "ORD-1"); len(history) != 2 {
+				t.Fatalf("ORD-1 history has %d entries, want 2", len(history))
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"lab10/internal/domain"
+)
+
+var (
+	// ErrCustomerNotFound indicates the requested customer doesn't exist.
+	ErrCustomerNotFound = errors.New("customer not found")
+
+	// ErrCustomerAlreadyExists indicates a customer with this ID already exists.
+	ErrCustomerAlreadyExists = errors.New("customer already exists")
+)
+
+// CustomerRepository defines data access for customers. Both MemoryRepository
+// and SQLiteRepository implement it alongside OrderRepository, so customer
+// stats can be computed next to the orders they summarize.
+type CustomerRepository interface {
+	// CreateCustomer stores a new customer and sets its CreatedAt and UpdatedAt.
+	// Returns ErrCustomerAlreadyExists if the ID is taken.
+	CreateCustomer(ctx context.Context, customer *domain.Customer) error
+
+	// GetCustomer retrieves a customer by ID. Returns ErrCustomerNotFound if it doesn't exist.
+	GetCustomer(ctx context.Context, id string) (*domain.Customer, error)
+
+	// UpdateCustomer replaces an existing customer if its stored version still
+	// equals customer.Version, then increments the version. Returns
+	// ErrCustomerNotFound if it doesn't exist and ErrVersionConflict if the
+	// version doesn't match.
+	UpdateCustomer(ctx context.Context, customer *domain.Customer) error
+
+	// CustomerStats summarizes the orders placed for a customer ID, excluding
+	// deleted orders. It doesn't check the customer exists.
+	CustomerStats(ctx context.Context, customerID string) (*domain.CustomerStats, error)
+}
+
+// Store is a repository holding orders, customers and products, as
+// MemoryRepository and SQLiteRepository do.
+type Store interface {
+	OrderRepository
+	CustomerRepository
+	ProductRepository
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lab10/internal/domain"
+)
+
+// TestCustomers checks customer CRUD and that stats skip deleted orders and
+// leave cancelled ones out of the lifetime value.
+func TestCustomers(t *testing.T) {
+	ctx := context.Background()
+
+	sqliteRepo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() error = %v", err)
+	}
+	defer sqliteRepo.Close()
+
+	repos := map[string]Store{
+		"memory": NewMemoryRepository(),
+		"sqlite": sqliteRepo,
+	}
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			customer := &domain.Customer{ID: "CUST-001", Name: "Ada", Email: "ada@example.com", Status: domain.CustomerActive, Version: 1}
+			if err := repo.CreateCustomer(ctx, customer); err != nil {
+				t.Fatalf("CreateCustomer() error = %v", err)
+			}
+			if err := repo.CreateCustomer(ctx, customer); !errors.Is(err, ErrCustomerAlreadyExists) {
+				t.Fatalf("second CreateCustomer() error = %v, want ErrCustomerAlreadyExists", err)
+			}
+			if _, err := repo.GetCustomer(ctx, "CUST-404"); !errors.Is(err, ErrCustomerNotFound) {
+				t.Fatalf("GetCustomer() of unknown customer error = %v, want ErrCustomerNotFound", err)
+			}
+
+			customer.Status = domain.CustomerInactive
+			if err := repo.UpdateCustomer(ctx, customer); err != nil {
+				t.Fatalf("UpdateCustomer() error = %v", err)
+			}
+			if err := repo.UpdateCustomer(ctx, customer); !errors.Is(err, ErrVersionConflict) {
+				t.Fatalf("UpdateCustomer() with stale version error = %v, want ErrVersionConflict", err)
+			}
+			stored, err := repo.GetCustomer(ctx, "CUST-001")
+			if err != nil {
+				t.Fatalf("GetCustomer() error = %v", err)
+			}
+			if stored.Status != domain.CustomerInactive || stored.Version != 2 {
+				t.Fatalf("customer = %s v%d, want inactive v2", stored.Status, stored.Version)
+			}
+
+			stats, err := repo.CustomerStats(ctx, "CUST-001")
+			if err != nil {
+				t.Fatalf("CustomerStats() error = %v", err)
+			}
+			if stats.OrderCount != 0 || len(stats.LifetimeValue) != 0 || stats.LastOrderAt != nil {
+				t.Fatalf("stats without orders = %+v, want empty", stats)
+			}
+
+			// 44.48 USD each: one counts, one is cancelled, one is deleted
+			for _, id := range []string{"ORD-1", "ORD-2", "ORD-3"} {
+				if err := repo.Create(ctx, newTestOrder(id)); err != nil {
+					t.Fatalf("Create(%s) error = %v", id, err)
+				}
+			}
+			cancel := domain.StatusChange{FromStatus: domain.StatusPending, ToStatus: domain.StatusCancelled, Actor: "test", Timestamp: time.Now()}
+			if err := repo.UpdateStatus(ctx, "ORD-2", cancel, 1); err != nil {
+				t.Fatalf("UpdateStatus() error = %v", err)
+			}
+			if err := repo.Delete(ctx, "ORD-3"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			other := newTestOrder("ORD-4")
+			other.CustomerID = "CUST-002"
+			if err := repo.Create(ctx, other); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			stats, err = repo.CustomerStats(ctx, "CUST-001")
+			if err != nil {
+				t.Fatalf("CustomerStats() error = %v", err)
+			}
+			if stats.OrderCount != 2 {
+				t.Errorf("OrderCount = %d, want 2", stats.OrderCount)
+			}
+			if len(stats.LifetimeValue) != 1 || stats.LifetimeValue[0] != domain.MustMoney("44.48", "USD") {
+				t.Errorf("LifetimeValue = %v, want [44.48 USD]", stats.LifetimeValue)
+			}
+			if stats.LastOrderAt == nil {
+				t.Error("LastOrderAt is nil, want the creation time of ORD-2")
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"lab10/internal/domain"
+)
+
+// IdempotencyRecord is what an IdempotencyStore remembers about one key.
+type IdempotencyRecord struct {
+	// Fingerprint identifies the request payload the key was first used with.
+	Fingerprint string
+
+	// Order is the created order as first returned to the client.
+	// Nil while the first request is still in progress.
+	Order *domain.Order
+
+	ExpiresAt time.Time
+}
+
+// IdempotencyStore remembers the result of requests made with an idempotency
+// key, so retries of the same request can be answered with the first result.
+type IdempotencyStore interface {
+	// Reserve claims key for a request with the given fingerprint until
+	// expiresAt and returns nil. If the key is already held and has not
+	// expired, it claims nothing and returns the existing record instead.
+	Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*IdempotencyRecord, error)
+
+	// Complete stores the result for a key claimed with Reserve.
+	Complete(ctx context.Context, key string, order *domain.Order) error
+
+	// Release drops a claimed key whose request failed, so it can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// idempotencySweepInterval is how often expired keys are removed from memory.
+const idempotencySweepInterval = time.Minute
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore.
+// Keys are lost on restart and not shared between instances.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]*IdempotencyRecord
+	nextSweep time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory idempotency store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]*IdempotencyRecord)}
+}
+
+// Reserve claims key unless an unexpired record for it exists.
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if record, exists := s.records[key]; exists && now.Before(record.ExpiresAt) {
+		recordCopy := *record
+		return &recordCopy, nil
+	}
+
+	s.records[key] = &IdempotencyRecord{Fingerprint: fingerprint, ExpiresAt: expiresAt}
+	return nil, nil
+}
+
+// Complete stores a copy of the order as the key's result.
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key string, order *domain.Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.records[key]
+	if !exists {
+		return ErrNotFound
+	}
+	orderCopy := *order
+	orderCopy.Items = append([]domain.LineItem{}, order.Items...)
+	record.Order = &orderCopy
+	return nil
+}
+
+// Release forgets key.
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// sweep drops expired records, at most once per idempotencySweepInterval.
+// Callers must hold s.mu.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+	s.nextSweep = now.Add(idempotencySweepInterval)
+}
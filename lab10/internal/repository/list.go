@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"lab10/internal/domain"
+)
+
+// ErrInvalidPageToken indicates a page token that is malformed or was issued
+// for a different sort order.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// SortField is a column orders can be sorted by. Ties are always broken by ID,
+// so every sort order is stable across pages.
+type SortField string
+
+const (
+	SortByCreatedAt   SortField = "created_at"
+	SortByUpdatedAt   SortField = "updated_at"
+	SortByTotalAmount SortField = "total_amount"
+	SortByID          SortField = "id"
+)
+
+// IsValid reports whether f is a supported sort field.
+func (f SortField) IsValid() bool {
+	switch f {
+	case SortByCreatedAt, SortByUpdatedAt, SortByTotalAmount, SortByID:
+		return true
+	}
+	return false
+}
+
+// ListFilter narrows the orders returned by List. Zero values mean "no filter".
+type ListFilter struct {
+	CustomerID    string
+	Status        domain.OrderStatus
+	CreatedAfter  time.Time     // inclusive
+	CreatedBefore time.Time     // exclusive
+	MinTotal      *domain.Money // inclusive; orders in other currencies don't match
+	MaxTotal      *domain.Money // inclusive; orders in other currencies don't match
+
+	// IncludeDeleted also returns soft-deleted orders.
+	IncludeDeleted bool
+}
+
+// Matches reports whether order passes every filter condition.
+func (f ListFilter) Matches(order *domain.Order) bool {
+	if !f.IncludeDeleted && order.IsDeleted() {
+		return false
+	}
+	if f.CustomerID != "" && order.CustomerID != f.CustomerID {
+		return false
+	}
+	if f.Status != "" && order.Status != f.Status {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && order.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !order.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	if f.MinTotal != nil {
+		if cmp, err := order.TotalAmount.Compare(*f.MinTotal); err != nil || cmp < 0 {
+			return false
+		}
+	}
+	if f.MaxTotal != nil {
+		if cmp, err := order.TotalAmount.Compare(*f.MaxTotal); err != nil || cmp > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ListOptions controls filtering, sorting and cursor-based pagination for List.
+type ListOptions struct {
+	Filter     ListFilter
+	SortBy     SortField // defaults to SortByCreatedAt
+	Descending bool
+
+	// PageSize limits the number of orders returned. Zero or negative means no limit.
+	PageSize int
+
+	// PageToken is the NextPageToken from a previous call with the same sort order.
+	PageToken string
+}
+
+// ListResult is one page of orders.
+type ListResult struct {
+	Orders []*domain.Order
+
+	// NextPageToken fetches the following page. Empty when there are no more orders.
+	NextPageToken string
+}
+
+// pageCursor is the position of the last order on a page.
+// It is serialized into the opaque page token handed to clients.
+type pageCursor struct {
+	SortBy      SortField `json:"s"`
+	Descending  bool      `json:"d,omitempty"`
+	ID          string    `json:"i"`
+	CreatedAt   int64     `json:"c,omitempty"`
+	UpdatedAt   int64     `json:"u,omitempty"`
+	TotalAmount int64     `json:"t,omitempty"` // minor units
+}
+
+// encodePageToken builds the token pointing just after order.
+func encodePageToken(order *domain.Order, opts ListOptions) string {
+	c := pageCursor{
+		SortBy:     opts.SortBy,
+		Descending: opts.Descending,
+		ID:         order.ID,
+	}
+	switch opts.SortBy {
+	case SortByCreatedAt:
+		c.CreatedAt = order.CreatedAt.UnixNano()
+	case SortByUpdatedAt:
+		c.UpdatedAt = order.UpdatedAt.UnixNano()
+	case SortByTotalAmount:
+		c.TotalAmount = order.TotalAmount.Amount
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePageToken parses a token and checks it matches the requested sort order.
+// An empty token returns a nil cursor (start from the first page).
+func decodePageToken(token string, opts ListOptions) (*pageCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	if c.SortBy != opts.SortBy || c.Descending != opts.Descending {
+		return nil, ErrInvalidPageToken
+	}
+	return &c, nil
+}
+
+// order returns a placeholder order holding the cursor's sort key values,
+// so it can be compared with compareOrders.
+func (c *pageCursor) order() *domain.Order {
+	return &domain.Order{
+		ID:          c.ID,
+		CreatedAt:   time.Unix(0, c.CreatedAt),
+		UpdatedAt:   time.Unix(0, c.UpdatedAt),
+		TotalAmount: domain.Money{Amount: c.TotalAmount},
+	}
+}
+
+// compareOrders orders a and b by the sort field, then by ID.
+// Totals are compared by minor units regardless of currency.
+// Returns a negative number if a sorts first, positive if b does, zero if equal.
+func compareOrders(a, b *domain.Order, sortBy SortField) int {
+	var cmp int
+	switch sortBy {
+	case SortByCreatedAt:
+		cmp = a.CreatedAt.Compare(b.CreatedAt)
+	case SortByUpdatedAt:
+		cmp = a.UpdatedAt.Compare(b.UpdatedAt)
+	case SortByTotalAmount:
+		switch {
+		case a.TotalAmount.Amount < b.TotalAmount.Amount:
+			cmp = -1
+		case a.TotalAmount.Amount > b.TotalAmount.Amount:
+			cmp = 1
+		}
+	}
+	if cmp != 0 {
+		return cmp
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// normalize fills in defaults and rejects unsupported options.
+func (opts ListOptions) normalize() (ListOptions, error) {
+	if opts.SortBy == "" {
+		opts.SortBy = SortByCreatedAt
+	}
+	if !opts.SortBy.IsValid() {
+		return opts, errors.New("unsupported sort field: " + string(opts.SortBy))
+	}
+	return opts, nil
+}
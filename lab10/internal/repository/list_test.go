@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"lab10/internal/domain"
+)
+
+// TestListPagination walks every page of both repositories and checks the
+// concatenated pages are complete, filtered and correctly ordered.
+func TestListPagination(t *testing.T) {
+	ctx := context.Background()
+
+	sqliteRepo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() error = %v", err)
+	}
+	defer sqliteRepo.Close()
+
+	repos := map[string]OrderRepository{
+		"memory": NewMemoryRepository(),
+		"sqlite": sqliteRepo,
+	}
+
+	for name, repo := range repos {
+		for i := 0; i < 25; i++ {
+			order := newTestOrder(fmt.Sprintf("ORD-%03d", i))
+			order.TotalAmount = domain.Money{Amount: int64(i % 5), Currency: "USD"} // duplicate totals exercise the ID tie-break
+			if i%2 == 0 {
+				order.CustomerID = "CUST-EVEN"
+			}
+			if err := repo.Create(ctx, order); err != nil {
+				t.Fatalf("%s: Create() error = %v", name, err)
+			}
+		}
+
+		tests := []struct {
+			name      string
+			opts      ListOptions
+			wantCount int
+			wantFirst string
+		}{
+			{"by id ascending", ListOptions{SortBy: SortByID, PageSize: 7}, 25, "ORD-000"},
+			{"by id descending", ListOptions{SortBy: SortByID, Descending: true, PageSize: 4}, 25, "ORD-024"},
+			{"by total descending", ListOptions{SortBy: SortByTotalAmount, Descending: true, PageSize: 3}, 25, "ORD-024"},
+			{"filtered by customer", ListOptions{SortBy: SortByID, PageSize: 5, Filter: ListFilter{CustomerID: "CUST-EVEN"}}, 13, "ORD-000"},
+			{"single page", ListOptions{SortBy: SortByCreatedAt}, 25, ""},
+		}
+
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				var all []*domain.Order
+				opts := tt.opts
+				for {
+					page, err := repo.List(ctx, opts)
+					if err != nil {
+						t.Fatalf("List() error = %v", err)
+					}
+					all = append(all, page.Orders...)
+					if page.NextPageToken == "" {
+						break
+					}
+					opts.PageToken = page.NextPageToken
+				}
+
+				if len(all) != tt.wantCount {
+					t.Fatalf("got %d orders, want %d", len(all), tt.wantCount)
+				}
+				if tt.wantFirst != "" && all[0].ID != tt.wantFirst {
+					t.Errorf("first order = %s, want %s", all[0].ID, tt.wantFirst)
+				}
+				for i := 1; i < len(all); i++ {
+					cmp := compareOrders(all[i-1], all[i], opts.SortBy)
+					if opts.Descending {
+						cmp = -cmp
+					}
+					if cmp >= 0 {
+						t.Fatalf("orders %s and %s out of order", all[i-1].ID, all[i].ID)
+					}
+				}
+			})
+		}
+
+		t.Run(name+"/token from other sort order", func(t *testing.T) {
+			page, err := repo.List(ctx, ListOptions{SortBy: SortByID, PageSize: 2})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			_, err = repo.List(ctx, ListOptions{SortBy: SortByTotalAmount, PageSize: 2, PageToken: page.NextPageToken})
+			if !errors.Is(err, ErrInvalidPageToken) {
+				t.Errorf("error = %v, want %v", err, ErrInvalidPageToken)
+			}
+		})
+	}
+}
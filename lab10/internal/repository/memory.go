@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"cmp"
 	"context"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,14 +15,23 @@ import (
 // Uses a map with mutex for thread-safe concurrent access.
 // Good for testing and demos, not for production (data lost on restart).
 type MemoryRepository struct {
-	mu     sync.RWMutex
-	orders map[string]*domain.Order
+	mu        sync.RWMutex
+	orders    map[string]*domain.Order
+	history   map[string][]domain.StatusChange
+	customers map[string]*domain.Customer
+	products  map[string]*domain.Product
+
+	outbox      []domain.Event // undelivered events, oldest first
+	nextEventID int64
 }
 
 // NewMemoryRepository creates a new in-memory repository.
 func NewMemoryRepository() *MemoryRepository {
 	return &MemoryRepository{
-		orders: make(map[string]*domain.Order),
+		orders:    make(map[string]*domain.Order),
+		history:   make(map[string][]domain.StatusChange),
+		customers: make(map[string]*domain.Customer),
+		products:  make(map[string]*domain.Product),
 	}
 }
 
@@ -31,14 +43,39 @@ func (r *MemoryRepository) Create(ctx context.Context, order *domain.Order) erro
 	if _, exists := r.orders[order.ID]; exists {
 		return ErrAlreadyExists
 	}
+	r.create(order)
+	return nil
+}
+
+// CreateBatch stores all orders or none. Every order is checked before the
+// first one is stored, so a failure leaves the repository untouched.
+func (r *MemoryRepository) CreateBatch(ctx context.Context, orders []*domain.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[string]bool, len(orders))
+	for i, order := range orders {
+		if _, exists := r.orders[order.ID]; exists || seen[order.ID] {
+			return &BatchError{Index: i, Err: ErrAlreadyExists}
+		}
+		seen[order.ID] = true
+	}
+	for _, order := range orders {
+		r.create(order)
+	}
+	return nil
+}
+
+// create stores a copy of a new order and sets the order's timestamps.
+// Callers must hold the write lock and have checked the ID is free.
+func (r *MemoryRepository) create(order *domain.Order) {
+	now := time.Now()
+	order.CreatedAt, order.UpdatedAt = now, now
 
 	// Store a copy to prevent external modification
 	orderCopy := *order
-	orderCopy.CreatedAt = time.Now()
-	orderCopy.UpdatedAt = time.Now()
 	r.orders[order.ID] = &orderCopy
-
-	return nil
+	r.recordEvent(domain.NewOrderEvent(domain.EventOrderCreated, &orderCopy, orderCopy.UpdatedAt))
 }
 
 // Get retrieves an order by ID.
@@ -46,7 +83,7 @@ func (r *MemoryRepository) Get(ctx context.Context, id string) (*domain.Order, e
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	order, exists := r.orders[id]
+	order, exists := r.active(id)
 	if !exists {
 		return nil, ErrNotFound
 	}
@@ -56,6 +93,19 @@ func (r *MemoryRepository) Get(ctx context.Context, id string) (*domain.Order, e
 	return &orderCopy, nil
 }
 
+// GetIncludingDeleted retrieves an order by ID, even if it is deleted.
+func (r *MemoryRepository) GetIncludingDeleted(ctx context.Context, id string) (*domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order, exists := r.orders[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	orderCopy := *order
+	return &orderCopy, nil
+}
+
 // GetAll returns all orders.
 func (r *MemoryRepository) GetAll(ctx context.Context) ([]*domain.Order, error) {
 	r.mu.RLock()
@@ -63,6 +113,9 @@ func (r *MemoryRepository) GetAll(ctx context.Context) ([]*domain.Order, error)
 
 	orders := make([]*domain.Order, 0, len(r.orders))
 	for _, order := range r.orders {
+		if order.IsDeleted() {
+			continue
+		}
 		orderCopy := *order
 		orders = append(orders, &orderCopy)
 	}
@@ -70,47 +123,395 @@ func (r *MemoryRepository) GetAll(ctx context.Context) ([]*domain.Order, error)
 	return orders, nil
 }
 
-// Update replaces an existing order.
+// List returns one page of orders matching opts.Filter, sorted by opts.SortBy.
+// Scans every order, which is fine for the data sizes an in-memory store holds.
+func (r *MemoryRepository) List(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodePageToken(opts.PageToken, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	matches := make([]*domain.Order, 0)
+	for _, order := range r.orders {
+		if opts.Filter.Matches(order) {
+			orderCopy := *order
+			matches = append(matches, &orderCopy)
+		}
+	}
+	r.mu.RUnlock()
+
+	compare := func(a, b *domain.Order) int {
+		if opts.Descending {
+			return compareOrders(b, a, opts.SortBy)
+		}
+		return compareOrders(a, b, opts.SortBy)
+	}
+	slices.SortFunc(matches, compare)
+
+	// Skip everything up to and including the cursor position
+	if cursor != nil {
+		last := cursor.order()
+		start, _ := slices.BinarySearchFunc(matches, last, compare)
+		for start < len(matches) && compare(matches[start], last) <= 0 {
+			start++
+		}
+		matches = matches[start:]
+	}
+
+	result := &ListResult{Orders: matches}
+	if opts.PageSize > 0 && len(matches) > opts.PageSize {
+		result.Orders = matches[:opts.PageSize]
+		result.NextPageToken = encodePageToken(result.Orders[opts.PageSize-1], opts)
+	}
+	return result, nil
+}
+
+// Update replaces an existing order if its version hasn't changed.
 func (r *MemoryRepository) Update(ctx context.Context, order *domain.Order) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.orders[order.ID]; !exists {
+	existing, exists := r.active(order.ID)
+	if !exists {
 		return ErrNotFound
 	}
+	if existing.Version != order.Version {
+		return ErrVersionConflict
+	}
 
 	orderCopy := *order
+	orderCopy.CreatedAt = existing.CreatedAt
 	orderCopy.UpdatedAt = time.Now()
+	orderCopy.Version++
 	r.orders[order.ID] = &orderCopy
+	r.recordEvent(domain.NewOrderEvent(domain.EventOrderUpdated, &orderCopy, orderCopy.UpdatedAt))
 
 	return nil
 }
 
-// UpdateStatus changes only the order status.
-func (r *MemoryRepository) UpdateStatus(ctx context.Context, id string, status domain.OrderStatus) error {
+// UpdateStatus changes the order status and records the change, if its version hasn't changed.
+func (r *MemoryRepository) UpdateStatus(ctx context.Context, id string, change domain.StatusChange, expectedVersion int64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	order, exists := r.orders[id]
+	order, exists := r.active(id)
 	if !exists {
 		return ErrNotFound
 	}
+	if order.Version != expectedVersion {
+		return ErrVersionConflict
+	}
+	r.updateStatus(order, change)
+	return nil
+}
 
-	order.Status = status
-	order.UpdatedAt = time.Now()
+// UpdateStatusBatch applies all status changes or none. Versions are checked
+// up front, counting earlier writes in the batch to the same order.
+func (r *MemoryRepository) UpdateStatusBatch(ctx context.Context, writes []StatusWrite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
+	versions := make(map[string]int64, len(writes))
+	for i, w := range writes {
+		version, seen := versions[w.ID]
+		if !seen {
+			order, exists := r.active(w.ID)
+			if !exists {
+				return &BatchError{Index: i, Err: ErrNotFound}
+			}
+			version = order.Version
+		}
+		if version != w.ExpectedVersion {
+			return &BatchError{Index: i, Err: ErrVersionConflict}
+		}
+		versions[w.ID] = version + 1
+	}
+	for _, w := range writes {
+		r.updateStatus(r.orders[w.ID], w.Change)
+	}
 	return nil
 }
 
-// Delete removes an order.
+// updateStatus applies a checked status change. Callers must hold the write lock.
+func (r *MemoryRepository) updateStatus(order *domain.Order, change domain.StatusChange) {
+	id := order.ID
+
+	// Replace rather than mutate, so copies handed out earlier stay unchanged
+	orderCopy := *order
+	orderCopy.Status = change.ToStatus
+	orderCopy.UpdatedAt = time.Now()
+	orderCopy.Version++
+	r.orders[id] = &orderCopy
+	r.history[id] = append(r.history[id], change)
+
+	event := domain.NewOrderEvent(domain.EventOrderStatusChanged, &orderCopy, orderCopy.UpdatedAt)
+	event.StatusChange = &change
+	r.recordEvent(event)
+}
+
+// Delete marks an order as deleted.
 func (r *MemoryRepository) Delete(ctx context.Context, id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.orders[id]; !exists {
+	order, exists := r.active(id)
+	if !exists {
 		return ErrNotFound
 	}
 
-	delete(r.orders, id)
+	now := time.Now()
+	orderCopy := *order
+	orderCopy.DeletedAt = &now
+	orderCopy.UpdatedAt = now
+	orderCopy.Version++
+	r.orders[id] = &orderCopy
+	r.recordEvent(domain.NewOrderEvent(domain.EventOrderDeleted, &orderCopy, now))
+	return nil
+}
+
+// Restore clears the deleted mark of an order.
+func (r *MemoryRepository) Restore(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, exists := r.orders[id]
+	if !exists {
+		return ErrNotFound
+	}
+	if !order.IsDeleted() {
+		return ErrNotDeleted
+	}
+
+	orderCopy := *order
+	orderCopy.DeletedAt = nil
+	orderCopy.UpdatedAt = time.Now()
+	orderCopy.Version++
+	r.orders[id] = &orderCopy
+	r.recordEvent(domain.NewOrderEvent(domain.EventOrderRestored, &orderCopy, orderCopy.UpdatedAt))
+	return nil
+}
+
+// Purge permanently removes orders deleted before deletedBefore.
+func (r *MemoryRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	purged := 0
+	for id, order := range r.orders {
+		if order.IsDeleted() && order.DeletedAt.Before(deletedBefore) {
+			delete(r.orders, id)
+			delete(r.history, id)
+			r.recordEvent(domain.NewOrderEvent(domain.EventOrderPurged, order, now))
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// active returns the order with the given ID unless it is missing or deleted.
+// Callers must hold the lock.
+func (r *MemoryRepository) active(id string) (*domain.Order, bool) {
+	order, exists := r.orders[id]
+	if !exists || order.IsDeleted() {
+		return nil, false
+	}
+	return order, true
+}
+
+// GetHistory returns the recorded status changes of an order, oldest first.
+func (r *MemoryRepository) GetHistory(ctx context.Context, id string) ([]domain.StatusChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.active(id); !exists {
+		return nil, ErrNotFound
+	}
+
+	// Return a copy so callers can't rewrite history
+	return append([]domain.StatusChange{}, r.history[id]...), nil
+}
+
+// recordEvent appends an event to the outbox. Callers must hold the write lock,
+// which makes the event part of the same atomic step as the change.
+func (r *MemoryRepository) recordEvent(event domain.Event) {
+	r.nextEventID++
+	event.ID = r.nextEventID
+	r.outbox = append(r.outbox, event)
+}
+
+// PendingEvents returns up to limit undelivered events with an ID greater
+// than after, oldest first.
+func (r *MemoryRepository) PendingEvents(ctx context.Context, after int64, limit int) ([]domain.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	start, _ := slices.BinarySearchFunc(r.outbox, after+1, func(event domain.Event, id int64) int {
+		return cmp.Compare(event.ID, id)
+	})
+	pending := r.outbox[start:]
+	return slices.Clone(pending[:min(limit, len(pending))]), nil
+}
+
+// MarkDelivered removes a published event from the outbox.
+func (r *MemoryRepository) MarkDelivered(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.outbox = slices.DeleteFunc(r.outbox, func(event domain.Event) bool {
+		return event.ID == id
+	})
+	return nil
+}
+
+// MarkFailed is a no-op: the event simply stays pending. Unlike SQLite, the
+// in-memory store keeps no delivery diagnostics.
+func (r *MemoryRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	return nil
+}
+
+// CreateCustomer stores a new customer in memory.
+func (r *MemoryRepository) CreateCustomer(ctx context.Context, customer *domain.Customer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.customers[customer.ID]; exists {
+		return ErrCustomerAlreadyExists
+	}
+	customer.CreatedAt = time.Now()
+	customer.UpdatedAt = customer.CreatedAt
+	customerCopy := *customer
+	r.customers[customer.ID] = &customerCopy
+	return nil
+}
+
+// GetCustomer retrieves a customer by ID.
+func (r *MemoryRepository) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	customer, exists := r.customers[id]
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+	customerCopy := *customer
+	return &customerCopy, nil
+}
+
+// UpdateCustomer replaces a customer if its version hasn't changed.
+func (r *MemoryRepository) UpdateCustomer(ctx context.Context, customer *domain.Customer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.customers[customer.ID]
+	if !exists {
+		return ErrCustomerNotFound
+	}
+	if existing.Version != customer.Version {
+		return ErrVersionConflict
+	}
+	customerCopy := *customer
+	customerCopy.CreatedAt = existing.CreatedAt
+	customerCopy.UpdatedAt = time.Now()
+	customerCopy.Version++
+	r.customers[customer.ID] = &customerCopy
+	return nil
+}
+
+// CustomerStats summarizes the customer's orders by scanning all of them.
+func (r *MemoryRepository) CustomerStats(ctx context.Context, customerID string) (*domain.CustomerStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := &domain.CustomerStats{CustomerID: customerID, LifetimeValue: []domain.Money{}}
+	for _, order := range r.orders {
+		if order.CustomerID != customerID || order.IsDeleted() {
+			continue
+		}
+		if err := stats.AddOrder(order); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// CreateProduct stores a new product in memory.
+func (r *MemoryRepository) CreateProduct(ctx context.Context, product *domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.products[product.SKU]; exists {
+		return ErrProductAlreadyExists
+	}
+	product.CreatedAt = time.Now()
+	product.UpdatedAt = product.CreatedAt
+	productCopy := *product
+	r.products[product.SKU] = &productCopy
+	return nil
+}
+
+// GetProduct retrieves a product by SKU.
+func (r *MemoryRepository) GetProduct(ctx context.Context, sku string) (*domain.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	product, exists := r.products[sku]
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+	productCopy := *product
+	return &productCopy, nil
+}
+
+// ListProducts returns copies of all products sorted by SKU.
+func (r *MemoryRepository) ListProducts(ctx context.Context) ([]*domain.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make([]*domain.Product, 0, len(r.products))
+	for _, product := range r.products {
+		productCopy := *product
+		products = append(products, &productCopy)
+	}
+	slices.SortFunc(products, func(a, b *domain.Product) int {
+		return strings.Compare(a.SKU, b.SKU)
+	})
+	return products, nil
+}
+
+// UpdateProduct replaces a product if its version hasn't changed.
+func (r *MemoryRepository) UpdateProduct(ctx context.Context, product *domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.products[product.SKU]
+	if !exists {
+		return ErrProductNotFound
+	}
+	if existing.Version != product.Version {
+		return ErrVersionConflict
+	}
+	productCopy := *product
+	productCopy.CreatedAt = existing.CreatedAt
+	productCopy.UpdatedAt = time.Now()
+	productCopy.Version++
+	r.products[product.SKU] = &productCopy
+	return nil
+}
+
+// DeleteProduct removes a product from memory.
+func (r *MemoryRepository) DeleteProduct(ctx context.Context, sku string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.products[sku]; !exists {
+		return ErrProductNotFound
+	}
+	delete(r.products, sku)
 	return nil
 }
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"lab10/internal/domain"
+)
+
+// Outbox gives access to the domain events recorded alongside order changes
+// (the transactional outbox pattern). Create, Update, UpdateStatus and Delete
+// each append an event in the same atomic step as the change, so an event
+// exists if and only if its change was committed. A relay reads pending
+// events, publishes them and then marks them delivered.
+type Outbox interface {
+	// PendingEvents returns up to limit undelivered events with an ID
+	// greater than after, oldest first. Pass 0 to start with the oldest.
+	PendingEvents(ctx context.Context, after int64, limit int) ([]domain.Event, error)
+
+	// MarkDelivered removes a published event from the outbox.
+	MarkDelivered(ctx context.Context, id int64) error
+
+	// MarkFailed records a failed delivery attempt. The event stays pending.
+	MarkFailed(ctx context.Context, id int64, reason string) error
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"lab10/internal/domain"
+)
+
+// TestOutboxRecordsEvents checks both repositories record one event per
+// successful write, in commit order, and none for failed writes.
+func TestOutboxRecordsEvents(t *testing.T) {
+	ctx := context.Background()
+
+	sqliteRepo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() error = %v", err)
+	}
+	defer sqliteRepo.Close()
+
+	repos := map[string]OrderRepository{
+		"memory": NewMemoryRepository(),
+		"sqlite": sqliteRepo,
+	}
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			order := newTestOrder("ORD-1")
+			if err := repo.Create(ctx, order); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if err := repo.Create(ctx, order); err == nil {
+				t.Fatal("Create() duplicate error = nil")
+			}
+			change := domain.StatusChange{FromStatus: domain.StatusPending, ToStatus: domain.StatusConfirmed, Actor: "test", Timestamp: time.Now()}
+			if err := repo.UpdateStatus(ctx, order.ID, change, 1); err != nil {
+				t.Fatalf("UpdateStatus() error = %v", err)
+			}
+			if err := repo.UpdateStatus(ctx, order.ID, change, 1); err == nil {
+				t.Fatal("UpdateStatus() stale version error = nil")
+			}
+			if err := repo.Delete(ctx, order.ID); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+
+			events, err := repo.PendingEvents(ctx, 0, 10)
+			if err != nil {
+				t.Fatalf("PendingEvents() error = %v", err)
+			}
+			var types []domain.EventType
+			for _, event := range events {
+				types = append(types, event.Type)
+			}
+			want := []domain.EventType{domain.EventOrderCreated, domain.EventOrderStatusChanged, domain.EventOrderDeleted}
+			if !slices.Equal(types, want) {
+				t.Fatalf("event types = %v, want %v", types, want)
+			}
+			if got := events[1].Order; got == nil || got.Status != domain.StatusConfirmed || got.Version != 2 {
+				t.Errorf("status changed event order = %+v, want confirmed at version 2", got)
+			}
+
+			if err := repo.MarkDelivered(ctx, events[0].ID); err != nil {
+				t.Fatalf("MarkDelivered() error = %v", err)
+			}
+			if err := repo.MarkFailed(ctx, events[1].ID, "boom"); err != nil {
+				t.Fatalf("MarkFailed() error = %v", err)
+			}
+			remaining, err := repo.PendingEvents(ctx, 0, 10)
+			if err != nil {
+				t.Fatalf("PendingEvents() error = %v", err)
+			}
+			if len(remaining) != 2 || remaining[0].ID != events[1].ID {
+				t.Errorf("pending after delivery = %+v, want events %d and %d", remaining, events[1].ID, events[2].ID)
+			}
+			after, err := repo.PendingEvents(ctx, events[1].ID, 10)
+			if err != nil {
+				t.Fatalf("PendingEvents() after error = %v", err)
+			}
+			if len(after) != 1 || after[0].ID != events[2].ID {
+				t.Errorf("pending after event %d = %+v, want event %d", events[1].ID, after, events[2].ID)
+			}
+		})
+	}
+}
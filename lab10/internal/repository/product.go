@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"lab10/internal/domain"
+)
+
+var (
+	// ErrProductNotFound indicates the requested product doesn't exist.
+	ErrProductNotFound = errors.New("product not found")
+
+	// ErrProductAlreadyExists indicates a product with this SKU already exists.
+	ErrProductAlreadyExists = errors.New("product already exists")
+)
+
+// ProductRepository defines data access for the product catalog.
+// Orders copy name and price from the catalog when they are created, so
+// catalog changes never alter existing orders.
+type ProductRepository interface {
+	// CreateProduct stores a new product and sets its CreatedAt and UpdatedAt.
+	// Returns ErrProductAlreadyExists if the SKU is taken.
+	CreateProduct(ctx context.Context, product *domain.Product) error
+
+	// GetProduct retrieves a product by SKU. Returns ErrProductNotFound if it doesn't exist.
+	GetProduct(ctx context.Context, sku string) (*domain.Product, error)
+
+	// ListProducts returns all products sorted by SKU. Empty slice if none exist.
+	ListProducts(ctx context.Context) ([]*domain.Product, error)
+
+	// UpdateProduct replaces an existing product if its stored version still
+	// equals product.Version, then increments the version. Returns
+	// ErrProductNotFound if it doesn't exist and ErrVersionConflict if the
+	// version doesn't match.
+	UpdateProduct(ctx context.Context, product *domain.Product) error
+
+	// DeleteProduct removes a product. Returns ErrProductNotFound if it doesn't exist.
+	DeleteProduct(ctx context.Context, sku string) error
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	commondomain "golang-for-java-developers-training/common/domain"
+	"lab10/internal/domain"
+)
+
+// TestProducts checks catalog CRUD, including the SKU sort order of
+// ListProducts and the exact price surviving a round trip.
+func TestProducts(t *testing.T) {
+	ctx := context.Background()
+
+	sqliteRepo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() error = %v", err)
+	}
+	defer sqliteRepo.Close()
+
+	repos := map[string]Store{
+		"memory": NewMemoryRepository(),
+		"sqlite": sqliteRepo,
+	}
+
+	newProduct := func(sku, price string) *domain.Product {
+		p := &domain.Product{Product: commondomain.Product{SKU: sku, Name: "Widget " + sku}, Status: domain.ProductActive, Version: 1}
+		p.SetPrice(domain.MustMoney(price, "USD"))
+		return p
+	}
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			for _, p := range []*domain.Product{newProduct("SKU-2", "24.50"), newProduct("SKU-1", "9.99")} {
+				if err := repo.CreateProduct(ctx, p); err != nil {
+					t.Fatalf("CreateProduct(%s) error = %v", p.SKU, err)
+				}
+			}
+			if err := repo.CreateProduct(ctx, newProduct("SKU-1", "1.00")); !errors.Is(err, ErrProductAlreadyExists) {
+				t.Fatalf("CreateProduct() with taken SKU error = %v, want ErrProductAlreadyExists", err)
+			}
+
+			products, err := repo.ListProducts(ctx)
+			if err != nil {
+				t.Fatalf("ListProducts() error = %v", err)
+			}
+			if len(products) != 2 || products[0].SKU != "SKU-1" || products[1].SKU != "SKU-2" {
+				t.Fatalf("ListProducts() returned %d products, want SKU-1 and SKU-2 in order", len(products))
+			}
+			if products[0].Price != domain.MustMoney("9.99", "USD") || products[0].BasePrice != 9.99 {
+				t.Fatalf("SKU-1 price = %v (base %v), want 9.99 USD", products[0].Price, products[0].BasePrice)
+			}
+
+			product := products[0]
+			product.Status = domain.ProductDiscontinued
+			if err := repo.UpdateProduct(ctx, product); err != nil {
+				t.Fatalf("UpdateProduct() error = %v", err)
+			}
+			if err := repo.UpdateProduct(ctx, product); !errors.Is(err, ErrVersionConflict) {
+				t.Fatalf("UpdateProduct() with stale version error = %v, want ErrVersionConflict", err)
+			}
+			if stored, _ := repo.GetProduct(ctx, "SKU-1"); stored.Status != domain.ProductDiscontinued || stored.Version != 2 {
+				t.Fatalf("SKU-1 = %s v%d, want discontinued v2", stored.Status, stored.Version)
+			}
+
+			if err := repo.DeleteProduct(ctx, "SKU-2"); err != nil {
+				t.Fatalf("DeleteProduct() error = %v", err)
+			}
+			if _, err := repo.GetProduct(ctx, "SKU-2"); !errors.Is(err, ErrProductNotFound) {
+				t.Fatalf("GetProduct() after delete error = %v, want ErrProductNotFound", err)
+			}
+			if err := repo.DeleteProduct(ctx, "SKU-2"); !errors.Is(err, ErrProductNotFound) {
+				t.Fatalf("second DeleteProduct() error = %v, want ErrProductNotFound", err)
+			}
+		})
+	}
+}
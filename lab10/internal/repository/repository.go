@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"lab10/internal/domain"
 )
@@ -13,27 +14,79 @@ var (
 
 	// ErrAlreadyExists indicates an order with this ID already exists.
 	ErrAlreadyExists = errors.New("order already exists")
+
+	// ErrVersionConflict indicates the order was modified since it was read.
+	ErrVersionConflict = errors.New("order version conflict")
+
+	// ErrNotDeleted indicates an attempt to restore an order that isn't deleted.
+	ErrNotDeleted = errors.New("order is not deleted")
 )
 
 // OrderRepository defines the interface for order data access.
 // Using an interface allows us to swap implementations (in-memory, database, etc.)
 // without changing business logic. This is the Repository pattern.
+//
+// Every successful write also records a domain event in the Outbox.
+//
+// Deletes are soft: a deleted order keeps its data but is treated as missing
+// (ErrNotFound) by every method except GetIncludingDeleted, List with
+// IncludeDeleted, Restore and Purge.
 type OrderRepository interface {
-	// Create stores a new order. Returns ErrAlreadyExists if ID is duplicate.
+	Outbox
+
+	// Create stores a new order and sets its CreatedAt and UpdatedAt to the
+	// stored times. Returns ErrAlreadyExists if ID is duplicate, including
+	// the ID of a deleted order that hasn't been purged yet.
 	Create(ctx context.Context, order *domain.Order) error
 
 	// Get retrieves an order by ID. Returns ErrNotFound if it doesn't exist.
 	Get(ctx context.Context, id string) (*domain.Order, error)
 
-	// GetAll returns all orders. Empty slice if none exist.
+	// GetIncludingDeleted is like Get but also returns deleted orders.
+	GetIncludingDeleted(ctx context.Context, id string) (*domain.Order, error)
+
+	// GetAll returns all orders that aren't deleted. Empty slice if none exist.
 	GetAll(ctx context.Context) ([]*domain.Order, error)
 
-	// Update replaces an existing order. Returns ErrNotFound if it doesn't exist.
+	// List returns one page of orders matching the filter, in a stable sort order.
+	// Returns ErrInvalidPageToken if the page token can't be used with these options.
+	List(ctx context.Context, opts ListOptions) (*ListResult, error)
+
+	// Update replaces an existing order if its stored version still equals order.Version,
+	// then increments the version. Returns ErrNotFound if it doesn't exist and
+	// ErrVersionConflict if the version doesn't match.
 	Update(ctx context.Context, order *domain.Order) error
 
-	// UpdateStatus changes only the status field. Returns ErrNotFound if it doesn't exist.
-	UpdateStatus(ctx context.Context, id string, status domain.OrderStatus) error
+	// UpdateStatus sets the status to change.ToStatus and appends change to the
+	// order's history in one atomic step, if the stored version equals
+	// expectedVersion. Increments the version. Returns ErrNotFound if it
+	// doesn't exist and ErrVersionConflict if the version doesn't match.
+	UpdateStatus(ctx context.Context, id string, change domain.StatusChange, expectedVersion int64) error
 
-	// Delete removes an order. Returns ErrNotFound if it doesn't exist.
+	// GetHistory returns the status changes of an order, oldest first.
+	// Returns ErrNotFound if the order doesn't exist.
+	GetHistory(ctx context.Context, id string) ([]domain.StatusChange, error)
+
+	// CreateBatch stores all orders in one atomic step: either every order is
+	// created, with its timestamps set like Create, or none is. On failure the error is a *BatchError wrapping the
+	// Create error of the first order that couldn't be stored.
+	CreateBatch(ctx context.Context, orders []*domain.Order) error
+
+	// UpdateStatusBatch applies each write like UpdateStatus, in order, in one
+	// atomic step: either every change is applied or none is. On failure the
+	// error is a *BatchError wrapping the UpdateStatus error of the first
+	// write that couldn't be applied.
+	UpdateStatusBatch(ctx context.Context, writes []StatusWrite) error
+
+	// Delete soft-deletes an order: it sets DeletedAt and increments the
+	// version. Returns ErrNotFound if it doesn't exist.
 	Delete(ctx context.Context, id string) error
+
+	// Restore undoes a soft delete and increments the version. Returns
+	// ErrNotFound if the order doesn't exist and ErrNotDeleted if it isn't deleted.
+	Restore(ctx context.Context, id string) error
+
+	// Purge permanently removes orders deleted before deletedBefore, along
+	// with their history, and returns how many were removed.
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
 }
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestSoftDelete checks deleted orders are hidden but restorable, and that
+// Purge only removes orders deleted before the cutoff.
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+
+	sqliteRepo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() error = %v", err)
+	}
+	defer sqliteRepo.Close()
+
+	repos := map[string]OrderRepository{
+		"memory": NewMemoryRepository(),
+		"sqlite": sqliteRepo,
+	}
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			for _, id := range []string{"ORD-1", "ORD-2"} {
+				if err := repo.Create(ctx, newTestOrder(id)); err != nil {
+					t.Fatalf("Create(%s) error = %v", id, err)
+				}
+			}
+
+			if err := repo.Restore(ctx, "ORD-1"); !errors.Is(err, ErrNotDeleted) {
+				t.Fatalf("Restore() of live order error = %v, want ErrNotDeleted", err)
+			}
+			if err := repo.Delete(ctx, "ORD-1"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := repo.Delete(ctx, "ORD-1"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("second Delete() error = %v, want ErrNotFound", err)
+			}
+			if _, err := repo.Get(ctx, "ORD-1"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get() of deleted order error = %v, want ErrNotFound", err)
+			}
+			if err := repo.Create(ctx, newTestOrder("ORD-1")); !errors.Is(err, ErrAlreadyExists) {
+				t.Fatalf("Create() reusing deleted ID error = %v, want ErrAlreadyExists", err)
+			}
+
+			deleted, err := repo.GetIncludingDeleted(ctx, "ORD-1")
+			if err != nil {
+				t.Fatalf("GetIncludingDeleted() error = %v", err)
+			}
+			if !deleted.IsDeleted() || deleted.Version != 2 {
+				t.Fatalf("deleted order = deleted_at %v v%d, want deleted v2", deleted.DeletedAt, deleted.Version)
+			}
+
+			if result, _ := repo.List(ctx, ListOptions{}); len(result.Orders) != 1 {
+				t.Fatalf("List() returned %d orders, want 1", len(result.Orders))
+			}
+			if result, _ := repo.List(ctx, ListOptions{Filter: ListFilter{IncludeDeleted: true}}); len(result.Orders) != 2 {
+				t.Fatalf("List(IncludeDeleted) returned %d orders, want 2", len(result.Orders))
+			}
+
+			if err := repo.Restore(ctx, "ORD-1"); err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			restored, err := repo.Get(ctx, "ORD-1")
+			if err != nil {
+				t.Fatalf("Get() after Restore() error = %v", err)
+			}
+			if restored.IsDeleted() || restored.Version != 3 {
+				t.Fatalf("restored order = deleted_at %v v%d, want live v3", restored.DeletedAt, restored.Version)
+			}
+
+			// Only orders deleted before the cutoff are purged
+			if err := repo.Delete(ctx, "ORD-2"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if n, err := repo.Purge(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+				t.Fatalf("Purge(an hour ago) = %d, %v, want 0", n, err)
+			}
+			if n, err := repo.Purge(ctx, time.Now().Add(time.Second)); err != nil || n != 1 {
+				t.Fatalf("Purge(now) = %d, %v, want 1", n, err)
+			}
+			if _, err := repo.GetIncludingDeleted(ctx, "ORD-2"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("GetIncludingDeleted() of purged order error = %v, want ErrNotFound", err)
+			}
+			if err := repo.Create(ctx, newTestOrder("ORD-2")); err != nil {
+				t.Fatalf("Create() reusing purged ID error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,1023 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"lab10/internal/domain"
+)
+
+// migrations holds the schema changes for the SQLite store, applied in order.
+// Never edit an existing entry once released - append a new one instead.
+// The index+1 of each entry is its schema version.
+var migrations = []string{
+	// 1: orders and their line items
+	`CREATE TABLE orders (
+		id           TEXT PRIMARY KEY,
+		customer_id  TEXT NOT NULL,
+		status       TEXT NOT NULL,
+		total_amount REAL NOT NULL,
+		created_at   INTEGER NOT NULL,
+		updated_at   INTEGER NOT NULL
+	);
+	CREATE TABLE line_items (
+		order_id     TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+		position     INTEGER NOT NULL,
+		product_id   TEXT NOT NULL,
+		product_name TEXT NOT NULL,
+		quantity     INTEGER NOT NULL,
+		unit_price   REAL NOT NULL,
+		PRIMARY KEY (order_id, position)
+	);
+	CREATE INDEX idx_orders_customer_id ON orders(customer_id);`,
+
+	// 2: indexes backing the List sort orders
+	`CREATE INDEX idx_orders_created_at ON orders(created_at, id);
+	CREATE INDEX idx_orders_updated_at ON orders(updated_at, id);
+	CREATE INDEX idx_orders_total_amount ON orders(total_amount, id);`,
+
+	// 3: version column for optimistic concurrency control
+	`ALTER TABLE orders ADD COLUMN version INTEGER NOT NULL DEFAULT 1;`,
+
+	// 4: append-only status change history
+	`CREATE TABLE order_history (
+		seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id    TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+		from_status TEXT NOT NULL,
+		to_status   TEXT NOT NULL,
+		actor       TEXT NOT NULL,
+		reason      TEXT NOT NULL,
+		occurred_at INTEGER NOT NULL
+	);
+	CREATE INDEX idx_order_history_order_id ON order_history(order_id, seq);`,
+
+	// 5: exact money - REAL amounts become integer minor units plus an ISO 4217 currency.
+	// Existing rows predate multi-currency support and are all USD.
+	`ALTER TABLE orders ADD COLUMN total_minor INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE orders ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+	UPDATE orders SET total_minor = CAST(ROUND(total_amount * 100) AS INTEGER);
+	DROP INDEX idx_orders_total_amount;
+	ALTER TABLE orders DROP COLUMN total_amount;
+	CREATE INDEX idx_orders_total_minor ON orders(total_minor, id);
+	ALTER TABLE line_items ADD COLUMN unit_price_minor INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE line_items ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+	UPDATE line_items SET unit_price_minor = CAST(ROUND(unit_price * 100) AS INTEGER);
+	ALTER TABLE line_items DROP COLUMN unit_price;`,
+
+	// 6: transactional outbox of domain events awaiting delivery
+	`CREATE TABLE outbox (
+		seq        INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_type TEXT NOT NULL,
+		order_id   TEXT NOT NULL,
+		payload    TEXT NOT NULL,
+		attempts   INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT ''
+	);`,
+
+	// 7: soft deletes - deleted orders keep their rows until purged
+	`ALTER TABLE orders ADD COLUMN deleted_at INTEGER;
+	CREATE INDEX idx_orders_deleted_at ON orders(deleted_at);`,
+
+	// 8: customers. Orders keep referring to them by ID without a foreign key,
+	// so orders created before customers existed stay valid.
+	`CREATE TABLE customers (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		status TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		version INTEGER NOT NULL DEFAULT 1
+	);`,
+
+	// 9: product catalog
+	`CREATE TABLE products (
+		sku TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		price_minor INTEGER NOT NULL,
+		currency TEXT NOT NULL,
+		status TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		version INTEGER NOT NULL DEFAULT 1
+	);`,
+
+	// 10: pricing inputs and the price breakdown (JSON), NULL for orders priced before
+	`ALTER TABLE orders ADD COLUMN coupon_code TEXT NOT NULL DEFAULT '';
+	ALTER TABLE orders ADD COLUMN region TEXT NOT NULL DEFAULT '';
+	ALTER TABLE orders ADD COLUMN pricing TEXT;`,
+}
+
+// productColumns is the column list read by scanProduct.
+const productColumns = `sku, name, price_minor, currency, status, created_at, updated_at, version`
+
+// orderColumns is the column list read by scanOrder.
+const orderColumns = `id, customer_id, status, total_minor, currency, created_at, updated_at, version, deleted_at, coupon_code, region, pricing`
+
+// SQLiteRepository is a file-backed implementation of OrderRepository.
+// Orders survive restarts. Each order is stored as one row in "orders" plus
+// one row per line item in "line_items".
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository opens (or creates) the SQLite database at path and
+// applies any pending schema migrations. Use ":memory:" for a throwaway database.
+func NewSQLiteRepository(path string) (*SQLiteRepository, error) {
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", path)
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite allows a single writer at a time, and every connection to
+	// ":memory:" would get its own empty database, so use one connection.
+	db.SetMaxOpenConns(1)
+
+	repo := &SQLiteRepository{db: db}
+	if err := repo.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return repo, nil
+}
+
+// Close releases the underlying database handle.
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// migrate brings the schema up to date, recording the applied version in
+// schema_migrations. Each migration runs in its own transaction.
+func (r *SQLiteRepository) migrate(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		err := r.withTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// Create stores a new order and its line items.
+func (r *SQLiteRepository) Create(ctx context.Context, order *domain.Order) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		return createOrder(ctx, tx, order, time.Now())
+	})
+}
+
+// CreateBatch stores all orders in a single transaction.
+func (r *SQLiteRepository) CreateBatch(ctx context.Context, orders []*domain.Order) error {
+	now := time.Now()
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		for i, order := range orders {
+			if err := createOrder(ctx, tx, order, now); err != nil {
+				return &BatchError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+// Get retrieves an order by ID.
+func (r *SQLiteRepository) Get(ctx context.Context, id string) (*domain.Order, error) {
+	order, err := getOrder(ctx, r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.IsDeleted() {
+		return nil, ErrNotFound
+	}
+	return order, nil
+}
+
+// GetIncludingDeleted retrieves an order by ID, even if it is deleted.
+func (r *SQLiteRepository) GetIncludingDeleted(ctx context.Context, id string) (*domain.Order, error) {
+	return getOrder(ctx, r.db, id)
+}
+
+// GetAll returns all orders, oldest first.
+func (r *SQLiteRepository) GetAll(ctx context.Context) ([]*domain.Order, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+orderColumns+` FROM orders WHERE deleted_at IS NULL ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]*domain.Order, 0)
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	items, err := loadLineItems(ctx, r.db, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		order.Items = items[order.ID]
+	}
+	return orders, nil
+}
+
+// sortColumns maps sort fields to their column in the orders table.
+var sortColumns = map[SortField]string{
+	SortByCreatedAt:   "created_at",
+	SortByUpdatedAt:   "updated_at",
+	SortByTotalAmount: "total_minor",
+	SortByID:          "id",
+}
+
+// List returns one page of orders using keyset pagination on (sort column, id),
+// so deep pages cost the same as the first one.
+func (r *SQLiteRepository) List(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodePageToken(opts.PageToken, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var conds []string
+	var args []interface{}
+	f := opts.Filter
+	if !f.IncludeDeleted {
+		conds = append(conds, "deleted_at IS NULL")
+	}
+	if f.CustomerID != "" {
+		conds = append(conds, "customer_id = ?")
+		args = append(args, f.CustomerID)
+	}
+	if f.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, string(f.Status))
+	}
+	if !f.CreatedAfter.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, f.CreatedAfter.UnixNano())
+	}
+	if !f.CreatedBefore.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, f.CreatedBefore.UnixNano())
+	}
+	if f.MinTotal != nil {
+		conds = append(conds, "currency = ? AND total_minor >= ?")
+		args = append(args, f.MinTotal.Currency, f.MinTotal.Amount)
+	}
+	if f.MaxTotal != nil {
+		conds = append(conds, "currency = ? AND total_minor <= ?")
+		args = append(args, f.MaxTotal.Currency, f.MaxTotal.Amount)
+	}
+
+	column := sortColumns[opts.SortBy]
+	op, direction := ">", "ASC"
+	if opts.Descending {
+		op, direction = "<", "DESC"
+	}
+	if cursor != nil {
+		var last interface{}
+		switch opts.SortBy {
+		case SortByCreatedAt:
+			last = cursor.CreatedAt
+		case SortByUpdatedAt:
+			last = cursor.UpdatedAt
+		case SortByTotalAmount:
+			last = cursor.TotalAmount
+		case SortByID:
+			last = cursor.ID
+		}
+		conds = append(conds, fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, op, column, op))
+		args = append(args, last, last, cursor.ID)
+	}
+
+	query := `SELECT ` + orderColumns + ` FROM orders`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction)
+	if opts.PageSize > 0 {
+		// Fetch one extra row to find out whether another page exists
+		query += " LIMIT ?"
+		args = append(args, opts.PageSize+1)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]*domain.Order, 0)
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &ListResult{Orders: orders}
+	if opts.PageSize > 0 && len(orders) > opts.PageSize {
+		result.Orders = orders[:opts.PageSize]
+		result.NextPageToken = encodePageToken(result.Orders[opts.PageSize-1], opts)
+	}
+
+	if len(result.Orders) > 0 {
+		ids := make([]interface{}, len(result.Orders))
+		for i, order := range result.Orders {
+			ids[i] = order.ID
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+		items, err := loadLineItems(ctx, r.db, "WHERE order_id IN ("+placeholders+")", ids...)
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range result.Orders {
+			order.Items = items[order.ID]
+		}
+	}
+	return result, nil
+}
+
+// Update replaces an existing order, including all of its line items,
+// if its version hasn't changed.
+func (r *SQLiteRepository) Update(ctx context.Context, order *domain.Order) error {
+	now := time.Now()
+	pricing, err := marshalPricing(order.Pricing)
+	if err != nil {
+		return err
+	}
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE orders SET customer_id = ?, status = ?, total_minor = ?, currency = ?, coupon_code = ?, region = ?, pricing = ?,
+			 updated_at = ?, version = version + 1
+			 WHERE id = ? AND version = ? AND deleted_at IS NULL`,
+			order.CustomerID, string(order.Status), order.TotalAmount.Amount, order.TotalAmount.Currency,
+			order.CouponCode, order.Region, pricing, now.UnixNano(),
+			order.ID, order.Version)
+		if err != nil {
+			return err
+		}
+		if err := requireVersionedRowAffected(ctx, tx, res, order.ID); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM line_items WHERE order_id = ?`, order.ID); err != nil {
+			return err
+		}
+		if err := insertLineItems(ctx, tx, order.ID, order.Items); err != nil {
+			return err
+		}
+
+		updated, err := getOrder(ctx, tx, order.ID)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, domain.NewOrderEvent(domain.EventOrderUpdated, updated, now))
+	})
+}
+
+// UpdateStatus changes the order status and records the change in the same
+// transaction, if its version hasn't changed.
+func (r *SQLiteRepository) UpdateStatus(ctx context.Context, id string, change domain.StatusChange, expectedVersion int64) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		return updateStatus(ctx, tx, id, change, expectedVersion, time.Now())
+	})
+}
+
+// UpdateStatusBatch applies all status changes in a single transaction.
+func (r *SQLiteRepository) UpdateStatusBatch(ctx context.Context, writes []StatusWrite) error {
+	now := time.Now()
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		for i, w := range writes {
+			if err := updateStatus(ctx, tx, w.ID, w.Change, w.ExpectedVersion, now); err != nil {
+				return &BatchError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+// GetHistory returns the recorded status changes of an order, oldest first.
+func (r *SQLiteRepository) GetHistory(ctx context.Context, id string) ([]domain.StatusChange, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM orders WHERE id = ? AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT from_status, to_status, actor, reason, occurred_at
+		 FROM order_history WHERE order_id = ? ORDER BY seq`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]domain.StatusChange, 0)
+	for rows.Next() {
+		var change domain.StatusChange
+		var from, to string
+		var occurredAt int64
+		if err := rows.Scan(&from, &to, &change.Actor, &change.Reason, &occurredAt); err != nil {
+			return nil, err
+		}
+		change.FromStatus = domain.OrderStatus(from)
+		change.ToStatus = domain.OrderStatus(to)
+		change.Timestamp = time.Unix(0, occurredAt)
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
+// Delete marks an order as deleted. The rows stay until purged.
+func (r *SQLiteRepository) Delete(ctx context.Context, id string) error {
+	now := time.Now()
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE orders SET deleted_at = ?, updated_at = ?, version = version + 1
+			 WHERE id = ? AND deleted_at IS NULL`,
+			now.UnixNano(), now.UnixNano(), id)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrNotFound
+		}
+
+		deleted, err := getOrder(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, domain.NewOrderEvent(domain.EventOrderDeleted, deleted, now))
+	})
+}
+
+// Restore clears the deleted mark of an order.
+func (r *SQLiteRepository) Restore(ctx context.Context, id string) error {
+	now := time.Now()
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		order, err := getOrder(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if !order.IsDeleted() {
+			return ErrNotDeleted
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE orders SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE id = ?`,
+			now.UnixNano(), id)
+		if err != nil {
+			return err
+		}
+
+		restored, err := getOrder(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, domain.NewOrderEvent(domain.EventOrderRestored, restored, now))
+	})
+}
+
+// Purge permanently removes orders deleted before deletedBefore. Line items
+// and history are removed by the ON DELETE CASCADE.
+func (r *SQLiteRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	now := time.Now()
+	purged := 0
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT id FROM orders WHERE deleted_at IS NOT NULL AND deleted_at < ?`, deletedBefore.UnixNano())
+		if err != nil {
+			return err
+		}
+		var ids []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			order, err := getOrder(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM orders WHERE id = ?`, id); err != nil {
+				return err
+			}
+			if err := recordEvent(ctx, tx, domain.NewOrderEvent(domain.EventOrderPurged, order, now)); err != nil {
+				return err
+			}
+		}
+		purged = len(ids)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// PendingEvents returns up to limit undelivered events with a sequence
+// number greater than after, oldest first.
+func (r *SQLiteRepository) PendingEvents(ctx context.Context, after int64, limit int) ([]domain.Event, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT seq, payload FROM outbox WHERE seq > ? ORDER BY seq LIMIT ?`, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]domain.Event, 0)
+	for rows.Next() {
+		var seq int64
+		var payload string
+		if err := rows.Scan(&seq, &payload); err != nil {
+			return nil, err
+		}
+		var event domain.Event
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			return nil, fmt.Errorf("outbox event %d: %w", seq, err)
+		}
+		event.ID = seq
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// MarkDelivered removes a published event from the outbox.
+func (r *SQLiteRepository) MarkDelivered(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM outbox WHERE seq = ?`, id)
+	return err
+}
+
+// MarkFailed records a failed delivery attempt, kept for troubleshooting stuck events.
+func (r *SQLiteRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = ? WHERE seq = ?`, reason, id)
+	return err
+}
+
+// CreateCustomer inserts a new customer row.
+func (r *SQLiteRepository) CreateCustomer(ctx context.Context, customer *domain.Customer) error {
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO customers (id, name, email, status, created_at, updated_at, version) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		customer.ID, customer.Name, customer.Email, string(customer.Status), now.UnixNano(), now.UnixNano(), customer.Version)
+	if isPrimaryKeyViolation(err) {
+		return ErrCustomerAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	customer.CreatedAt, customer.UpdatedAt = now, now
+	return nil
+}
+
+// GetCustomer reads a customer row.
+func (r *SQLiteRepository) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	var customer domain.Customer
+	var status string
+	var createdAt, updatedAt int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, name, email, status, created_at, updated_at, version FROM customers WHERE id = ?`, id).
+		Scan(&customer.ID, &customer.Name, &customer.Email, &status, &createdAt, &updatedAt, &customer.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	customer.Status = domain.CustomerStatus(status)
+	customer.CreatedAt = time.Unix(0, createdAt)
+	customer.UpdatedAt = time.Unix(0, updatedAt)
+	return &customer, nil
+}
+
+// UpdateCustomer replaces a customer row if its version hasn't changed.
+func (r *SQLiteRepository) UpdateCustomer(ctx context.Context, customer *domain.Customer) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE customers SET name = ?, email = ?, status = ?, updated_at = ?, version = version + 1
+			 WHERE id = ? AND version = ?`,
+			customer.Name, customer.Email, string(customer.Status), time.Now().UnixNano(), customer.ID, customer.Version)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n > 0 {
+			return err
+		}
+
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM customers WHERE id = ?)`, customer.ID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return ErrVersionConflict
+		}
+		return ErrCustomerNotFound
+	})
+}
+
+// CustomerStats aggregates the customer's orders per currency in SQL.
+func (r *SQLiteRepository) CustomerStats(ctx context.Context, customerID string) (*domain.CustomerStats, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT currency, COUNT(*), SUM(CASE WHEN status != ? THEN total_minor END), MAX(created_at)
+		 FROM orders WHERE customer_id = ? AND deleted_at IS NULL
+		 GROUP BY currency ORDER BY currency`,
+		string(domain.StatusCancelled), customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &domain.CustomerStats{CustomerID: customerID, LifetimeValue: []domain.Money{}}
+	var lastOrderAt int64
+	for rows.Next() {
+		var currency string
+		var count int
+		var value sql.NullInt64 // NULL when every order in this currency was cancelled
+		var createdAt int64
+		if err := rows.Scan(&currency, &count, &value, &createdAt); err != nil {
+			return nil, err
+		}
+		stats.OrderCount += count
+		if value.Valid {
+			stats.LifetimeValue = append(stats.LifetimeValue, domain.Money{Amount: value.Int64, Currency: currency})
+		}
+		lastOrderAt = max(lastOrderAt, createdAt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if stats.OrderCount > 0 {
+		t := time.Unix(0, lastOrderAt)
+		stats.LastOrderAt = &t
+	}
+	return stats, nil
+}
+
+// CreateProduct inserts a new product row.
+func (r *SQLiteRepository) CreateProduct(ctx context.Context, product *domain.Product) error {
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO products (`+productColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		product.SKU, product.Name, product.Price.Amount, product.Price.Currency, string(product.Status), now.UnixNano(), now.UnixNano(), product.Version)
+	if isPrimaryKeyViolation(err) {
+		return ErrProductAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	product.CreatedAt, product.UpdatedAt = now, now
+	return nil
+}
+
+// GetProduct reads a product row.
+func (r *SQLiteRepository) GetProduct(ctx context.Context, sku string) (*domain.Product, error) {
+	product, err := scanProduct(r.db.QueryRowContext(ctx,
+		`SELECT `+productColumns+` FROM products WHERE sku = ?`, sku))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	return product, err
+}
+
+// ListProducts reads all product rows sorted by SKU.
+func (r *SQLiteRepository) ListProducts(ctx context.Context) ([]*domain.Product, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+productColumns+` FROM products ORDER BY sku`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]*domain.Product, 0)
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
+// UpdateProduct replaces a product row if its version hasn't changed.
+func (r *SQLiteRepository) UpdateProduct(ctx context.Context, product *domain.Product) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE products SET name = ?, price_minor = ?, currency = ?, status = ?, updated_at = ?, version = version + 1
+			 WHERE sku = ? AND version = ?`,
+			product.Name, product.Price.Amount, product.Price.Currency, string(product.Status), time.Now().UnixNano(),
+			product.SKU, product.Version)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n > 0 {
+			return err
+		}
+
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM products WHERE sku = ?)`, product.SKU).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return ErrVersionConflict
+		}
+		return ErrProductNotFound
+	})
+}
+
+// DeleteProduct removes a product row.
+func (r *SQLiteRepository) DeleteProduct(ctx context.Context, sku string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM products WHERE sku = ?`, sku)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// withTx runs fn inside a transaction, committing on success and rolling back on error.
+func (r *SQLiteRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx, so reads can run inside a transaction.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// getOrder reads an order and its line items.
+func getOrder(ctx context.Context, q queryer, id string) (*domain.Order, error) {
+	row := q.QueryRowContext(ctx,
+		`SELECT `+orderColumns+` FROM orders WHERE id = ?`, id)
+
+	order, err := scanOrder(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := loadLineItems(ctx, q, `WHERE order_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	order.Items = items[order.ID]
+	return order, nil
+}
+
+// loadLineItems returns line items grouped by order ID, in their original order.
+// where is an optional SQL filter on the line_items table.
+func loadLineItems(ctx context.Context, q queryer, where string, args ...interface{}) (map[string][]domain.LineItem, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT order_id, product_id, product_name, quantity, unit_price_minor, currency
+		 FROM line_items `+where+` ORDER BY order_id, position`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make(map[string][]domain.LineItem)
+	for rows.Next() {
+		var orderID string
+		var item domain.LineItem
+		if err := rows.Scan(&orderID, &item.ProductID, &item.ProductName, &item.Quantity, &item.UnitPrice.Amount, &item.UnitPrice.Currency); err != nil {
+			return nil, err
+		}
+		items[orderID] = append(items[orderID], item)
+	}
+	return items, rows.Err()
+}
+
+// createOrder inserts a new order, its line items and its created event as part of tx.
+func createOrder(ctx context.Context, tx *sql.Tx, order *domain.Order, now time.Time) error {
+	pricing, err := marshalPricing(order.Pricing)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO orders (`+orderColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULL, ?, ?, ?)`,
+		order.ID, order.CustomerID, string(order.Status), order.TotalAmount.Amount, order.TotalAmount.Currency,
+		now.UnixNano(), now.UnixNano(), order.Version, order.CouponCode, order.Region, pricing)
+	if err != nil {
+		if isPrimaryKeyViolation(err) {
+			return ErrAlreadyExists
+		}
+		return err
+	}
+	if err := insertLineItems(ctx, tx, order.ID, order.Items); err != nil {
+		return err
+	}
+	order.CreatedAt, order.UpdatedAt = now, now
+	return recordEvent(ctx, tx, domain.NewOrderEvent(domain.EventOrderCreated, order, now))
+}
+
+// updateStatus applies a compare-and-swap status change, appends it to the
+// history and records the event, all as part of tx.
+func updateStatus(ctx context.Context, tx *sql.Tx, id string, change domain.StatusChange, expectedVersion int64, now time.Time) error {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE orders SET status = ?, updated_at = ?, version = version + 1
+		 WHERE id = ? AND version = ? AND deleted_at IS NULL`,
+		string(change.ToStatus), now.UnixNano(), id, expectedVersion)
+	if err != nil {
+		return err
+	}
+	if err := requireVersionedRowAffected(ctx, tx, res, id); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO order_history (order_id, from_status, to_status, actor, reason, occurred_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		id, string(change.FromStatus), string(change.ToStatus), change.Actor, change.Reason,
+		change.Timestamp.UnixNano())
+	if err != nil {
+		return err
+	}
+
+	updated, err := getOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	event := domain.NewOrderEvent(domain.EventOrderStatusChanged, updated, now)
+	event.StatusChange = &change
+	return recordEvent(ctx, tx, event)
+}
+
+// insertLineItems writes the items of an order, preserving their position.
+func insertLineItems(ctx context.Context, tx *sql.Tx, orderID string, items []domain.LineItem) error {
+	for i, item := range items {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO line_items (order_id, position, product_id, product_name, quantity, unit_price_minor, currency)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			orderID, i, item.ProductID, item.ProductName, item.Quantity, item.UnitPrice.Amount, item.UnitPrice.Currency)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordEvent appends an event to the outbox as part of tx.
+func recordEvent(ctx context.Context, tx *sql.Tx, event domain.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO outbox (event_type, order_id, payload) VALUES (?, ?, ?)`,
+		string(event.Type), event.OrderID, string(payload))
+	return err
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanOrder reads an order row (without line items).
+func scanOrder(row rowScanner) (*domain.Order, error) {
+	var order domain.Order
+	var status string
+	var createdAt, updatedAt int64
+	var deletedAt sql.NullInt64
+	var pricing sql.NullString
+	if err := row.Scan(&order.ID, &order.CustomerID, &status, &order.TotalAmount.Amount, &order.TotalAmount.Currency, &createdAt, &updatedAt, &order.Version, &deletedAt,
+		&order.CouponCode, &order.Region, &pricing); err != nil {
+		return nil, err
+	}
+	if pricing.Valid {
+		if err := json.Unmarshal([]byte(pricing.String), &order.Pricing); err != nil {
+			return nil, fmt.Errorf("order %s: invalid pricing: %w", order.ID, err)
+		}
+	}
+	order.Status = domain.OrderStatus(status)
+	order.CreatedAt = time.Unix(0, createdAt)
+	order.UpdatedAt = time.Unix(0, updatedAt)
+	if deletedAt.Valid {
+		t := time.Unix(0, deletedAt.Int64)
+		order.DeletedAt = &t
+	}
+	return &order, nil
+}
+
+// marshalPricing encodes a price breakdown for the pricing column; nil stays NULL.
+func marshalPricing(breakdown *domain.PriceBreakdown) (sql.NullString, error) {
+	if breakdown == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(breakdown)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// scanProduct reads a product row.
+func scanProduct(row rowScanner) (*domain.Product, error) {
+	var product domain.Product
+	var price domain.Money
+	var status string
+	var createdAt, updatedAt int64
+	if err := row.Scan(&product.SKU, &product.Name, &price.Amount, &price.Currency, &status, &createdAt, &updatedAt, &product.Version); err != nil {
+		return nil, err
+	}
+	product.SetPrice(price)
+	product.Status = domain.ProductStatus(status)
+	product.CreatedAt = time.Unix(0, createdAt)
+	product.UpdatedAt = time.Unix(0, updatedAt)
+	return &product, nil
+}
+
+// requireVersionedRowAffected handles the result of a compare-and-swap update.
+// When no row changed it tells apart a missing order (ErrNotFound) from a
+// stale version (ErrVersionConflict).
+func requireVersionedRowAffected(ctx context.Context, tx *sql.Tx, res sql.Result, id string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM orders WHERE id = ? AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return ErrNotFound
+}
+
+// isPrimaryKeyViolation reports whether err is a duplicate primary key error.
+func isPrimaryKeyViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+}
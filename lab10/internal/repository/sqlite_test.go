@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"lab10/internal/domain"
+)
+
+func newTestOrder(id string) *domain.Order {
+	return &domain.Order{
+		ID:         id,
+		CustomerID: "CUST-001",
+		Status:     domain.StatusPending,
+		Items: []domain.LineItem{
+			{ProductID: "SKU-1", ProductName: "Widget", Quantity: 2, UnitPrice: domain.MustMoney("9.99", "USD")},
+			{ProductID: "SKU-2", ProductName: "Gadget", Quantity: 1, UnitPrice: domain.MustMoney("24.50", "USD")},
+		},
+		TotalAmount: domain.MustMoney("44.48", "USD"),
+		Version:     1,
+	}
+}
+
+// TestSQLiteRepositoryPersists verifies orders survive closing and reopening the database.
+func TestSQLiteRepositoryPersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "orders.db")
+
+	repo, err := NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() error = %v", err)
+	}
+	order := newTestOrder("ORD-001")
+	order.CouponCode, order.Region = "SAVE10", "DE"
+	order.Pricing = &domain.PriceBreakdown{
+		Subtotal:      order.TotalAmount,
+		Discounts:     []domain.PriceAdjustment{{Rule: "coupons", Description: "coupon SAVE10: 10% off", Amount: domain.MustMoney("4.45", "USD")}},
+		DiscountTotal: domain.MustMoney("4.45", "USD"),
+		Tax:           domain.Zero("USD"),
+		Total:         domain.MustMoney("40.03", "USD"),
+	}
+	if err := repo.Create(ctx, order); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if order.CreatedAt.IsZero() || !order.UpdatedAt.Equal(order.CreatedAt) {
+		t.Errorf("Create() set times %v, %v; want the creation time", order.CreatedAt, order.UpdatedAt)
+	}
+	repo.Close()
+
+	// Reopening must not re-run migrations or lose data
+	repo, err = NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() reopen error = %v", err)
+	}
+	defer repo.Close()
+
+	got, err := repo.Get(ctx, "ORD-001")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(got.Items) != 2 || got.Items[1].ProductID != "SKU-2" {
+		t.Errorf("Get() items = %+v, want both line items in order", got.Items)
+	}
+	if !got.CreatedAt.Equal(order.CreatedAt) {
+		t.Errorf("Get() CreatedAt = %v, want the time Create set, %v", got.CreatedAt, order.CreatedAt)
+	}
+	if got.CouponCode != "SAVE10" || got.Region != "DE" || got.Pricing == nil || len(got.Pricing.Discounts) != 1 || got.Pricing.Total != order.Pricing.Total {
+		t.Errorf("Get() pricing = %q, %q, %+v; want it as stored", got.CouponCode, got.Region, got.Pricing)
+	}
+}
+
+// TestSQLiteRepositoryErrors verifies the ErrNotFound/ErrAlreadyExists/ErrVersionConflict contract.
+// Cases run in order against the same database.
+func TestSQLiteRepositoryErrors(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() error = %v", err)
+	}
+	defer repo.Close()
+
+	if err := repo.Create(ctx, newTestOrder("ORD-001")); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	confirm := domain.StatusChange{FromStatus: domain.StatusPending, ToStatus: domain.StatusConfirmed, Actor: "test"}
+	ship := domain.StatusChange{FromStatus: domain.StatusConfirmed, ToStatus: domain.StatusShipped, Actor: "test"}
+
+	tests := []struct {
+		name    string
+		op      func() error
+		wantErr error
+	}{
+		{"create duplicate", func() error { return repo.Create(ctx, newTestOrder("ORD-001")) }, ErrAlreadyExists},
+		{"get missing", func() error { _, err := repo.Get(ctx, "missing"); return err }, ErrNotFound},
+		{"update missing", func() error { return repo.Update(ctx, newTestOrder("missing")) }, ErrNotFound},
+		{"update status missing", func() error { return repo.UpdateStatus(ctx, "missing", confirm, 1) }, ErrNotFound},
+		{"delete missing", func() error { return repo.Delete(ctx, "missing") }, ErrNotFound},
+		{"update status existing", func() error { return repo.UpdateStatus(ctx, "ORD-001", confirm, 1) }, nil},
+		{"update status stale version", func() error { return repo.UpdateStatus(ctx, "ORD-001", ship, 1) }, ErrVersionConflict},
+		{"update stale version", func() error { return repo.Update(ctx, newTestOrder("ORD-001")) }, ErrVersionConflict},
+		{"history records only applied changes", func() error {
+			history, err := repo.GetHistory(ctx, "ORD-001")
+			if err == nil && (len(history) != 1 || history[0].ToStatus != domain.StatusConfirmed) {
+				return fmt.Errorf("history = %+v, want single confirm", history)
+			}
+			return err
+		}, nil},
+		{"history missing", func() error { _, err := repo.GetHistory(ctx, "missing"); return err }, ErrNotFound},
+		{"delete existing", func() error { return repo.Delete(ctx, "ORD-001") }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+)
+
+// MaxBatchSize caps the number of items in one batch request.
+// Larger imports should be split up or use the streaming RPC.
+const MaxBatchSize = 1000
+
+var (
+	// ErrInvalidBatch indicates a batch that is empty or too large.
+	ErrInvalidBatch = errors.New("invalid batch")
+
+	// ErrBatchAborted is reported for items of an all-or-nothing batch that
+	// were fine on their own but not applied because another item failed.
+	ErrBatchAborted = errors.New("not applied: another item in the all-or-nothing batch failed")
+)
+
+// BatchResult is the outcome of one batch item, in request order.
+// Exactly one of Order and Err is set.
+type BatchResult struct {
+	Order *domain.Order
+	Err   error
+}
+
+// StatusUpdateItem is one item of a BatchUpdateStatus call.
+type StatusUpdateItem struct {
+	ID string
+	StatusUpdate
+}
+
+// BatchCreateOrders creates each order like CreateOrder and reports the
+// outcome per order. With atomic set, either all orders are created or none
+// are: if any order fails, the others report ErrBatchAborted.
+//
+// The returned error is only set for problems with the batch as a whole.
+func (s *OrderService) BatchCreateOrders(ctx context.Context, orders []*domain.Order, atomic bool) ([]BatchResult, error) {
+	if err := checkBatchSize(len(orders)); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(orders))
+	if !atomic {
+		for i, order := range orders {
+			if err := s.CreateOrder(ctx, order); err != nil {
+				results[i].Err = err
+				continue
+			}
+			results[i].Order = order
+		}
+		return results, nil
+	}
+
+	failed := false
+	for i, order := range orders {
+		if err := s.prepareNewOrder(ctx, order); err != nil {
+			results[i].Err = err
+			failed = true
+		}
+	}
+	if !failed {
+		err := s.repo.CreateBatch(ctx, orders)
+		if failed, err = recordBatchError(results, err); err != nil {
+			return nil, err
+		}
+	}
+	if failed {
+		return abortBatch(results), nil
+	}
+	for i, order := range orders {
+		results[i].Order = order
+	}
+	return results, nil
+}
+
+// BatchUpdateStatus applies each status update like UpdateOrderStatus and
+// reports the outcome per item. With atomic set, either all updates are
+// applied or none are: if any update fails, the others report ErrBatchAborted.
+// Several updates to the same order are applied in request order. Stock is
+// reserved and released as in UpdateOrderStatus; in atomic mode the
+// reservations of a failed batch are released again.
+//
+// The returned error is only set for problems with the batch as a whole.
+func (s *OrderService) BatchUpdateStatus(ctx context.Context, items []StatusUpdateItem, atomic bool) ([]BatchResult, error) {
+	if err := checkBatchSize(len(items)); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(items))
+	if !atomic {
+		for i, item := range items {
+			results[i].Order, results[i].Err = s.UpdateOrderStatus(ctx, item.ID, item.StatusUpdate)
+		}
+		return results, nil
+	}
+
+	// Check every transition against the state the order will be in by the
+	// time the item is applied, taking earlier items in the batch into account
+	writes := make([]repository.StatusWrite, len(items))
+	orders := make([]*domain.Order, len(items))
+	pending := make(map[string]*domain.Order)
+	failed := false
+	for i, item := range items {
+		order, ok := pending[item.ID]
+		if !ok {
+			current, err := s.repo.Get(ctx, item.ID)
+			if err != nil {
+				results[i].Err = err
+				failed = true
+				continue
+			}
+			order = current
+		}
+		change, err := s.prepareStatusChange(ctx, order, item.StatusUpdate)
+		if err != nil {
+			results[i].Err = err
+			failed = true
+			continue
+		}
+		writes[i] = repository.StatusWrite{ID: item.ID, Change: change, ExpectedVersion: order.Version}
+		orders[i] = order
+
+		next := *order
+		next.Status = change.ToStatus
+		next.Version++
+		pending[item.ID] = &next
+	}
+	var reserved []string
+	if !failed {
+		for i, w := range writes {
+			ok, err := s.reserveStock(ctx, orders[i], w.Change)
+			if err != nil {
+				results[i].Err = err
+				failed = true
+				break
+			}
+			if ok {
+				reserved = append(reserved, w.ID)
+			}
+		}
+	}
+	if !failed {
+		err := s.repo.UpdateStatusBatch(ctx, writes)
+		if failed, err = recordBatchError(results, err); err != nil {
+			s.releaseAll(ctx, reserved)
+			return nil, err
+		}
+	}
+	if failed {
+		s.releaseAll(ctx, reserved)
+		return abortBatch(results), nil
+	}
+	for _, w := range writes {
+		if w.Change.ToStatus == domain.StatusCancelled {
+			s.releaseStock(ctx, w.ID)
+		}
+	}
+
+	for i, item := range items {
+		order, err := s.repo.Get(ctx, item.ID)
+		if err != nil {
+			return nil, err
+		}
+		results[i].Order = order
+	}
+	return results, nil
+}
+
+// releaseAll undoes the reservations made for every listed order (see
+// unreserveStock).
+func (s *OrderService) releaseAll(ctx context.Context, orderIDs []string) {
+	for _, id := range orderIDs {
+		s.unreserveStock(ctx, id)
+	}
+}
+
+// checkBatchSize rejects empty and oversized batches.
+func checkBatchSize(n int) error {
+	if n == 0 {
+		return fmt.Errorf("%w: no items given", ErrInvalidBatch)
+	}
+	if n > MaxBatchSize {
+		return fmt.Errorf("%w: %d items exceeds the limit of %d", ErrInvalidBatch, n, MaxBatchSize)
+	}
+	return nil
+}
+
+// recordBatchError stores a repository *BatchError against its item and
+// reports whether the batch failed. Other errors are returned as is.
+func recordBatchError(results []BatchResult, err error) (bool, error) {
+	if err == nil {
+		return false, nil
+	}
+	var batchErr *repository.BatchError
+	if !errors.As(err, &batchErr) {
+		return true, err
+	}
+	results[batchErr.Index].Err = batchErr.Err
+	return true, nil
+}
+
+// abortBatch marks every item without an error of its own as aborted.
+func abortBatch(results []BatchResult) []BatchResult {
+	for i := range results {
+		results[i].Order = nil
+		if results[i].Err == nil {
+			results[i].Err = ErrBatchAborted
+		}
+	}
+	return results
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+)
+
+// exportPageSize is how many orders ExportOrders reads from the repository at a time.
+const exportPageSize = MaxPageSize
+
+// ExportOrders calls fn for every order matching filter, oldest first.
+// Orders are read a page at a time, so memory use doesn't grow with the
+// number of orders. Orders created while the export runs may be missed.
+// An error from fn stops the export and is returned.
+func (s *OrderService) ExportOrders(ctx context.Context, filter repository.ListFilter, fn func(*domain.Order) error) error {
+	opts := repository.ListOptions{
+		Filter:   filter,
+		SortBy:   repository.SortByCreatedAt,
+		PageSize: exportPageSize,
+	}
+	for {
+		page, err := s.ListOrders(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, order := range page.Orders {
+			if err := fn(order); err != nil {
+				return err
+			}
+		}
+		if page.NextPageToken == "" {
+			return nil
+		}
+		opts.PageToken = page.NextPageToken
+	}
+}
+
+// ImportOrder creates one order of a bulk import. It is checked exactly
+// like CreateOrder, including Order.Validate; with dryRun set it is only
+// checked, so a nil error means the order would have been created.
+func (s *OrderService) ImportOrder(ctx context.Context, order *domain.Order, dryRun bool) error {
+	if err := s.prepareNewOrder(ctx, order); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+	return s.repo.Create(ctx, order)
+}
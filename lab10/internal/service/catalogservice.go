@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+)
+
+// ErrInvalidProduct indicates product validation failed.
+var ErrInvalidProduct = errors.New("invalid product")
+
+// ProductUpdate describes a change to a product. Nil and empty fields are left unchanged.
+type ProductUpdate struct {
+	Name   string
+	Price  *domain.Money
+	Status domain.ProductStatus
+
+	// ExpectedVersion is the product version the caller last saw. Zero skips the check.
+	ExpectedVersion int64
+}
+
+// CatalogService contains business logic for the product catalog.
+type CatalogService struct {
+	products repository.ProductRepository
+}
+
+// NewCatalogService creates a new catalog service with the given repository.
+func NewCatalogService(products repository.ProductRepository) *CatalogService {
+	return &CatalogService{products: products}
+}
+
+// CreateProduct validates and creates a new product. New products are active
+// unless a status is given.
+func (s *CatalogService) CreateProduct(ctx context.Context, product *domain.Product) error {
+	if product.Status == "" {
+		product.Status = domain.ProductActive
+	}
+	if err := product.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProduct, err)
+	}
+	product.SetPrice(product.Price)
+	product.Version = 1
+	return s.products.CreateProduct(ctx, product)
+}
+
+// GetProduct retrieves a product by SKU.
+func (s *CatalogService) GetProduct(ctx context.Context, sku string) (*domain.Product, error) {
+	return s.products.GetProduct(ctx, sku)
+}
+
+// ListProducts returns the whole catalog sorted by SKU.
+func (s *CatalogService) ListProducts(ctx context.Context) ([]*domain.Product, error) {
+	return s.products.ListProducts(ctx)
+}
+
+// UpdateProduct applies update to a product and returns the updated product.
+// Price changes only affect orders created afterwards.
+func (s *CatalogService) UpdateProduct(ctx context.Context, sku string, update ProductUpdate) (*domain.Product, error) {
+	product, err := s.products.GetProduct(ctx, sku)
+	if err != nil {
+		return nil, err
+	}
+	if update.ExpectedVersion != 0 && product.Version != update.ExpectedVersion {
+		return nil, fmt.Errorf("%w: expected version %d, current version is %d",
+			repository.ErrVersionConflict, update.ExpectedVersion, product.Version)
+	}
+
+	if update.Name != "" {
+		product.Name = update.Name
+	}
+	if update.Price != nil {
+		product.SetPrice(*update.Price)
+	}
+	if update.Status != "" {
+		product.Status = update.Status
+	}
+	if err := product.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProduct, err)
+	}
+
+	if err := s.products.UpdateProduct(ctx, product); err != nil {
+		return nil, err
+	}
+	return s.products.GetProduct(ctx, sku)
+}
+
+// DeleteProduct removes a product from the catalog. Existing orders keep
+// their copy of its name and price; prefer discontinuing a product to keep
+// its SKU from being reused.
+func (s *CatalogService) DeleteProduct(ctx context.Context, sku string) error {
+	return s.products.DeleteProduct(ctx, sku)
+}
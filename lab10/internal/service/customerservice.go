@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"lab10/internal/domain"
+	"lab10/internal/idgen"
+	"lab10/internal/repository"
+)
+
+// ErrInvalidCustomer indicates customer validation failed.
+var ErrInvalidCustomer = errors.New("invalid customer")
+
+// CustomerUpdate describes a change to a customer. Empty fields are left unchanged.
+type CustomerUpdate struct {
+	Name   string
+	Email  string
+	Status domain.CustomerStatus
+
+	// ExpectedVersion is the customer version the caller last saw. Zero skips the check.
+	ExpectedVersion int64
+}
+
+// CustomerService contains business logic for customers and their orders.
+// Order queries go through the OrderService so they get the same defaults
+// and validation as ListOrders.
+type CustomerService struct {
+	customers repository.CustomerRepository
+	orders    *OrderService
+	ids       IDGenerator
+}
+
+// CustomerOption configures optional CustomerService settings.
+type CustomerOption func(*CustomerService)
+
+// WithCustomerIDGenerator sets how IDs for new customers are generated. Defaults to UUIDv7.
+func WithCustomerIDGenerator(ids IDGenerator) CustomerOption {
+	return func(s *CustomerService) {
+		s.ids = ids
+	}
+}
+
+// NewCustomerService creates a customer service. orders serves the
+// per-customer order listing.
+func NewCustomerService(customers repository.CustomerRepository, orders *OrderService, opts ...CustomerOption) *CustomerService {
+	s := &CustomerService{
+		customers: customers,
+		orders:    orders,
+		ids:       idgen.NewUUIDv7(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CreateCustomer validates and creates a new customer.
+// Unlike orders, customers may bring their own ID (e.g. from a CRM); one is
+// generated if it's empty. New customers are active unless a status is given.
+func (s *CustomerService) CreateCustomer(ctx context.Context, customer *domain.Customer) error {
+	if customer.ID == "" {
+		customer.ID = s.ids.NewID()
+	}
+	if customer.Status == "" {
+		customer.Status = domain.CustomerActive
+	}
+	if err := customer.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCustomer, err)
+	}
+	customer.Version = 1
+	return s.customers.CreateCustomer(ctx, customer)
+}
+
+// GetCustomer retrieves a customer by ID.
+func (s *CustomerService) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	return s.customers.GetCustomer(ctx, id)
+}
+
+// UpdateCustomer applies update to a customer and returns the updated customer.
+// Deactivating a customer stops new orders but leaves existing ones alone.
+func (s *CustomerService) UpdateCustomer(ctx context.Context, id string, update CustomerUpdate) (*domain.Customer, error) {
+	customer, err := s.customers.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if update.ExpectedVersion != 0 && customer.Version != update.ExpectedVersion {
+		return nil, fmt.Errorf("%w: expected version %d, current version is %d",
+			repository.ErrVersionConflict, update.ExpectedVersion, customer.Version)
+	}
+
+	if update.Name != "" {
+		customer.Name = update.Name
+	}
+	if update.Email != "" {
+		customer.Email = update.Email
+	}
+	if update.Status != "" {
+		customer.Status = update.Status
+	}
+	if err := customer.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCustomer, err)
+	}
+
+	if err := s.customers.UpdateCustomer(ctx, customer); err != nil {
+		return nil, err
+	}
+	return s.customers.GetCustomer(ctx, id)
+}
+
+// ListCustomerOrders returns one page of a customer's orders. Any customer
+// filter in opts is replaced by id. Returns ErrCustomerNotFound for unknown customers.
+func (s *CustomerService) ListCustomerOrders(ctx context.Context, id string, opts repository.ListOptions) (*repository.ListResult, error) {
+	if _, err := s.customers.GetCustomer(ctx, id); err != nil {
+		return nil, err
+	}
+	opts.Filter.CustomerID = id
+	return s.orders.ListOrders(ctx, opts)
+}
+
+// GetCustomerStats summarizes a customer's orders: how many there are, their
+// lifetime value and when the last one was placed.
+func (s *CustomerService) GetCustomerStats(ctx context.Context, id string) (*domain.CustomerStats, error) {
+	if _, err := s.customers.GetCustomer(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.customers.CustomerStats(ctx, id)
+}
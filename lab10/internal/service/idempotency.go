@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"lab10/internal/auth"
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+)
+
+const (
+	// DefaultIdempotencyTTL is how long a CreateOrder result is kept for replay
+	// when no TTL is configured.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	// MaxIdempotencyKeyLength caps the length of client-supplied keys.
+	MaxIdempotencyKeyLength = 255
+)
+
+var (
+	// ErrInvalidIdempotencyKey indicates an idempotency key that is too long.
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+
+	// ErrIdempotencyKeyReused indicates a key was reused with a different request payload.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+	// ErrIdempotencyKeyInProgress indicates the first request with this key hasn't finished yet.
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// WithIdempotency sets where CreateOrder results are kept for replay and for how long.
+func WithIdempotency(store repository.IdempotencyStore, ttl time.Duration) Option {
+	return func(s *OrderService) {
+		s.idempotency = store
+		s.idempotencyTTL = ttl
+	}
+}
+
+// CreateOrderIdempotent creates an order like CreateOrder, but remembers the
+// result under key. A retry with the same key and the same order fields gets
+// the first result copied into order and replayed set to true, without
+// creating anything. Reusing a key with different fields fails with
+// ErrIdempotencyKeyReused. An empty key behaves exactly like CreateOrder.
+//
+// Keys are scoped to the caller: with a principal in ctx (see
+// auth.FromContext), another caller's use of the same key is a different
+// request. Failed requests are not remembered, so they can be retried with
+// the same key.
+func (s *OrderService) CreateOrderIdempotent(ctx context.Context, key string, order *domain.Order) (replayed bool, err error) {
+	if key == "" {
+		return false, s.CreateOrder(ctx, order)
+	}
+	if len(key) > MaxIdempotencyKeyLength {
+		return false, fmt.Errorf("%w: longer than %d characters", ErrInvalidIdempotencyKey, MaxIdempotencyKeyLength)
+	}
+
+	fingerprint, err := orderFingerprint(order)
+	if err != nil {
+		return false, err
+	}
+
+	key = scopedIdempotencyKey(ctx, key)
+	existing, err := s.idempotency.Reserve(ctx, key, fingerprint, time.Now().Add(s.idempotencyTTL))
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		if existing.Fingerprint != fingerprint {
+			return false, ErrIdempotencyKeyReused
+		}
+		if existing.Order == nil {
+			return false, ErrIdempotencyKeyInProgress
+		}
+		*order = *existing.Order
+		return true, nil
+	}
+
+	// Finish bookkeeping even if the client has gone away meanwhile
+	storeCtx := context.WithoutCancel(ctx)
+	if err := s.CreateOrder(ctx, order); err != nil {
+		if releaseErr := s.idempotency.Release(storeCtx, key); releaseErr != nil {
+			return false, errors.Join(err, releaseErr)
+		}
+		return false, err
+	}
+	if err := s.idempotency.Complete(storeCtx, key, order); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// scopedIdempotencyKey returns the key under which a client's key is stored:
+// prefixed with the authentication method and subject of the caller, if
+// known, so callers can't replay each other's orders.
+func scopedIdempotencyKey(ctx context.Context, key string) string {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return key
+	}
+	return string(principal.Method) + ":" + principal.Subject + "\x00" + key
+}
+
+// orderFingerprint hashes the client-supplied fields of a new order.
+func orderFingerprint(order *domain.Order) (string, error) {
+	data, err := json.Marshal(struct {
+		ID         string            `json:"id"`
+		CustomerID string            `json:"customer_id"`
+		Items      []domain.LineItem `json:"items"`
+		CouponCode string            `json:"coupon_code"`
+		Region     string            `json:"region"`
+	}{order.ID, order.CustomerID, order.Items, order.CouponCode, order.Region})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
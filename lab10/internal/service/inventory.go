@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"lab10/internal/domain"
+	"lab10/internal/inventory"
+	"lab10/internal/repository"
+)
+
+// ErrInsufficientStock indicates an order can't be confirmed because a
+// product doesn't have enough unreserved stock.
+var ErrInsufficientStock = inventory.ErrInsufficientStock
+
+// InventoryService reserves stock for confirmed orders, such as
+// inventory.Memory or inventory.External.
+type InventoryService interface {
+	// Reserve holds stock for every item of the order, or for none of them
+	// and returns an error wrapping ErrInsufficientStock. It reports whether
+	// this call made the reservation: reserving again for an order that
+	// already holds stock changes nothing and reports false.
+	Reserve(ctx context.Context, orderID string, items []domain.LineItem) (bool, error)
+
+	// Release returns the stock held by the order. Releasing an order that
+	// holds nothing is a no-op.
+	Release(ctx context.Context, orderID string) error
+
+	// Hold records that the order holds stock for its items, without
+	// checking stock levels, as RestoreReservations does at startup.
+	// Holding for an order that already holds stock changes nothing.
+	Hold(ctx context.Context, orderID string, items []domain.LineItem) error
+}
+
+// WithInventory makes confirming an order reserve stock for its items and
+// cancelling it release that stock again. Without it stock isn't checked.
+func WithInventory(inventory InventoryService) Option {
+	return func(s *OrderService) {
+		s.inventory = inventory
+	}
+}
+
+// reserveStock reserves the order's items if change confirms it, and
+// reports whether this call made the reservation. Only then may the caller
+// release it if the change fails: a concurrent confirm of the same order
+// may hold it otherwise.
+func (s *OrderService) reserveStock(ctx context.Context, order *domain.Order, change domain.StatusChange) (bool, error) {
+	if s.inventory == nil || change.ToStatus != domain.StatusConfirmed {
+		return false, nil
+	}
+	return s.inventory.Reserve(ctx, order.ID, order.Items)
+}
+
+// unreserveStock undoes a reservation made by reserveStock for a confirm
+// that then failed. A concurrent confirm of the same order may have won in
+// the meantime, having found the stock already held; the order keeps it then.
+func (s *OrderService) unreserveStock(ctx context.Context, orderID string) {
+	order, err := s.repo.Get(context.WithoutCancel(ctx), orderID)
+	if err == nil && order.Status == domain.StatusConfirmed {
+		return
+	}
+	s.releaseStock(ctx, orderID)
+}
+
+// releaseStock returns an order's reserved stock. It runs after the status
+// change is decided, so it ignores cancellation of ctx and only logs failures.
+func (s *OrderService) releaseStock(ctx context.Context, orderID string) {
+	if s.inventory == nil {
+		return
+	}
+	if err := s.inventory.Release(context.WithoutCancel(ctx), orderID); err != nil {
+		log.Printf("Inventory release for order %s: %v", orderID, err)
+	}
+}
+
+// RestoreReservations rebuilds the inventory's reservations from the stored
+// orders, since inventories keep them in memory only: every order that was
+// confirmed and hasn't been cancelled or deleted since holds stock for its
+// items again, even if stock levels have dropped meanwhile. Call it at
+// startup, before serving requests. It returns the number of orders that
+// hold stock.
+func (s *OrderService) RestoreReservations(ctx context.Context) (int, error) {
+	if s.inventory == nil {
+		return 0, nil
+	}
+	opts := repository.ListOptions{SortBy: repository.SortByCreatedAt, PageSize: MaxPageSize}
+	held := 0
+	for {
+		page, err := s.repo.List(ctx, opts)
+		if err != nil {
+			return held, err
+		}
+		for _, order := range page.Orders {
+			holds, err := s.holdsStock(ctx, order)
+			if err != nil {
+				return held, err
+			}
+			if !holds {
+				continue
+			}
+			if err := s.inventory.Hold(ctx, order.ID, order.Items); err != nil {
+				return held, err
+			}
+			held++
+		}
+		if page.NextPageToken == "" {
+			return held, nil
+		}
+		opts.PageToken = page.NextPageToken
+	}
+}
+
+// holdsStock reports whether an order that isn't deleted holds reserved
+// stock: whether it was confirmed and hasn't been cancelled since.
+func (s *OrderService) holdsStock(ctx context.Context, order *domain.Order) (bool, error) {
+	switch order.Status {
+	case domain.StatusConfirmed:
+		return true, nil
+	case domain.StatusCancelled:
+		return false, nil
+	}
+	history, err := s.repo.GetHistory(ctx, order.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, change := range history {
+		if change.ToStatus == domain.StatusConfirmed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
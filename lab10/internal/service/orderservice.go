@@ -4,8 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"lab10/internal/auth"
 	"lab10/internal/domain"
+	"lab10/internal/idgen"
+	"lab10/internal/pricing"
 	"lab10/internal/repository"
 )
 
@@ -15,40 +19,249 @@ var (
 
 	// ErrInvalidStatusTransition indicates status change not allowed.
 	ErrInvalidStatusTransition = errors.New("invalid status transition")
+
+	// ErrInvalidListOptions indicates bad paging, filter or sort parameters.
+	ErrInvalidListOptions = errors.New("invalid list options")
+
+	// ErrOrderNotEditable indicates an attempt to change items of an order that is no longer pending.
+	ErrOrderNotEditable = errors.New("order is not editable")
+
+	// ErrPermissionDenied indicates the caller may not do what it asked for.
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+const (
+	// DefaultPageSize is used when ListOrders is called without a page size.
+	DefaultPageSize = 50
+
+	// MaxPageSize caps the page size so a single request can't load every order.
+	MaxPageSize = 1000
 )
 
+// StatusUpdate describes a requested status change.
+type StatusUpdate struct {
+	Status domain.OrderStatus
+
+	// ExpectedVersion is the order version the caller last saw. Zero skips the check.
+	ExpectedVersion int64
+
+	// Actor and Reason are recorded in the order's history. For an
+	// authenticated caller the actor is the caller's subject; Actor may be
+	// left empty or must match it.
+	Actor  string
+	Reason string
+}
+
+// OrderUpdate describes a partial update of a pending order.
+// Nil fields are left unchanged; a non-nil empty CouponCode or Region clears it.
+type OrderUpdate struct {
+	CustomerID *string
+	Items      *[]domain.LineItem
+	CouponCode *string
+	Region     *string
+
+	// ExpectedVersion is the order version the caller last saw. Zero skips the check.
+	ExpectedVersion int64
+}
+
 // OrderService contains business logic for order operations.
 // Depends on repository interface (not concrete implementation) for flexibility.
 // This is dependency injection - repository is injected via constructor.
 type OrderService struct {
-	repo repository.OrderRepository
+	repo           repository.OrderRepository
+	machine        *domain.StateMachine
+	pricing        *pricing.Pipeline
+	subscriber     EventSubscriber
+	idempotency    repository.IdempotencyStore
+	idempotencyTTL time.Duration
+	ids            IDGenerator
+	allowClientIDs bool
+	customers      repository.CustomerRepository
+	catalog        repository.ProductRepository
+	inventory      InventoryService
+}
+
+// IDGenerator creates IDs for new orders, such as idgen.UUIDv7 or idgen.ULID.
+// NewID must be safe for concurrent use and never return the same ID twice.
+type IDGenerator interface {
+	NewID() string
+}
+
+// Option configures optional OrderService dependencies.
+type Option func(*OrderService)
+
+// WithStateMachine replaces the default order lifecycle with a custom state machine.
+func WithStateMachine(machine *domain.StateMachine) Option {
+	return func(s *OrderService) {
+		s.machine = machine
+	}
+}
+
+// WithPricing sets the discount and tax rules orders are priced with.
+// Without it an order's total is the sum of its line items.
+func WithPricing(pipeline *pricing.Pipeline) Option {
+	return func(s *OrderService) {
+		s.pricing = pipeline
+	}
+}
+
+// WithEventSubscriber sets where WatchOrders receives order events from.
+// Without one, WatchOrders returns ErrWatchUnavailable.
+func WithEventSubscriber(subscriber EventSubscriber) Option {
+	return func(s *OrderService) {
+		s.subscriber = subscriber
+	}
+}
+
+// WithIDGenerator sets how IDs for new orders are generated. Defaults to UUIDv7.
+func WithIDGenerator(ids IDGenerator) Option {
+	return func(s *OrderService) {
+		s.ids = ids
+	}
+}
+
+// WithClientOrderIDs controls whether CreateOrder accepts an ID chosen by the
+// client. When false (the default) the ID is always generated and an order
+// that already has one is rejected.
+func WithClientOrderIDs(allowed bool) Option {
+	return func(s *OrderService) {
+		s.allowClientIDs = allowed
+	}
+}
+
+// WithCustomers makes CreateOrder check that the order's customer exists and
+// is active. Without it any customer ID is accepted.
+func WithCustomers(customers repository.CustomerRepository) Option {
+	return func(s *OrderService) {
+		s.customers = customers
+	}
+}
+
+// WithCatalog makes new line items take their name and price from the product
+// catalog instead of the caller, rejecting unknown and discontinued products.
+// Without it the caller's name and price are used as given.
+func WithCatalog(catalog repository.ProductRepository) Option {
+	return func(s *OrderService) {
+		s.catalog = catalog
+	}
 }
 
 // NewOrderService creates a new order service with the given repository.
-func NewOrderService(repo repository.OrderRepository) *OrderService {
-	return &OrderService{
-		repo: repo,
+func NewOrderService(repo repository.OrderRepository, opts ...Option) *OrderService {
+	s := &OrderService{
+		repo:           repo,
+		machine:        domain.DefaultStateMachine(),
+		pricing:        pricing.NewPipeline(nil, nil),
+		idempotency:    repository.NewMemoryIdempotencyStore(),
+		idempotencyTTL: DefaultIdempotencyTTL,
+		ids:            idgen.NewUUIDv7(),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // CreateOrder validates and creates a new order.
-// Business logic: validates order, calculates total, sets initial status.
+// Business logic: assigns an ID, validates order, calculates total, sets initial status.
 func (s *OrderService) CreateOrder(ctx context.Context, order *domain.Order) error {
+	if err := s.prepareNewOrder(ctx, order); err != nil {
+		return err
+	}
+
+	// Persist to repository
+	return s.repo.Create(ctx, order)
+}
+
+// prepareNewOrder readies an order for its first write: assigns the ID,
+// prices its items from the catalog, validates it and its customer,
+// runs the pricing rules and sets the initial status and version.
+func (s *OrderService) prepareNewOrder(ctx context.Context, order *domain.Order) error {
+	// Generate the ID unless the client may choose its own and did
+	if order.ID == "" {
+		order.ID = s.ids.NewID()
+	} else if !s.allowClientIDs {
+		return fmt.Errorf("%w: %w", ErrInvalidOrder, domain.NewFieldError("id", "order ID is assigned by the server and must not be set"))
+	}
+
+	// Snapshot catalog names and prices; later catalog changes don't affect the order
+	for i := range order.Items {
+		if err := s.resolveItem(ctx, fmt.Sprintf("items[%d]", i), &order.Items[i]); err != nil {
+			return err
+		}
+	}
+
 	// Validate order meets business rules
 	if err := order.Validate(); err != nil {
-		return fmt.Errorf("%w: %v", ErrInvalidOrder, err)
+		return fmt.Errorf("%w: %w", ErrInvalidOrder, err)
+	}
+	if err := s.checkCustomer(ctx, order.CustomerID); err != nil {
+		return err
 	}
 
-	// Calculate total from line items
-	order.CalculateTotal()
+	// Apply discounts and tax to the item subtotal
+	if err := s.priceOrder(order); err != nil {
+		return err
+	}
 
 	// Set initial status if not set
 	if order.Status == "" {
-		order.Status = domain.StatusPending
+		order.Status = s.machine.InitialStatus()
 	}
 
-	// Persist to repository
-	return s.repo.Create(ctx, order)
+	// New orders start at version 1; the repository bumps it on every update
+	order.Version = 1
+	return nil
+}
+
+// checkCustomer verifies the customer may place orders, if customers are configured.
+func (s *OrderService) checkCustomer(ctx context.Context, customerID string) error {
+	if s.customers == nil {
+		return nil
+	}
+	customer, err := s.customers.GetCustomer(ctx, customerID)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return fmt.Errorf("%w: %w", ErrInvalidOrder, domain.NewFieldError("customer_id", fmt.Sprintf("customer %q does not exist", customerID)))
+	}
+	if err != nil {
+		return err
+	}
+	if !customer.IsActive() {
+		return fmt.Errorf("%w: %w", ErrInvalidOrder, domain.NewFieldError("customer_id", fmt.Sprintf("customer %q is not active", customerID)))
+	}
+	return nil
+}
+
+// priceOrder runs the pricing rules and stores the breakdown and total on the order.
+func (s *OrderService) priceOrder(order *domain.Order) error {
+	if err := s.pricing.Apply(order); err != nil {
+		if errors.Is(err, pricing.ErrUnknownCoupon) {
+			return fmt.Errorf("%w: %w", ErrInvalidOrder, domain.NewFieldError("coupon_code", err.Error()))
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidOrder, err)
+	}
+	return nil
+}
+
+// resolveItem fills in the catalog name and price of a line item, if a catalog
+// is configured. field is the item's path for validation errors.
+func (s *OrderService) resolveItem(ctx context.Context, field string, item *domain.LineItem) error {
+	if s.catalog == nil {
+		return nil
+	}
+	product, err := s.catalog.GetProduct(ctx, item.ProductID)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		return fmt.Errorf("%w: %w", ErrInvalidOrder, domain.NewFieldError(field+".product_id", fmt.Sprintf("unknown product %q", item.ProductID)))
+	}
+	if err != nil {
+		return err
+	}
+	if !product.IsOrderable() {
+		return fmt.Errorf("%w: %w", ErrInvalidOrder, domain.NewFieldError(field+".product_id", fmt.Sprintf("product %q is discontinued", item.ProductID)))
+	}
+	item.ProductName = product.Name
+	item.UnitPrice = product.Price
+	return nil
 }
 
 // GetOrder retrieves an order by ID.
@@ -56,43 +269,322 @@ func (s *OrderService) GetOrder(ctx context.Context, id string) (*domain.Order,
 	return s.repo.Get(ctx, id)
 }
 
-// ListOrders returns all orders.
-func (s *OrderService) ListOrders(ctx context.Context) ([]*domain.Order, error) {
-	return s.repo.GetAll(ctx)
+// GetOrderIncludingDeleted retrieves an order by ID, even if it is soft-deleted.
+// Only admins may read deleted orders (see requireAdmin).
+func (s *OrderService) GetOrderIncludingDeleted(ctx context.Context, id string) (*domain.Order, error) {
+	if err := requireAdmin(ctx, "reading deleted orders"); err != nil {
+		return nil, err
+	}
+	return s.repo.GetIncludingDeleted(ctx, id)
 }
 
-// UpdateOrderStatus changes order status with validation.
-// Business logic: checks if state transition is valid before updating.
-func (s *OrderService) UpdateOrderStatus(ctx context.Context, id string, newStatus domain.OrderStatus) error {
+// requireAdmin fails with ErrPermissionDenied unless the caller in ctx is an
+// admin (see auth.Principal.IsAdmin). Without a principal authentication is
+// disabled, and everything is allowed.
+func requireAdmin(ctx context.Context, action string) error {
+	if principal, ok := auth.FromContext(ctx); ok && !principal.IsAdmin() {
+		return fmt.Errorf("%w: %s requires the %s role", ErrPermissionDenied, action, auth.AdminRole)
+	}
+	return nil
+}
+
+// ListOrders returns one page of orders matching the filter in opts.
+// Business logic: applies the default page size, caps it at MaxPageSize and
+// validates the sort and filter options before hitting the repository.
+// Only admins may list deleted orders.
+func (s *OrderService) ListOrders(ctx context.Context, opts repository.ListOptions) (*repository.ListResult, error) {
+	if opts.Filter.IncludeDeleted {
+		if err := requireAdmin(ctx, "listing deleted orders"); err != nil {
+			return nil, err
+		}
+	}
+	if opts.PageSize < 0 {
+		return nil, fmt.Errorf("%w: page size cannot be negative", ErrInvalidListOptions)
+	}
+	if opts.PageSize == 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	if opts.PageSize > MaxPageSize {
+		opts.PageSize = MaxPageSize
+	}
+
+	if opts.SortBy == "" {
+		opts.SortBy = repository.SortByCreatedAt
+	}
+	if !opts.SortBy.IsValid() {
+		return nil, fmt.Errorf("%w: unsupported sort field %q", ErrInvalidListOptions, opts.SortBy)
+	}
+
+	f := opts.Filter
+	if !f.CreatedAfter.IsZero() && !f.CreatedBefore.IsZero() && !f.CreatedAfter.Before(f.CreatedBefore) {
+		return nil, fmt.Errorf("%w: created_after must be before created_before", ErrInvalidListOptions)
+	}
+	if f.MinTotal != nil && f.MaxTotal != nil {
+		cmp, err := f.MinTotal.Compare(*f.MaxTotal)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidListOptions, err)
+		}
+		if cmp > 0 {
+			return nil, fmt.Errorf("%w: min_total cannot exceed max_total", ErrInvalidListOptions)
+		}
+	}
+
+	result, err := s.repo.List(ctx, opts)
+	if errors.Is(err, repository.ErrInvalidPageToken) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidListOptions, err)
+	}
+	return result, err
+}
+
+// UpdateOrderStatus changes order status with validation and returns the updated order.
+// Business logic: checks if state transition is valid before updating, and
+// records who made the change (and why) in the order's history.
+// The update itself is always a compare-and-swap against the version read here,
+// so two concurrent transitions can't both succeed against the same state.
+// With an inventory configured, confirming reserves stock first (failing with
+// ErrInsufficientStock) and cancelling releases it afterwards.
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, id string, update StatusUpdate) (*domain.Order, error) {
 	// Get current order
 	order, err := s.repo.Get(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	change, err := s.prepareStatusChange(ctx, order, update)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved, err := s.reserveStock(ctx, order, change)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update status in repository, only if nobody changed the order since we read it
+	if err := s.repo.UpdateStatus(ctx, id, change, order.Version); err != nil {
+		// Compensate: the order didn't move, so give back what this call reserved
+		if reserved {
+			s.unreserveStock(ctx, id)
+		}
+		return nil, err
+	}
+	if change.ToStatus == domain.StatusCancelled {
+		s.releaseStock(ctx, id)
+	}
+	return s.repo.Get(ctx, id)
+}
+
+// prepareStatusChange checks update against the order's current state and
+// builds the history entry for it. The actor recorded is the authenticated
+// caller in ctx; update.Actor may only repeat it. Without a principal
+// (authentication disabled) update.Actor is taken as given.
+func (s *OrderService) prepareStatusChange(ctx context.Context, order *domain.Order, update StatusUpdate) (domain.StatusChange, error) {
+	if update.ExpectedVersion != 0 && order.Version != update.ExpectedVersion {
+		return domain.StatusChange{}, fmt.Errorf("%w: expected version %d, current version is %d",
+			repository.ErrVersionConflict, update.ExpectedVersion, order.Version)
+	}
+
+	// Validate status transition against the configured state machine
+	if err := s.machine.CanTransition(order, update.Status); err != nil {
+		return domain.StatusChange{}, fmt.Errorf("%w: cannot transition from %s to %s: %v",
+			ErrInvalidStatusTransition, order.Status, update.Status, err)
+	}
+
+	actor := update.Actor
+	if principal, ok := auth.FromContext(ctx); ok {
+		if actor != "" && actor != principal.Subject {
+			return domain.StatusChange{}, fmt.Errorf("%w: actor %q is not the authenticated caller %q",
+				ErrPermissionDenied, actor, principal.Subject)
+		}
+		actor = principal.Subject
+	}
+	if actor == "" {
+		actor = "anonymous"
+	}
+	return domain.StatusChange{
+		FromStatus: order.Status,
+		ToStatus:   update.Status,
+		Actor:      actor,
+		Reason:     update.Reason,
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// UpdateOrderItems adds, removes or re-quantifies line items of a pending order
+// and returns the updated order.
+// Business logic: only pending orders can be edited; the edited order must still
+// pass validation, and the total is recalculated. Like status updates, the write
+// is a compare-and-swap on the version read here. Added items are priced from
+// the catalog, as on CreateOrder.
+func (s *OrderService) UpdateOrderItems(ctx context.Context, id string, edits []domain.ItemEdit, expectedVersion int64) (*domain.Order, error) {
+	if len(edits) == 0 {
+		return nil, fmt.Errorf("%w: no item edits given", ErrInvalidOrder)
+	}
+
+	order, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedVersion != 0 && order.Version != expectedVersion {
+		return nil, fmt.Errorf("%w: expected version %d, current version is %d",
+			repository.ErrVersionConflict, expectedVersion, order.Version)
+	}
+
+	if order.Status != domain.StatusPending {
+		return nil, fmt.Errorf("%w: items can only be changed while the order is %s, it is %s",
+			ErrOrderNotEditable, domain.StatusPending, order.Status)
+	}
+
+	for n, edit := range edits {
+		if edit.Op == domain.ItemEditAdd && edit.Item != nil {
+			if err := s.resolveItem(ctx, fmt.Sprintf("operations[%d].item", n), edit.Item); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := order.ApplyItemEdits(edits); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOrder, err)
+	}
+	if err := order.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOrder, err)
+	}
+	if err := s.priceOrder(order); err != nil {
+		return nil, err
 	}
 
-	// Validate status transition
-	if !order.CanTransitionTo(newStatus) {
-		return fmt.Errorf("%w: cannot transition from %s to %s",
-			ErrInvalidStatusTransition, order.Status, newStatus)
+	if err := s.repo.Update(ctx, order); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, id)
+}
+
+// UpdateOrder changes the customer, items, coupon code or region of a pending
+// order and returns the updated order. The result is validated and repriced
+// like a new order: a new customer must be active and replaced items are
+// priced from the catalog. The write is a compare-and-swap on the version read here.
+func (s *OrderService) UpdateOrder(ctx context.Context, id string, update OrderUpdate) (*domain.Order, error) {
+	if update.CustomerID == nil && update.Items == nil && update.CouponCode == nil && update.Region == nil {
+		return nil, fmt.Errorf("%w: no fields to update", ErrInvalidOrder)
+	}
+
+	order, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.ExpectedVersion != 0 && order.Version != update.ExpectedVersion {
+		return nil, fmt.Errorf("%w: expected version %d, current version is %d",
+			repository.ErrVersionConflict, update.ExpectedVersion, order.Version)
+	}
+
+	if order.Status != domain.StatusPending {
+		return nil, fmt.Errorf("%w: orders can only be changed while %s, it is %s",
+			ErrOrderNotEditable, domain.StatusPending, order.Status)
+	}
+
+	customerChanged := update.CustomerID != nil && *update.CustomerID != order.CustomerID
+	if update.CustomerID != nil {
+		order.CustomerID = *update.CustomerID
+	}
+	if update.Items != nil {
+		order.Items = append([]domain.LineItem(nil), *update.Items...)
+		for i := range order.Items {
+			if err := s.resolveItem(ctx, fmt.Sprintf("items[%d]", i), &order.Items[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if update.CouponCode != nil {
+		order.CouponCode = *update.CouponCode
+	}
+	if update.Region != nil {
+		order.Region = *update.Region
+	}
+
+	if err := order.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOrder, err)
+	}
+	if customerChanged {
+		if err := s.checkCustomer(ctx, order.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.priceOrder(order); err != nil {
+		return nil, err
 	}
 
-	// Update status in repository
-	return s.repo.UpdateStatus(ctx, id, newStatus)
+	if err := s.repo.Update(ctx, order); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, id)
 }
 
-// CalculateOrderTotal recalculates and returns the total for an order.
-// Useful if prices change or items are modified.
-func (s *OrderService) CalculateOrderTotal(ctx context.Context, id string) (float64, error) {
+// GetAllowedTransitions returns the order and the statuses it can move to next.
+func (s *OrderService) GetAllowedTransitions(ctx context.Context, id string) (*domain.Order, []domain.OrderStatus, error) {
 	order, err := s.repo.Get(ctx, id)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
 	}
+	return order, s.machine.AllowedTransitions(order), nil
+}
 
-	order.CalculateTotal()
-	return order.TotalAmount, nil
+// GetOrderHistory returns the status changes of an order, oldest first.
+func (s *OrderService) GetOrderHistory(ctx context.Context, id string) ([]domain.StatusChange, error) {
+	return s.repo.GetHistory(ctx, id)
+}
+
+// CalculateOrderTotal re-runs the current pricing rules on an order and
+// returns the resulting breakdown, without changing the stored order.
+// Useful to see how an order would be priced after the rules have changed.
+func (s *OrderService) CalculateOrderTotal(ctx context.Context, id string) (*domain.PriceBreakdown, error) {
+	order, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown, err := s.pricing.Price(order)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOrder, err)
+	}
+	return breakdown, nil
 }
 
-// DeleteOrder removes an order.
+// DeleteOrder soft-deletes an order. It disappears from reads but can be
+// restored with RestoreOrder until the purge job removes it for good.
+// Deleting a confirmed order releases its stock, so purging it later
+// doesn't leave the stock reserved.
 func (s *OrderService) DeleteOrder(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	// Read the status back: deleted orders can't change, so it is final
+	order, err := s.repo.GetIncludingDeleted(ctx, id)
+	if err == nil && order.Status == domain.StatusConfirmed {
+		s.releaseStock(ctx, id)
+	}
+	return nil
+}
+
+// RestoreOrder undoes a soft delete and returns the restored order.
+// Restoring a confirmed order reserves its stock again; if there isn't
+// enough, it fails with ErrInsufficientStock and the order stays deleted.
+func (s *OrderService) RestoreOrder(ctx context.Context, id string) (*domain.Order, error) {
+	order, err := s.repo.GetIncludingDeleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reserved := false
+	if s.inventory != nil && order.IsDeleted() && order.Status == domain.StatusConfirmed {
+		if reserved, err = s.inventory.Reserve(ctx, id, order.Items); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.Restore(ctx, id); err != nil {
+		if reserved {
+			s.unreserveStock(ctx, id)
+		}
+		return nil, err
+	}
+	return s.repo.Get(ctx, id)
 }
@@ -0,0 +1,1094 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	commondomain "golang-for-java-developers-training/common/domain"
+	"lab10/internal/auth"
+	"lab10/internal/domain"
+	"lab10/internal/inventory"
+	"lab10/internal/pricing"
+	"lab10/internal/repository"
+)
+
+func newTestService(t *testing.T) (*OrderService, *domain.Order) {
+	t.Helper()
+	svc := NewOrderService(repository.NewMemoryRepository())
+	order := &domain.Order{
+		CustomerID: "CUST-001",
+		Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 1, UnitPrice: domain.MustMoney("10.00", "USD")}},
+	}
+	if err := svc.CreateOrder(context.Background(), order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	return svc, order
+}
+
+// TestUpdateOrderStatusConcurrent races a confirm against a cancel:
+// exactly one may win, the other must see a version conflict.
+func TestUpdateOrderStatusConcurrent(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		svc, order := newTestService(t)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for j, status := range []domain.OrderStatus{domain.StatusConfirmed, domain.StatusCancelled} {
+			wg.Add(1)
+			go func(j int, status domain.OrderStatus) {
+				defer wg.Done()
+				update := StatusUpdate{Status: status, ExpectedVersion: order.Version}
+				_, errs[j] = svc.UpdateOrderStatus(context.Background(), order.ID, update)
+			}(j, status)
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, repository.ErrVersionConflict):
+			default:
+				t.Fatalf("UpdateOrderStatus() unexpected error = %v", err)
+			}
+		}
+		if succeeded != 1 {
+			t.Fatalf("%d concurrent transitions succeeded, want exactly 1", succeeded)
+		}
+	}
+}
+
+// TestConfirmOrderConcurrent races two confirms of one order: exactly one
+// may win, and the loser must not give back the stock the winner holds.
+func TestConfirmOrderConcurrent(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		stock := inventory.NewMemory(10)
+		svc := NewOrderService(repository.NewMemoryRepository(), WithInventory(stock))
+		order := &domain.Order{
+			CustomerID: "CUST-001",
+			Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 3, UnitPrice: domain.MustMoney("10.00", "USD")}},
+		}
+		if err := svc.CreateOrder(context.Background(), order); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for j := range errs {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				update := StatusUpdate{Status: domain.StatusConfirmed, ExpectedVersion: order.Version}
+				_, errs[j] = svc.UpdateOrderStatus(context.Background(), order.ID, update)
+			}(j)
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, repository.ErrVersionConflict):
+			default:
+				t.Fatalf("UpdateOrderStatus() unexpected error = %v", err)
+			}
+		}
+		if succeeded != 1 {
+			t.Fatalf("%d concurrent confirms succeeded, want exactly 1", succeeded)
+		}
+		if got := stock.Available("SKU-1"); got != 7 {
+			t.Fatalf("Available() after concurrent confirms = %d, want 7", got)
+		}
+	}
+}
+
+// TestUpdateOrderStatusExpectedVersion verifies a stale expected version is rejected.
+func TestUpdateOrderStatusExpectedVersion(t *testing.T) {
+	svc, order := newTestService(t)
+	ctx := context.Background()
+
+	updated, err := svc.UpdateOrderStatus(ctx, order.ID, StatusUpdate{Status: domain.StatusConfirmed, ExpectedVersion: order.Version})
+	if err != nil {
+		t.Fatalf("UpdateOrderStatus() error = %v", err)
+	}
+	if updated.Version != order.Version+1 {
+		t.Errorf("version = %d, want %d", updated.Version, order.Version+1)
+	}
+
+	_, err = svc.UpdateOrderStatus(ctx, order.ID, StatusUpdate{Status: domain.StatusShipped, ExpectedVersion: order.Version})
+	if !errors.Is(err, repository.ErrVersionConflict) {
+		t.Errorf("error = %v, want %v", err, repository.ErrVersionConflict)
+	}
+}
+
+// TestGetOrderHistory verifies each transition is recorded with its actor and reason.
+func TestGetOrderHistory(t *testing.T) {
+	svc, order := newTestService(t)
+	ctx := context.Background()
+
+	updates := []StatusUpdate{
+		{Status: domain.StatusConfirmed, Actor: "alice"},
+		{Status: domain.StatusCancelled, Actor: "bob", Reason: "customer request"},
+	}
+	for _, update := range updates {
+		if _, err := svc.UpdateOrderStatus(ctx, order.ID, update); err != nil {
+			t.Fatalf("UpdateOrderStatus(%s) error = %v", update.Status, err)
+		}
+	}
+	// A rejected transition must not be recorded
+	if _, err := svc.UpdateOrderStatus(ctx, order.ID, StatusUpdate{Status: domain.StatusShipped}); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatalf("UpdateOrderStatus(shipped) error = %v, want %v", err, ErrInvalidStatusTransition)
+	}
+
+	history, err := svc.GetOrderHistory(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("GetOrderHistory() error = %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("got %d history entries, want 2", len(history))
+	}
+	if history[0].FromStatus != domain.StatusPending || history[0].ToStatus != domain.StatusConfirmed || history[0].Actor != "alice" {
+		t.Errorf("history[0] = %+v", history[0])
+	}
+	if history[1].FromStatus != domain.StatusConfirmed || history[1].Reason != "customer request" {
+		t.Errorf("history[1] = %+v", history[1])
+	}
+}
+
+// TestStatusChangeActor checks the authenticated caller is recorded as the
+// actor, and that a caller can't record someone else.
+func TestStatusChangeActor(t *testing.T) {
+	svc, order := newTestService(t)
+	alice := auth.NewContext(context.Background(), &auth.Principal{Subject: "alice", Method: auth.MethodJWT})
+
+	if _, err := svc.UpdateOrderStatus(alice, order.ID, StatusUpdate{Status: domain.StatusConfirmed, Actor: "bob"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("UpdateOrderStatus() as alice for bob error = %v, want %v", err, ErrPermissionDenied)
+	}
+	items := []StatusUpdateItem{{ID: order.ID, StatusUpdate: StatusUpdate{Status: domain.StatusConfirmed, Actor: "bob"}}}
+	if results, err := svc.BatchUpdateStatus(alice, items, true); err != nil || !errors.Is(results[0].Err, ErrPermissionDenied) {
+		t.Fatalf("BatchUpdateStatus() as alice for bob = %v, %v, want %v", results, err, ErrPermissionDenied)
+	}
+
+	updates := []struct {
+		ctx       context.Context
+		update    StatusUpdate
+		wantActor string
+	}{
+		{alice, StatusUpdate{Status: domain.StatusConfirmed}, "alice"},
+		{alice, StatusUpdate{Status: domain.StatusShipped, Actor: "alice"}, "alice"},
+		{context.Background(), StatusUpdate{Status: domain.StatusDelivered}, "anonymous"},
+	}
+	for _, u := range updates {
+		if _, err := svc.UpdateOrderStatus(u.ctx, order.ID, u.update); err != nil {
+			t.Fatalf("UpdateOrderStatus(%s) error = %v", u.update.Status, err)
+		}
+	}
+	history, err := svc.GetOrderHistory(context.Background(), order.ID)
+	if err != nil {
+		t.Fatalf("GetOrderHistory() error = %v", err)
+	}
+	if len(history) != len(updates) {
+		t.Fatalf("got %d history entries, want %d", len(history), len(updates))
+	}
+	for i, u := range updates {
+		if history[i].Actor != u.wantActor {
+			t.Errorf("history[%d] (%s) actor = %q, want %q", i, history[i].ToStatus, history[i].Actor, u.wantActor)
+		}
+	}
+}
+
+// TestUpdateOrderItems checks edits recalculate the total and are rejected once the order leaves pending.
+func TestUpdateOrderItems(t *testing.T) {
+	svc, order := newTestService(t)
+	ctx := context.Background()
+
+	edits := []domain.ItemEdit{
+		{Op: domain.ItemEditAdd, Item: &domain.LineItem{ProductID: "SKU-2", ProductName: "Gadget", Quantity: 2, UnitPrice: domain.MustMoney("2.50", "USD")}},
+		{Op: domain.ItemEditSetQuantity, ProductID: "SKU-1", Quantity: 3},
+	}
+	updated, err := svc.UpdateOrderItems(ctx, order.ID, edits, order.Version)
+	if err != nil {
+		t.Fatalf("UpdateOrderItems() error = %v", err)
+	}
+	if updated.TotalAmount != domain.MustMoney("35.00", "USD") {
+		t.Errorf("TotalAmount = %v, want 35.00 USD", updated.TotalAmount)
+	}
+
+	tests := []struct {
+		name    string
+		edits   []domain.ItemEdit
+		version int64
+		want    error
+	}{
+		{"remove last item", []domain.ItemEdit{{Op: domain.ItemEditRemove, ProductID: "SKU-1"}, {Op: domain.ItemEditRemove, ProductID: "SKU-2"}}, 0, ErrInvalidOrder},
+		{"unknown product", []domain.ItemEdit{{Op: domain.ItemEditRemove, ProductID: "SKU-9"}}, 0, ErrInvalidOrder},
+		{"duplicate product", []domain.ItemEdit{{Op: domain.ItemEditAdd, Item: &domain.LineItem{ProductID: "SKU-1", ProductName: "Widget", Quantity: 1, UnitPrice: domain.MustMoney("10.00", "USD")}}}, 0, ErrInvalidOrder},
+		{"zero quantity", []domain.ItemEdit{{Op: domain.ItemEditSetQuantity, ProductID: "SKU-1"}}, 0, ErrInvalidOrder},
+		{"stale version", []domain.ItemEdit{{Op: domain.ItemEditRemove, ProductID: "SKU-2"}}, order.Version, repository.ErrVersionConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.UpdateOrderItems(ctx, order.ID, tt.edits, tt.version); !errors.Is(err, tt.want) {
+				t.Errorf("UpdateOrderItems() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := svc.UpdateOrderStatus(ctx, order.ID, StatusUpdate{Status: domain.StatusConfirmed}); err != nil {
+		t.Fatalf("UpdateOrderStatus() error = %v", err)
+	}
+	_, err = svc.UpdateOrderItems(ctx, order.ID, []domain.ItemEdit{{Op: domain.ItemEditRemove, ProductID: "SKU-2"}}, 0)
+	if !errors.Is(err, ErrOrderNotEditable) {
+		t.Errorf("UpdateOrderItems() on confirmed order error = %v, want %v", err, ErrOrderNotEditable)
+	}
+}
+
+// TestUpdateOrder checks partial updates change only the given fields and
+// reprice the order.
+func TestUpdateOrder(t *testing.T) {
+	svc, order := newTestService(t)
+	ctx := context.Background()
+
+	region := "US-CA"
+	items := []domain.LineItem{{ProductID: "SKU-2", ProductName: "Gadget", Quantity: 4, UnitPrice: domain.MustMoney("2.50", "USD")}}
+	updated, err := svc.UpdateOrder(ctx, order.ID, OrderUpdate{Items: &items, Region: &region, ExpectedVersion: order.Version})
+	if err != nil {
+		t.Fatalf("UpdateOrder() error = %v", err)
+	}
+	if updated.CustomerID != order.CustomerID || updated.Region != region || len(updated.Items) != 1 || updated.Items[0].ProductID != "SKU-2" {
+		t.Errorf("UpdateOrder() = %+v, want items and region replaced, customer unchanged", updated)
+	}
+	if updated.TotalAmount != domain.MustMoney("10.00", "USD") || updated.Version != order.Version+1 {
+		t.Errorf("UpdateOrder() total = %v, version = %d; want 10.00 USD, %d", updated.TotalAmount, updated.Version, order.Version+1)
+	}
+
+	empty := ""
+	var noItems []domain.LineItem
+	tests := []struct {
+		name   string
+		update OrderUpdate
+		want   error
+	}{
+		{"nothing to update", OrderUpdate{}, ErrInvalidOrder},
+		{"empty customer", OrderUpdate{CustomerID: &empty}, ErrInvalidOrder},
+		{"no items", OrderUpdate{Items: &noItems}, ErrInvalidOrder},
+		{"stale version", OrderUpdate{Region: &empty, ExpectedVersion: order.Version}, repository.ErrVersionConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.UpdateOrder(ctx, order.ID, tt.update); !errors.Is(err, tt.want) {
+				t.Errorf("UpdateOrder() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := svc.UpdateOrderStatus(ctx, order.ID, StatusUpdate{Status: domain.StatusConfirmed}); err != nil {
+		t.Fatalf("UpdateOrderStatus() error = %v", err)
+	}
+	if _, err := svc.UpdateOrder(ctx, order.ID, OrderUpdate{Region: &empty}); !errors.Is(err, ErrOrderNotEditable) {
+		t.Errorf("UpdateOrder() on confirmed order error = %v, want %v", err, ErrOrderNotEditable)
+	}
+}
+
+// fakeSubscriber hands events straight to the subscribed handlers.
+type fakeSubscriber struct {
+	mu       sync.Mutex
+	handlers map[int]func(domain.Event)
+	nextID   int
+}
+
+func (f *fakeSubscriber) Subscribe(handler func(domain.Event)) func() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.handlers == nil {
+		f.handlers = make(map[int]func(domain.Event))
+	}
+	id := f.nextID
+	f.nextID++
+	f.handlers[id] = handler
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.handlers, id)
+	}
+}
+
+func (f *fakeSubscriber) publish(event domain.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, handler := range f.handlers {
+		handler(event)
+	}
+}
+
+func (f *fakeSubscriber) subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.handlers)
+}
+
+func TestWatchOrdersFilters(t *testing.T) {
+	subscriber := &fakeSubscriber{}
+	svc := NewOrderService(repository.NewMemoryRepository(), WithEventSubscriber(subscriber))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events, err := svc.WatchOrders(ctx, WatchFilter{CustomerID: "CUST-1"})
+	if err != nil {
+		t.Fatalf("WatchOrders() error = %v", err)
+	}
+
+	subscriber.publish(domain.Event{ID: 1, OrderID: "ORD-1", Order: &domain.Order{ID: "ORD-1", CustomerID: "CUST-2"}})
+	subscriber.publish(domain.Event{ID: 2, OrderID: "ORD-2", Order: &domain.Order{ID: "ORD-2", CustomerID: "CUST-1"}})
+
+	if got := <-events; got.ID != 2 {
+		t.Fatalf("received event %d, want 2", got.ID)
+	}
+
+	// A redelivered event is dropped
+	subscriber.publish(domain.Event{ID: 2, OrderID: "ORD-2", Order: &domain.Order{ID: "ORD-2", CustomerID: "CUST-1"}})
+	subscriber.publish(domain.Event{ID: 3, OrderID: "ORD-2", Order: &domain.Order{ID: "ORD-2", CustomerID: "CUST-1"}})
+	if got := <-events; got.ID != 3 {
+		t.Fatalf("received event %d, want 3", got.ID)
+	}
+
+	// Cancelling the watch closes the channel and unsubscribes
+	cancel()
+	for range events {
+	}
+	if n := subscriber.subscribers(); n != 0 {
+		t.Fatalf("%d subscribers left after cancel, want 0", n)
+	}
+}
+
+func TestWatchOrdersSlowConsumer(t *testing.T) {
+	subscriber := &fakeSubscriber{}
+	svc := NewOrderService(repository.NewMemoryRepository(), WithEventSubscriber(subscriber))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := svc.WatchOrders(ctx, WatchFilter{})
+	if err != nil {
+		t.Fatalf("WatchOrders() error = %v", err)
+	}
+
+	for i := 0; i <= watchBufferSize; i++ {
+		subscriber.publish(domain.Event{ID: int64(i + 1), OrderID: "ORD-1"})
+	}
+
+	received := 0
+	for range events {
+		received++
+	}
+	if received != watchBufferSize {
+		t.Fatalf("received %d events before close, want %d", received, watchBufferSize)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("caller context was cancelled, want only the watch to end")
+	}
+}
+
+func TestWatchOrdersUnavailable(t *testing.T) {
+	svc := NewOrderService(repository.NewMemoryRepository())
+	if _, err := svc.WatchOrders(context.Background(), WatchFilter{}); !errors.Is(err, ErrWatchUnavailable) {
+		t.Fatalf("WatchOrders() error = %v, want ErrWatchUnavailable", err)
+	}
+}
+
+func TestCreateOrderIdempotent(t *testing.T) {
+	ctx := context.Background()
+	svc := NewOrderService(repository.NewMemoryRepository(), WithClientOrderIDs(true))
+	newOrder := func(quantity int) *domain.Order {
+		return &domain.Order{
+			ID:         "ORD-001",
+			CustomerID: "CUST-001",
+			Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: quantity, UnitPrice: domain.MustMoney("10.00", "USD")}},
+		}
+	}
+
+	first := newOrder(1)
+	replayed, err := svc.CreateOrderIdempotent(ctx, "key-1", first)
+	if err != nil || replayed {
+		t.Fatalf("CreateOrderIdempotent() = %v, %v, want false, nil", replayed, err)
+	}
+
+	// A change after creation must not alter the replayed response
+	if _, err := svc.UpdateOrderStatus(ctx, first.ID, StatusUpdate{Status: domain.StatusConfirmed}); err != nil {
+		t.Fatalf("UpdateOrderStatus() error = %v", err)
+	}
+
+	retry := newOrder(1)
+	replayed, err = svc.CreateOrderIdempotent(ctx, "key-1", retry)
+	if err != nil || !replayed {
+		t.Fatalf("retry CreateOrderIdempotent() = %v, %v, want true, nil", replayed, err)
+	}
+	if retry.Status != domain.StatusPending || retry.Version != first.Version || retry.TotalAmount != first.TotalAmount {
+		t.Fatalf("replayed order = %+v, want %+v", retry, first)
+	}
+
+	if _, err := svc.CreateOrderIdempotent(ctx, "key-1", newOrder(2)); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("CreateOrderIdempotent() with different payload error = %v, want ErrIdempotencyKeyReused", err)
+	}
+
+	// Without a key a duplicate still conflicts
+	if _, err := svc.CreateOrderIdempotent(ctx, "", newOrder(1)); !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Fatalf("CreateOrderIdempotent() without key error = %v, want ErrAlreadyExists", err)
+	}
+}
+
+// TestCreateOrderIdempotentFailure checks a failed request doesn't hold on to its key.
+func TestCreateOrderIdempotentFailure(t *testing.T) {
+	ctx := context.Background()
+	svc := NewOrderService(repository.NewMemoryRepository())
+
+	invalid := &domain.Order{CustomerID: "CUST-001"}
+	if _, err := svc.CreateOrderIdempotent(ctx, "key-1", invalid); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("CreateOrderIdempotent() error = %v, want ErrInvalidOrder", err)
+	}
+
+	valid := &domain.Order{
+		CustomerID: "CUST-001",
+		Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 1, UnitPrice: domain.MustMoney("10.00", "USD")}},
+	}
+	if replayed, err := svc.CreateOrderIdempotent(ctx, "key-1", valid); err != nil || replayed {
+		t.Fatalf("CreateOrderIdempotent() = %v, %v, want false, nil", replayed, err)
+	}
+}
+
+// TestCreateOrderIdempotentPerCaller checks callers can't replay each
+// other's orders by reusing a key.
+func TestCreateOrderIdempotentPerCaller(t *testing.T) {
+	svc := NewOrderService(repository.NewMemoryRepository())
+	newOrder := func() *domain.Order {
+		return &domain.Order{
+			CustomerID: "CUST-001",
+			Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 1, UnitPrice: domain.MustMoney("10.00", "USD")}},
+		}
+	}
+	alice := auth.NewContext(context.Background(), &auth.Principal{Subject: "alice", Method: auth.MethodJWT})
+	bob := auth.NewContext(context.Background(), &auth.Principal{Subject: "bob", Method: auth.MethodJWT})
+
+	first := newOrder()
+	if replayed, err := svc.CreateOrderIdempotent(alice, "key-1", first); err != nil || replayed {
+		t.Fatalf("CreateOrderIdempotent(alice) = %v, %v, want false, nil", replayed, err)
+	}
+	other := newOrder()
+	if replayed, err := svc.CreateOrderIdempotent(bob, "key-1", other); err != nil || replayed {
+		t.Fatalf("CreateOrderIdempotent(bob) = %v, %v, want false, nil", replayed, err)
+	}
+	if other.ID == first.ID {
+		t.Fatalf("bob got alice's order %s replayed", first.ID)
+	}
+
+	retry := newOrder()
+	if replayed, err := svc.CreateOrderIdempotent(alice, "key-1", retry); err != nil || !replayed || retry.ID != first.ID {
+		t.Fatalf("CreateOrderIdempotent(alice) retry = %v, %v, order %s; want replay of %s", replayed, err, retry.ID, first.ID)
+	}
+}
+
+func TestCreateOrderIDs(t *testing.T) {
+	ctx := context.Background()
+	newOrder := func(id string) *domain.Order {
+		return &domain.Order{
+			ID:         id,
+			CustomerID: "CUST-001",
+			Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 1, UnitPrice: domain.MustMoney("10.00", "USD")}},
+		}
+	}
+
+	svc := NewOrderService(repository.NewMemoryRepository())
+	first, second := newOrder(""), newOrder("")
+	for _, order := range []*domain.Order{first, second} {
+		if err := svc.CreateOrder(ctx, order); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+	}
+	if first.ID == "" || first.ID == second.ID {
+		t.Fatalf("generated IDs %q and %q, want distinct non-empty IDs", first.ID, second.ID)
+	}
+	if err := svc.CreateOrder(ctx, newOrder("ORD-001")); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("CreateOrder() with client ID error = %v, want ErrInvalidOrder", err)
+	}
+
+	svc = NewOrderService(repository.NewMemoryRepository(), WithClientOrderIDs(true))
+	if err := svc.CreateOrder(ctx, newOrder("ORD-001")); err != nil {
+		t.Fatalf("CreateOrder() with allowed client ID error = %v", err)
+	}
+	if err := svc.CreateOrder(ctx, newOrder("ORD/001")); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("CreateOrder() with invalid client ID error = %v, want ErrInvalidOrder", err)
+	}
+}
+
+func TestBatchCreateOrders(t *testing.T) {
+	ctx := context.Background()
+	newOrders := func() []*domain.Order {
+		valid := func() *domain.Order {
+			return &domain.Order{
+				CustomerID: "CUST-001",
+				Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 1, UnitPrice: domain.MustMoney("10.00", "USD")}},
+			}
+		}
+		return []*domain.Order{valid(), {CustomerID: "CUST-001"}, valid()}
+	}
+
+	t.Run("partial", func(t *testing.T) {
+		svc := NewOrderService(repository.NewMemoryRepository())
+		results, err := svc.BatchCreateOrders(ctx, newOrders(), false)
+		if err != nil {
+			t.Fatalf("BatchCreateOrders() error = %v", err)
+		}
+		if results[0].Err != nil || results[2].Err != nil || !errors.Is(results[1].Err, ErrInvalidOrder) {
+			t.Fatalf("BatchCreateOrders() results = %+v, want only item 1 to fail", results)
+		}
+		if _, err := svc.GetOrder(ctx, results[2].Order.ID); err != nil {
+			t.Fatalf("GetOrder() error = %v", err)
+		}
+	})
+
+	t.Run("atomic", func(t *testing.T) {
+		repo := repository.NewMemoryRepository()
+		svc := NewOrderService(repo)
+		results, err := svc.BatchCreateOrders(ctx, newOrders(), true)
+		if err != nil {
+			t.Fatalf("BatchCreateOrders() error = %v", err)
+		}
+		if !errors.Is(results[0].Err, ErrBatchAborted) || !errors.Is(results[1].Err, ErrInvalidOrder) || !errors.Is(results[2].Err, ErrBatchAborted) {
+			t.Fatalf("BatchCreateOrders() results = %+v, want item 1 invalid and the rest aborted", results)
+		}
+		if orders, _ := repo.GetAll(ctx); len(orders) != 0 {
+			t.Fatalf("%d orders stored after failed atomic batch, want 0", len(orders))
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		svc := NewOrderService(repository.NewMemoryRepository())
+		if _, err := svc.BatchCreateOrders(ctx, make([]*domain.Order, MaxBatchSize+1), false); !errors.Is(err, ErrInvalidBatch) {
+			t.Fatalf("BatchCreateOrders() error = %v, want ErrInvalidBatch", err)
+		}
+	})
+}
+
+func TestBatchUpdateStatusAtomic(t *testing.T) {
+	ctx := context.Background()
+	svc, order := newTestService(t)
+
+	// Shipping is only allowed after the confirm earlier in the same batch
+	items := []StatusUpdateItem{
+		{ID: order.ID, StatusUpdate: StatusUpdate{Status: domain.StatusConfirmed}},
+		{ID: order.ID, StatusUpdate: StatusUpdate{Status: domain.StatusShipped}},
+		{ID: "missing", StatusUpdate: StatusUpdate{Status: domain.StatusConfirmed}},
+	}
+	results, err := svc.BatchUpdateStatus(ctx, items, true)
+	if err != nil {
+		t.Fatalf("BatchUpdateStatus() error = %v", err)
+	}
+	if !errors.Is(results[2].Err, repository.ErrNotFound) || !errors.Is(results[0].Err, ErrBatchAborted) {
+		t.Fatalf("BatchUpdateStatus() results = %+v, want item 2 not found and the rest aborted", results)
+	}
+	if current, _ := svc.GetOrder(ctx, order.ID); current.Status != domain.StatusPending {
+		t.Fatalf("status after failed atomic batch = %s, want pending", current.Status)
+	}
+
+	results, err = svc.BatchUpdateStatus(ctx, items[:2], true)
+	if err != nil {
+		t.Fatalf("BatchUpdateStatus() error = %v", err)
+	}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("BatchUpdateStatus() item %d error = %v", i, result.Err)
+		}
+	}
+	if current, _ := svc.GetOrder(ctx, order.ID); current.Status != domain.StatusShipped {
+		t.Fatalf("status after atomic batch = %s, want shipped", current.Status)
+	}
+}
+
+// TestRestoreOrder checks a deleted order can be restored once and is
+// gone for good after a purge.
+func TestRestoreOrder(t *testing.T) {
+	svc, order := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.RestoreOrder(ctx, order.ID); !errors.Is(err, repository.ErrNotDeleted) {
+		t.Fatalf("RestoreOrder() of live order error = %v, want %v", err, repository.ErrNotDeleted)
+	}
+	if err := svc.DeleteOrder(ctx, order.ID); err != nil {
+		t.Fatalf("DeleteOrder() error = %v", err)
+	}
+	if _, err := svc.GetOrder(ctx, order.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("GetOrder() of deleted order error = %v, want %v", err, repository.ErrNotFound)
+	}
+
+	restored, err := svc.RestoreOrder(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("RestoreOrder() error = %v", err)
+	}
+	if restored.IsDeleted() {
+		t.Fatal("restored order still has DeletedAt set")
+	}
+
+	if err := svc.DeleteOrder(ctx, order.ID); err != nil {
+		t.Fatalf("DeleteOrder() error = %v", err)
+	}
+	// A negative retention makes every deleted order eligible
+	if n, err := NewPurger(svc.repo, -time.Minute).PurgeOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("PurgeOnce() = %d, %v, want 1", n, err)
+	}
+	if _, err := svc.GetOrderIncludingDeleted(ctx, order.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("GetOrderIncludingDeleted() of purged order error = %v, want %v", err, repository.ErrNotFound)
+	}
+}
+
+// TestIncludeDeletedRequiresAdmin checks only admins, or any caller with
+// authentication disabled, may read or list deleted orders.
+func TestIncludeDeletedRequiresAdmin(t *testing.T) {
+	svc, order := newTestService(t)
+	if err := svc.DeleteOrder(context.Background(), order.ID); err != nil {
+		t.Fatalf("DeleteOrder() error = %v", err)
+	}
+	deleted := repository.ListOptions{Filter: repository.ListFilter{IncludeDeleted: true}}
+
+	user := auth.NewContext(context.Background(), &auth.Principal{Subject: "alice", Method: auth.MethodJWT})
+	if _, err := svc.GetOrderIncludingDeleted(user, order.ID); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("GetOrderIncludingDeleted() by user error = %v, want %v", err, ErrPermissionDenied)
+	}
+	if _, err := svc.ListOrders(user, deleted); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("ListOrders(IncludeDeleted) by user error = %v, want %v", err, ErrPermissionDenied)
+	}
+	if err := svc.ExportOrders(user, deleted.Filter, func(*domain.Order) error { return nil }); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("ExportOrders(IncludeDeleted) by user error = %v, want %v", err, ErrPermissionDenied)
+	}
+	if _, err := svc.ListOrders(user, repository.ListOptions{}); err != nil {
+		t.Errorf("ListOrders() by user error = %v", err)
+	}
+
+	admin := auth.NewContext(context.Background(), &auth.Principal{
+		Subject: "bob",
+		Method:  auth.MethodJWT,
+		Claims:  map[string]interface{}{"role": auth.AdminRole},
+	})
+	for name, ctx := range map[string]context.Context{"admin": admin, "no principal": context.Background()} {
+		if _, err := svc.GetOrderIncludingDeleted(ctx, order.ID); err != nil {
+			t.Errorf("GetOrderIncludingDeleted() by %s error = %v", name, err)
+		}
+		if page, err := svc.ListOrders(ctx, deleted); err != nil || len(page.Orders) != 1 {
+			t.Errorf("ListOrders(IncludeDeleted) by %s = %v, %v, want the deleted order", name, page, err)
+		}
+	}
+}
+
+// TestCreateOrderChecksCustomer checks orders are only accepted for existing,
+// active customers once customers are configured, and that they then show
+// up in the customer's orders and stats.
+func TestCreateOrderChecksCustomer(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	orders := NewOrderService(repo, WithCustomers(repo))
+	customers := NewCustomerService(repo, orders)
+
+	newOrder := func() *domain.Order {
+		return &domain.Order{
+			CustomerID: "CUST-001",
+			Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 2, UnitPrice: domain.MustMoney("10.00", "USD")}},
+		}
+	}
+
+	err := orders.CreateOrder(ctx, newOrder())
+	var verr *domain.ValidationError
+	if !errors.Is(err, ErrInvalidOrder) || !errors.As(err, &verr) || verr.Violations[0].Field != "customer_id" {
+		t.Fatalf("CreateOrder() for unknown customer error = %v, want %v on customer_id", err, ErrInvalidOrder)
+	}
+
+	customer := &domain.Customer{ID: "CUST-001", Name: "Ada", Email: "ada@example.com"}
+	if err := customers.CreateCustomer(ctx, customer); err != nil {
+		t.Fatalf("CreateCustomer() error = %v", err)
+	}
+	if customer.Status != domain.CustomerActive {
+		t.Fatalf("new customer status = %s, want %s", customer.Status, domain.CustomerActive)
+	}
+	if err := orders.CreateOrder(ctx, newOrder()); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	stats, err := customers.GetCustomerStats(ctx, "CUST-001")
+	if err != nil {
+		t.Fatalf("GetCustomerStats() error = %v", err)
+	}
+	if stats.OrderCount != 1 || len(stats.LifetimeValue) != 1 || stats.LifetimeValue[0] != domain.MustMoney("20.00", "USD") {
+		t.Fatalf("stats = %+v, want 1 order worth 20.00 USD", stats)
+	}
+	result, err := customers.ListCustomerOrders(ctx, "CUST-001", repository.ListOptions{})
+	if err != nil || len(result.Orders) != 1 {
+		t.Fatalf("ListCustomerOrders() = %v, %v, want 1 order", result, err)
+	}
+	if _, err := customers.ListCustomerOrders(ctx, "CUST-404", repository.ListOptions{}); !errors.Is(err, repository.ErrCustomerNotFound) {
+		t.Fatalf("ListCustomerOrders() for unknown customer error = %v, want %v", err, repository.ErrCustomerNotFound)
+	}
+
+	if _, err := customers.UpdateCustomer(ctx, "CUST-001", CustomerUpdate{Status: domain.CustomerInactive}); err != nil {
+		t.Fatalf("UpdateCustomer() error = %v", err)
+	}
+	if err := orders.CreateOrder(ctx, newOrder()); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("CreateOrder() for inactive customer error = %v, want %v", err, ErrInvalidOrder)
+	}
+	if _, err := customers.UpdateCustomer(ctx, "CUST-001", CustomerUpdate{Email: "not-an-email"}); !errors.Is(err, ErrInvalidCustomer) {
+		t.Fatalf("UpdateCustomer() with bad email error = %v, want %v", err, ErrInvalidCustomer)
+	}
+}
+
+// TestCreateOrderUsesCatalog checks items are priced from the catalog, that
+// unknown and discontinued products are rejected, and that later price
+// changes leave existing orders alone.
+func TestCreateOrderUsesCatalog(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	orders := NewOrderService(repo, WithCatalog(repo))
+	catalog := NewCatalogService(repo)
+
+	product := &domain.Product{Product: commondomain.Product{SKU: "SKU-1", Name: "Widget"}}
+	product.SetPrice(domain.MustMoney("12.50", "USD"))
+	if err := catalog.CreateProduct(ctx, product); err != nil {
+		t.Fatalf("CreateProduct() error = %v", err)
+	}
+
+	// The caller's name and price are replaced by the catalog's
+	order := &domain.Order{
+		CustomerID: "CUST-001",
+		Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Cheap", Quantity: 2, UnitPrice: domain.MustMoney("0.01", "USD")}},
+	}
+	if err := orders.CreateOrder(ctx, order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if order.Items[0].ProductName != "Widget" || order.TotalAmount != domain.MustMoney("25.00", "USD") {
+		t.Fatalf("order = %q totalling %v, want Widget totalling 25.00 USD", order.Items[0].ProductName, order.TotalAmount)
+	}
+
+	newPrice := domain.MustMoney("20.00", "USD")
+	if _, err := catalog.UpdateProduct(ctx, "SKU-1", ProductUpdate{Price: &newPrice, Status: domain.ProductDiscontinued}); err != nil {
+		t.Fatalf("UpdateProduct() error = %v", err)
+	}
+	stored, err := orders.GetOrder(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if stored.Items[0].UnitPrice != domain.MustMoney("12.50", "USD") {
+		t.Errorf("unit price after catalog change = %v, want the 12.50 USD snapshot", stored.Items[0].UnitPrice)
+	}
+
+	for _, sku := range []string{"SKU-1", "SKU-404"} {
+		order := &domain.Order{CustomerID: "CUST-001", Items: []domain.LineItem{{ProductID: sku, Quantity: 1}}}
+		if err := orders.CreateOrder(ctx, order); !errors.Is(err, ErrInvalidOrder) {
+			t.Errorf("CreateOrder() with %s error = %v, want %v", sku, err, ErrInvalidOrder)
+		}
+	}
+}
+
+// TestRestoreReservations restarts a service over a SQLite database and
+// checks the orders that held stock before hold it again.
+func TestRestoreReservations(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "orders.db")
+	repo, err := repository.NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() error = %v", err)
+	}
+	svc := NewOrderService(repo, WithInventory(inventory.NewMemory(10)))
+
+	// Each order holds its quantity of SKU-1 while confirmed
+	create := func(qty int, statuses ...domain.OrderStatus) *domain.Order {
+		t.Helper()
+		order := &domain.Order{
+			CustomerID: "CUST-001",
+			Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: qty, UnitPrice: domain.MustMoney("10.00", "USD")}},
+		}
+		if err := svc.CreateOrder(ctx, order); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		for _, status := range statuses {
+			if _, err := svc.UpdateOrderStatus(ctx, order.ID, StatusUpdate{Status: status}); err != nil {
+				t.Fatalf("UpdateOrderStatus(%s) error = %v", status, err)
+			}
+		}
+		return order
+	}
+	confirmed := create(2, domain.StatusConfirmed)
+	create(1, domain.StatusConfirmed, domain.StatusShipped)
+	create(4)
+	create(1, domain.StatusConfirmed, domain.StatusCancelled)
+	deleted := create(1, domain.StatusConfirmed)
+	if err := svc.DeleteOrder(ctx, deleted.ID); err != nil {
+		t.Fatalf("DeleteOrder() error = %v", err)
+	}
+	repo.Close()
+
+	repo, err = repository.NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() reopen error = %v", err)
+	}
+	defer repo.Close()
+	stock := inventory.NewMemory(10)
+	svc = NewOrderService(repo, WithInventory(stock))
+
+	for i := 0; i < 2; i++ {
+		held, err := svc.RestoreReservations(ctx)
+		if err != nil {
+			t.Fatalf("RestoreReservations() error = %v", err)
+		}
+		if held != 2 {
+			t.Errorf("RestoreReservations() = %d, want the confirmed and the shipped order", held)
+		}
+		if got := stock.Available("SKU-1"); got != 7 {
+			t.Errorf("Available() after RestoreReservations() = %d, want 7", got)
+		}
+	}
+
+	// Restored reservations are released like any other
+	if _, err := svc.UpdateOrderStatus(ctx, confirmed.ID, StatusUpdate{Status: domain.StatusCancelled}); err != nil {
+		t.Fatalf("UpdateOrderStatus(cancelled) error = %v", err)
+	}
+	if got := stock.Available("SKU-1"); got != 9 {
+		t.Errorf("Available() after cancelling = %d, want 9", got)
+	}
+}
+
+// TestConfirmReservesStock checks confirming reserves stock, a confirm
+// without enough stock leaves the order pending, cancelling releases the
+// stock, and a failed atomic batch gives back what it reserved.
+func TestConfirmReservesStock(t *testing.T) {
+	ctx := context.Background()
+	stock := inventory.NewMemory(0)
+	stock.SetLevel("SKU-1", 3)
+	svc := NewOrderService(repository.NewMemoryRepository(), WithInventory(stock))
+
+	create := func(qty int) *domain.Order {
+		order := &domain.Order{
+			CustomerID: "CUST-001",
+			Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: qty, UnitPrice: domain.MustMoney("10.00", "USD")}},
+		}
+		if err := svc.CreateOrder(ctx, order); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		return order
+	}
+	first, second := create(2), create(2)
+
+	if _, err := svc.UpdateOrderStatus(ctx, first.ID, StatusUpdate{Status: domain.StatusConfirmed}); err != nil {
+		t.Fatalf("UpdateOrderStatus(confirmed) error = %v", err)
+	}
+	if got := stock.Available("SKU-1"); got != 1 {
+		t.Fatalf("Available() after confirm = %d, want 1", got)
+	}
+
+	_, err := svc.UpdateOrderStatus(ctx, second.ID, StatusUpdate{Status: domain.StatusConfirmed})
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("UpdateOrderStatus(confirmed) without stock error = %v, want %v", err, ErrInsufficientStock)
+	}
+	if order, _ := svc.GetOrder(ctx, second.ID); order.Status != domain.StatusPending {
+		t.Fatalf("order status after failed confirm = %s, want pending", order.Status)
+	}
+
+	// A stale version fails the write, so the reservation must be undone
+	stock.SetLevel("SKU-1", 5)
+	_, err = svc.UpdateOrderStatus(ctx, second.ID, StatusUpdate{Status: domain.StatusConfirmed, ExpectedVersion: 7})
+	if !errors.Is(err, repository.ErrVersionConflict) {
+		t.Fatalf("UpdateOrderStatus() with stale version error = %v, want %v", err, repository.ErrVersionConflict)
+	}
+	if got := stock.Available("SKU-1"); got != 3 {
+		t.Fatalf("Available() after failed confirm = %d, want 3", got)
+	}
+
+	if _, err := svc.UpdateOrderStatus(ctx, first.ID, StatusUpdate{Status: domain.StatusCancelled}); err != nil {
+		t.Fatalf("UpdateOrderStatus(cancelled) error = %v", err)
+	}
+	if got := stock.Available("SKU-1"); got != 5 {
+		t.Fatalf("Available() after cancel = %d, want 5", got)
+	}
+
+	// The second item lacks stock, so the first item's reservation is undone
+	third := create(4)
+	results, err := svc.BatchUpdateStatus(ctx, []StatusUpdateItem{
+		{ID: second.ID, StatusUpdate: StatusUpdate{Status: domain.StatusConfirmed}},
+		{ID: third.ID, StatusUpdate: StatusUpdate{Status: domain.StatusConfirmed}},
+	}, true)
+	if err != nil {
+		t.Fatalf("BatchUpdateStatus() error = %v", err)
+	}
+	if !errors.Is(results[1].Err, ErrInsufficientStock) || !errors.Is(results[0].Err, ErrBatchAborted) {
+		t.Fatalf("batch errors = %v, %v; want aborted, insufficient stock", results[0].Err, results[1].Err)
+	}
+	if got := stock.Available("SKU-1"); got != 5 {
+		t.Fatalf("Available() after failed batch = %d, want 5", got)
+	}
+}
+
+// TestDeleteOrderReleasesStock checks deleting a confirmed order releases
+// its stock and restoring it reserves the stock again, if there is enough.
+func TestDeleteOrderReleasesStock(t *testing.T) {
+	ctx := context.Background()
+	stock := inventory.NewMemory(0)
+	stock.SetLevel("SKU-1", 3)
+	svc := NewOrderService(repository.NewMemoryRepository(), WithInventory(stock))
+
+	create := func() *domain.Order {
+		order := &domain.Order{
+			CustomerID: "CUST-001",
+			Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 2, UnitPrice: domain.MustMoney("10.00", "USD")}},
+		}
+		if err := svc.CreateOrder(ctx, order); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		if _, err := svc.UpdateOrderStatus(ctx, order.ID, StatusUpdate{Status: domain.StatusConfirmed}); err != nil {
+			t.Fatalf("UpdateOrderStatus(confirmed) error = %v", err)
+		}
+		return order
+	}
+	first := create()
+
+	if err := svc.DeleteOrder(ctx, first.ID); err != nil {
+		t.Fatalf("DeleteOrder() error = %v", err)
+	}
+	if got := stock.Available("SKU-1"); got != 3 {
+		t.Fatalf("Available() after delete = %d, want 3", got)
+	}
+	if _, err := svc.RestoreOrder(ctx, first.ID); err != nil {
+		t.Fatalf("RestoreOrder() error = %v", err)
+	}
+	if got := stock.Available("SKU-1"); got != 1 {
+		t.Fatalf("Available() after restore = %d, want 1", got)
+	}
+
+	// While it is deleted, its stock goes to another order
+	if err := svc.DeleteOrder(ctx, first.ID); err != nil {
+		t.Fatalf("DeleteOrder() error = %v", err)
+	}
+	create()
+	if _, err := svc.RestoreOrder(ctx, first.ID); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("RestoreOrder() without stock error = %v, want %v", err, ErrInsufficientStock)
+	}
+	if _, err := svc.GetOrder(ctx, first.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("GetOrder() after failed restore error = %v, want %v", err, repository.ErrNotFound)
+	}
+	if got := stock.Available("SKU-1"); got != 1 {
+		t.Fatalf("Available() after failed restore = %d, want 1", got)
+	}
+}
+
+// TestCreateOrderAppliesPricing checks new and edited orders are priced by
+// the pipeline and unknown coupons are rejected.
+func TestCreateOrderAppliesPricing(t *testing.T) {
+	ctx := context.Background()
+	pipeline := pricing.NewPipeline(
+		[]pricing.Rule{pricing.Coupons{Name: "coupons", Codes: map[string]pricing.Coupon{"SAVE10": {BasisPoints: 1000}}}},
+		[]pricing.Rule{pricing.RegionTax{Name: "tax", Rates: map[string]int64{"DE": 1900}}},
+	)
+	svc := NewOrderService(repository.NewMemoryRepository(), WithPricing(pipeline))
+
+	order := &domain.Order{
+		CustomerID: "CUST-001",
+		Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 2, UnitPrice: domain.MustMoney("50.00", "USD")}},
+		CouponCode: "save10",
+		Region:     "DE",
+	}
+	if err := svc.CreateOrder(ctx, order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	// 100.00 - 10% = 90.00, + 19% tax = 107.10
+	if order.TotalAmount != domain.MustMoney("107.10", "USD") {
+		t.Errorf("TotalAmount = %v, want 107.10 USD", order.TotalAmount)
+	}
+	breakdown, err := svc.CalculateOrderTotal(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("CalculateOrderTotal() error = %v", err)
+	}
+	if breakdown.Subtotal != domain.MustMoney("100.00", "USD") || breakdown.Tax != domain.MustMoney("17.10", "USD") || breakdown.Total != order.TotalAmount {
+		t.Errorf("CalculateOrderTotal() = %+v, want subtotal 100.00, tax 17.10, total 107.10", breakdown)
+	}
+
+	updated, err := svc.UpdateOrderItems(ctx, order.ID, []domain.ItemEdit{{Op: domain.ItemEditSetQuantity, ProductID: "SKU-1", Quantity: 1}}, 0)
+	if err != nil {
+		t.Fatalf("UpdateOrderItems() error = %v", err)
+	}
+	if updated.TotalAmount != domain.MustMoney("53.55", "USD") || updated.Pricing == nil {
+		t.Errorf("TotalAmount after edit = %v, want 53.55 USD with a breakdown", updated.TotalAmount)
+	}
+
+	order = &domain.Order{CustomerID: "CUST-001", Items: order.Items, CouponCode: "BOGUS"}
+	if err := svc.CreateOrder(ctx, order); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("CreateOrder() with unknown coupon error = %v, want %v", err, ErrInvalidOrder)
+	}
+}
+
+func TestExportOrdersPages(t *testing.T) {
+	ctx := context.Background()
+	svc := NewOrderService(repository.NewMemoryRepository())
+	want := exportPageSize + 1
+	for i := 0; i < want; i++ {
+		order := &domain.Order{
+			CustomerID: "CUST-001",
+			Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 1, UnitPrice: domain.MustMoney("10.00", "USD")}},
+		}
+		if err := svc.CreateOrder(ctx, order); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	err := svc.ExportOrders(ctx, repository.ListFilter{}, func(order *domain.Order) error {
+		if seen[order.ID] {
+			t.Fatalf("order %s exported twice", order.ID)
+		}
+		seen[order.ID] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportOrders() error = %v", err)
+	}
+	if len(seen) != want {
+		t.Fatalf("ExportOrders() exported %d orders, want %d", len(seen), want)
+	}
+
+	stop := errors.New("stop")
+	if err := svc.ExportOrders(ctx, repository.ListFilter{}, func(*domain.Order) error { return stop }); !errors.Is(err, stop) {
+		t.Fatalf("ExportOrders() error = %v, want the callback's error", err)
+	}
+}
+
+func TestImportOrderDryRun(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	svc := NewOrderService(repo)
+	newOrder := func() *domain.Order {
+		return &domain.Order{
+			CustomerID: "CUST-001",
+			Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 2, UnitPrice: domain.MustMoney("10.00", "USD")}},
+		}
+	}
+
+	dry := newOrder()
+	if err := svc.ImportOrder(ctx, dry, true); err != nil {
+		t.Fatalf("ImportOrder(dry run) error = %v", err)
+	}
+	if want := domain.MustMoney("20.00", "USD"); dry.TotalAmount != want {
+		t.Fatalf("dry run TotalAmount = %v, want %v", dry.TotalAmount, want)
+	}
+	if orders, _ := repo.GetAll(ctx); len(orders) != 0 {
+		t.Fatalf("%d orders stored after dry run, want 0", len(orders))
+	}
+
+	if err := svc.ImportOrder(ctx, &domain.Order{CustomerID: "CUST-001"}, true); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("ImportOrder(no items) error = %v, want ErrInvalidOrder", err)
+	}
+
+	order := newOrder()
+	if err := svc.ImportOrder(ctx, order, false); err != nil {
+		t.Fatalf("ImportOrder() error = %v", err)
+	}
+	if _, err := svc.GetOrder(ctx, order.ID); err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"lab10/internal/repository"
+)
+
+// DefaultPurgeInterval is how often the Purger looks for expired deletes.
+const DefaultPurgeInterval = time.Hour
+
+// Purger permanently removes soft-deleted orders once they have been deleted
+// for longer than the retention period.
+type Purger struct {
+	repo      repository.OrderRepository
+	retention time.Duration
+	interval  time.Duration
+}
+
+// PurgerOption configures optional Purger settings.
+type PurgerOption func(*Purger)
+
+// WithPurgeInterval sets how often the purger runs.
+func WithPurgeInterval(d time.Duration) PurgerOption {
+	return func(p *Purger) {
+		p.interval = d
+	}
+}
+
+// NewPurger creates a purger that removes orders deleted more than retention ago.
+func NewPurger(repo repository.OrderRepository, retention time.Duration, opts ...PurgerOption) *Purger {
+	p := &Purger{
+		repo:      repo,
+		retention: retention,
+		interval:  DefaultPurgeInterval,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// PurgeOnce removes every order whose retention has expired and returns how many were removed.
+func (p *Purger) PurgeOnce(ctx context.Context) (int, error) {
+	return p.repo.Purge(ctx, time.Now().Add(-p.retention))
+}
+
+// Run purges immediately and then every interval until ctx is cancelled.
+// Call it in its own goroutine.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.PurgeOnce(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("Order purge: %v", err)
+		case purged > 0:
+			log.Printf("Order purge: permanently removed %d deleted orders", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
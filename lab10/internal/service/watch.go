@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"lab10/internal/domain"
+)
+
+// watchBufferSize is how many events a watcher may fall behind before it is disconnected.
+const watchBufferSize = 256
+
+// ErrWatchUnavailable indicates the service was built without an event source.
+var ErrWatchUnavailable = errors.New("order watching is not available")
+
+// EventSubscriber is a source of published order events, such as
+// events.InProcessPublisher. The handler must not block.
+type EventSubscriber interface {
+	Subscribe(handler func(domain.Event)) (unsubscribe func())
+}
+
+// WatchFilter selects which order events a watcher receives.
+// Zero values mean "no filter".
+type WatchFilter struct {
+	CustomerID string
+	OrderID    string
+}
+
+// Matches reports whether the event passes the filter.
+func (f WatchFilter) Matches(event domain.Event) bool {
+	if f.OrderID != "" && event.OrderID != f.OrderID {
+		return false
+	}
+	if f.CustomerID != "" && (event.Order == nil || event.Order.CustomerID != f.CustomerID) {
+		return false
+	}
+	return true
+}
+
+// WatchOrders streams order events matching filter as they are published.
+// The channel is closed when ctx is done. It is also closed if the consumer
+// falls more than watchBufferSize events behind - rather than silently
+// skipping events - so a channel closed while ctx is still active means the
+// caller missed events and should re-read state before watching again.
+// Events published more than once (delivery is at-least-once) are passed on
+// only the first time.
+func (s *OrderService) WatchOrders(ctx context.Context, filter WatchFilter) (<-chan domain.Event, error) {
+	if s.subscriber == nil {
+		return nil, ErrWatchUnavailable
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	ch := make(chan domain.Event, watchBufferSize)
+	var overflowed atomic.Bool
+
+	// Events are published in order, so one seen before has an ID <= lastID
+	var lastID atomic.Int64
+	unsubscribe := s.subscriber.Subscribe(func(event domain.Event) {
+		if overflowed.Load() || event.ID <= lastID.Load() {
+			return
+		}
+		lastID.Store(event.ID)
+		if !filter.Matches(event) {
+			return
+		}
+		select {
+		case ch <- event:
+		default:
+			overflowed.Store(true)
+			cancel()
+		}
+	})
+
+	go func() {
+		<-ctx.Done()
+		// After unsubscribe returns the handler is never called again, so closing is safe
+		unsubscribe()
+		close(ch)
+	}()
+	return ch, nil
+}
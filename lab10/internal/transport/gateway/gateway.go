@@ -0,0 +1,164 @@
+// Package gateway serves the REST API defined by the google.api.http
+// annotations in orders.proto. Requests are translated to calls on the gRPC
+// servers in-process, so both transports share one definition and one set of
+// request mapping, validation and error handling.
+//
+// orders.swagger.json is generated from the same annotations by `make proto`.
+package gateway
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+	"net/textproto"
+	"net/url"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	grpcTransport "lab10/internal/transport/grpc"
+	pb "lab10/proto/orders"
+)
+
+// openAPI is the OpenAPI (Swagger 2.0) document of the REST API.
+//
+//go:embed orders.swagger.json
+var openAPI []byte
+
+// forwardedHeaders are the HTTP request headers passed to the gRPC servers as
+// metadata under their lower-case name, and the response metadata returned
+// as headers, in addition to the gateway's defaults.
+var forwardedHeaders = map[string]bool{
+	"Idempotency-Key":     true,
+	"Idempotent-Replayed": true,
+	"If-Match":            true,
+}
+
+// NewHandler returns a handler serving the REST API backed by the given
+// gRPC servers. JSON field names are the proto field names (snake_case),
+// like the rest of the HTTP API. Path parameters may contain an escaped
+// slash (%2F), so IDs with slashes can be addressed.
+//
+// Responses holding an order, customer or product carry its version as
+// an ETag, and updates take an If-Match header in place of
+// expected_version. A version conflict is answered with 412 Precondition
+// Failed. CreateOrder answers 201 Created with the order's URL in
+// Location, replays of an idempotent create included. Errors are answered with RFC 7807 problem details (see Problem).
+func NewHandler(ctx context.Context, orders pb.OrderServiceServer, customers pb.CustomerServiceServer, products pb.ProductServiceServer) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(matchHeader(runtime.DefaultHeaderMatcher)),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+		runtime.WithUnescapingMode(runtime.UnescapingModeAllExceptReserved),
+		runtime.WithRoutingErrorHandler(routingError),
+		runtime.WithErrorHandler(handleError),
+		// setCreated writes the status line, so it must come last
+		runtime.WithForwardResponseOption(setETag),
+		runtime.WithForwardResponseOption(setCreated),
+	)
+
+	if err := pb.RegisterOrderServiceHandlerServer(ctx, mux, orders); err != nil {
+		return nil, err
+	}
+	if err := pb.RegisterCustomerServiceHandlerServer(ctx, mux, customers); err != nil {
+		return nil, err
+	}
+	if err := pb.RegisterProductServiceHandlerServer(ctx, mux, products); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// HTTPStatus returns the HTTP status the REST API answers st with: the
+// gateway's default for its code, except that a version conflict gets a
+// 412 Precondition Failed like any failed If-Match.
+func HTTPStatus(st *status.Status) int {
+	if grpcTransport.ErrorReason(st) == pb.ErrorReason_VERSION_CONFLICT.String() {
+		return http.StatusPreconditionFailed
+	}
+	return runtime.HTTPStatusFromCode(st.Code())
+}
+
+// setETag sets the ETag header of responses holding a versioned resource.
+func setETag(ctx context.Context, w http.ResponseWriter, resp proto.Message) error {
+	if version := responseVersion(resp); version > 0 {
+		w.Header().Set("ETag", grpcTransport.ETag(version))
+	}
+	return nil
+}
+
+// setCreated answers CreateOrder with 201 Created and the new order's URL,
+// under the path the order was created at (/v1/orders or /orders), in the
+// Location header.
+func setCreated(ctx context.Context, w http.ResponseWriter, resp proto.Message) error {
+	created, ok := resp.(*pb.CreateOrderResponse)
+	if !ok {
+		return nil
+	}
+	collection, _ := runtime.HTTPPathPattern(ctx)
+	w.Header().Set("Location", collection+"/"+url.PathEscape(created.GetOrder().GetId()))
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// responseVersion returns the version of the order, customer or product in
+// resp, or 0 if it holds none.
+func responseVersion(resp proto.Message) int64 {
+	switch resp := resp.(type) {
+	case *pb.UpdateOrderStatusResponse:
+		return resp.GetVersion()
+	case interface{ GetOrder() *pb.Order }:
+		return resp.GetOrder().GetVersion()
+	case interface{ GetCustomer() *pb.Customer }:
+		return resp.GetCustomer().GetVersion()
+	case interface{ GetProduct() *pb.Product }:
+		return resp.GetProduct().GetVersion()
+	}
+	return 0
+}
+
+// routingError answers requests that match no route like the default
+// handler, except that a wrong method gets a 405 instead of a 501.
+func routingError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+	if httpStatus == http.StatusMethodNotAllowed {
+		err := &runtime.HTTPStatusError{
+			HTTPStatus: httpStatus,
+			Err:        status.Error(codes.Unimplemented, http.StatusText(httpStatus)),
+		}
+		runtime.HTTPError(ctx, mux, marshaler, w, r, err)
+		return
+	}
+	runtime.DefaultRoutingErrorHandler(ctx, mux, marshaler, w, r, httpStatus)
+}
+
+// matchHeader passes forwardedHeaders through under their own name and
+// leaves every other header to fallback.
+func matchHeader(fallback runtime.HeaderMatcherFunc) runtime.HeaderMatcherFunc {
+	return func(key string) (string, bool) {
+		if canonical := textproto.CanonicalMIMEHeaderKey(key); forwardedHeaders[canonical] {
+			return canonical, true
+		}
+		return fallback(key)
+	}
+}
+
+// outgoingHeader returns the HTTP header response metadata is returned as.
+var outgoingHeader = matchHeader(func(key string) (string, bool) {
+	return runtime.MetadataHeaderPrefix + key, true
+})
+
+// OpenAPIHandler serves the OpenAPI document of the REST API.
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPI)
+}
@@ -0,0 +1,261 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lab10/internal/repository"
+	"lab10/internal/service"
+	grpcTransport "lab10/internal/transport/grpc"
+)
+
+// testOrder is a CreateOrder request body for a valid order.
+const testOrder = `{"customer_id": "CUST-1", "items": [{"product_id": "SKU-1", "product_name": "Widget", "quantity": 1, "price": {"currency_code": "USD", "units": "10"}}]}`
+
+// newTestHandler returns the REST gateway over an empty in-memory repository.
+func newTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+	repo := repository.NewMemoryRepository()
+	orders := service.NewOrderService(repo)
+	handler, err := NewHandler(context.Background(),
+		grpcTransport.NewOrderServer(orders),
+		grpcTransport.NewCustomerServer(service.NewCustomerService(repo, orders)),
+		grpcTransport.NewProductServer(service.NewCatalogService(repo)),
+	)
+	if err != nil {
+		t.Fatalf("NewHandler() error = %v", err)
+	}
+	return handler
+}
+
+// serve sends a request with the given body and header name/value pairs.
+func serve(handler http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// createOrder creates testOrder and returns the response and the order's ID.
+func createOrder(t *testing.T, handler http.Handler, header ...string) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	rec := serve(handler, "POST", "/v1/orders", testOrder, header...)
+	var created struct {
+		Order struct {
+			ID string `json:"id"`
+		} `json:"order"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.Order.ID == "" {
+		t.Fatalf("POST /v1/orders = %d %s, want an order", rec.Code, rec.Body)
+	}
+	return rec, created.Order.ID
+}
+
+// TestConditionalUpdate checks orders carry their version as an ETag and
+// that If-Match and expected_version make updates conditional on it.
+func TestConditionalUpdate(t *testing.T) {
+	handler := newTestHandler(t)
+	_, id := createOrder(t, handler)
+	target := "/v1/orders/" + id
+
+	rec := serve(handler, "GET", target, "")
+	if got := rec.Header().Get("ETag"); got != `"1"` {
+		t.Fatalf("GET ETag = %q, want %q", got, `"1"`)
+	}
+
+	// Other updates take If-Match too
+	rec = serve(handler, "PATCH", target, `{"region": "EU"}`, "If-Match", `"2"`)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("PATCH order with a stale If-Match = %d (body %s), want 412", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		header     []string
+		wantStatus int
+		wantETag   string
+	}{
+		{"stale If-Match", `{"status": "CONFIRMED"}`, []string{"If-Match", `"2"`}, http.StatusPreconditionFailed, ""},
+		{"stale expected_version", `{"status": "CONFIRMED", "expected_version": 2}`, nil, http.StatusPreconditionFailed, ""},
+		{"expected_version wins", `{"status": "CONFIRMED", "expected_version": 2}`, []string{"If-Match", `"1"`}, http.StatusPreconditionFailed, ""},
+		{"invalid If-Match", `{"status": "CONFIRMED"}`, []string{"If-Match", "1"}, http.StatusBadRequest, ""},
+		{"If-Match", `{"status": "CONFIRMED"}`, []string{"If-Match", `"1"`}, http.StatusOK, `"2"`},
+		{"any version", `{"status": "SHIPPED"}`, []string{"If-Match", "*"}, http.StatusOK, `"3"`},
+		{"weak If-Match", `{"status": "DELIVERED"}`, []string{"If-Match", `W/"3"`}, http.StatusOK, `"4"`},
+	}
+	for _, tt := range tests {
+		rec := serve(handler, "PATCH", target+"/status", tt.body, tt.header...)
+		if rec.Code != tt.wantStatus {
+			t.Fatalf("%s: status = %d (body %s), want %d", tt.name, rec.Code, rec.Body, tt.wantStatus)
+		}
+		if got := rec.Header().Get("ETag"); got != tt.wantETag {
+			t.Errorf("%s: ETag = %q, want %q", tt.name, got, tt.wantETag)
+		}
+	}
+}
+
+// TestProblemDetails checks errors are answered with RFC 7807 problem
+// details that keep the google.rpc.Status of the error.
+func TestProblemDetails(t *testing.T) {
+	handler := newTestHandler(t)
+	_, id := createOrder(t, handler)
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		wantStatus     int
+		wantTitle      string
+		wantCode       int
+		wantViolations []string // fields
+	}{
+		{"invalid order", "POST", "/v1/orders", `{"items": [{"product_id": "SKU-1", "quantity": 0}]}`, http.StatusBadRequest, "Bad Request", 3,
+			[]string{"customer_id", "items[0].product_name", "items[0].quantity"}},
+		{"unknown order", "GET", "/v1/orders/nothing", "", http.StatusNotFound, "Not Found", 5, nil},
+		{"version conflict", "PATCH", "/v1/orders/" + id + "/status", `{"status": "CONFIRMED", "expected_version": 7}`, http.StatusPreconditionFailed, "Precondition Failed", 9, nil},
+		{"unknown route", "GET", "/v1/nothing", "", http.StatusNotFound, "Not Found", 5, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(handler, tt.method, tt.target, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d (body %s), want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want application/problem+json", got)
+			}
+
+			var problem struct {
+				Problem
+				Details []map[string]interface{} `json:"details"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("body %s: %v", rec.Body, err)
+			}
+			if problem.Type != "about:blank" || problem.Title != tt.wantTitle || problem.Status != tt.wantStatus {
+				t.Errorf("problem = %+v, want type about:blank, title %q and status %d", problem.Problem, tt.wantTitle, tt.wantStatus)
+			}
+			if problem.Detail == "" || problem.Error != problem.Detail || problem.Message != problem.Detail {
+				t.Errorf("detail %q, error %q, message %q; want the same error message", problem.Detail, problem.Error, problem.Message)
+			}
+			if int(problem.Code) != tt.wantCode {
+				t.Errorf("code = %d, want %d", problem.Code, tt.wantCode)
+			}
+			var fields []string
+			for _, v := range problem.Violations {
+				fields = append(fields, v.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantViolations, ",") {
+				t.Errorf("violations = %+v, want fields %q", problem.Violations, tt.wantViolations)
+			}
+			if tt.wantViolations != nil && len(problem.Details) != 2 {
+				t.Errorf("details = %v, want ErrorInfo and BadRequest", problem.Details)
+			}
+		})
+	}
+}
+
+// TestCreateOrderCreated checks CreateOrder answers 201 Created with the
+// order's URL, for a replayed idempotent create too.
+func TestCreateOrderCreated(t *testing.T) {
+	handler := newTestHandler(t)
+
+	rec, id := createOrder(t, handler, "Idempotency-Key", "create-1")
+	replay, replayID := createOrder(t, handler, "Idempotency-Key", "create-1")
+	if replayID != id || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay created %q (Idempotent-Replayed %q), want %q replayed", replayID, replay.Header().Get("Idempotent-Replayed"), id)
+	}
+	for name, rec := range map[string]*httptest.ResponseRecorder{"create": rec, "replay": replay} {
+		if rec.Code != http.StatusCreated {
+			t.Errorf("%s: status = %d, want 201", name, rec.Code)
+		}
+		if got, want := rec.Header().Get("Location"), "/v1/orders/"+id; got != want {
+			t.Errorf("%s: Location = %q, want %q", name, got, want)
+		}
+		if got := rec.Header().Get("ETag"); got != `"1"` {
+			t.Errorf("%s: ETag = %q, want %q", name, got, `"1"`)
+		}
+	}
+	if rec := serve(handler, "GET", rec.Header().Get("Location"), ""); rec.Code != http.StatusOK {
+		t.Errorf("GET Location = %d (body %s), want the order", rec.Code, rec.Body)
+	}
+
+	// Without the version prefix, the Location has none either
+	rec = serve(handler, "POST", "/orders", testOrder)
+	if rec.Code != http.StatusCreated || !strings.HasPrefix(rec.Header().Get("Location"), "/orders/") {
+		t.Errorf("POST /orders = %d with Location %q, want 201 with /orders/{id}", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+// TestUpdateLineItems checks PATCH .../items applies all operations as one
+// update, or none of them.
+func TestUpdateLineItems(t *testing.T) {
+	handler := newTestHandler(t)
+	_, id := createOrder(t, handler)
+
+	type order struct {
+		Version string `json:"version"`
+		Items   []struct {
+			ProductID string `json:"product_id"`
+			Quantity  int    `json:"quantity"`
+		} `json:"items"`
+	}
+	get := func(target string) order {
+		t.Helper()
+		var resp struct {
+			Order order `json:"order"`
+		}
+		rec := serve(handler, "GET", target, "")
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("GET %s = %d %s", target, rec.Code, rec.Body)
+		}
+		return resp.Order
+	}
+
+	body := `{"operations": [
+		{"op": "LINE_ITEM_ADD", "item": {"product_id": "SKU-2", "product_name": "Gadget", "quantity": 1, "price": {"currency_code": "USD", "units": "5"}}},
+		{"op": "LINE_ITEM_SET_QUANTITY", "product_id": "SKU-2", "quantity": 3},
+		{"op": "LINE_ITEM_ADD", "item": {"product_id": "SKU-3", "product_name": "Gizmo", "quantity": 1, "price": {"currency_code": "USD", "units": "1"}}},
+		{"op": "LINE_ITEM_REMOVE", "product_id": "SKU-1"}
+	]}`
+	rec := serve(handler, "PATCH", "/v1/orders/"+id+"/items", body, "If-Match", `"1"`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH items = %d (body %s), want 200", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("ETag = %q, want one version bump to %q", got, `"2"`)
+	}
+	got := get("/v1/orders/" + id)
+	if got.Version != "2" || len(got.Items) != 2 || got.Items[0].ProductID != "SKU-2" || got.Items[0].Quantity != 3 || got.Items[1].ProductID != "SKU-3" {
+		t.Errorf("order after PATCH items = %+v, want version 2 with SKU-2 x3 and SKU-3", got)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no operations", `{"operations": []}`},
+		{"unknown op", `{"operations": [{"op": "LINE_ITEM_OP_UNSPECIFIED", "product_id": "SKU-2"}]}`},
+		{"add without item", `{"operations": [{"op": "LINE_ITEM_ADD"}]}`},
+		{"failing last operation", `{"operations": [{"op": "LINE_ITEM_SET_QUANTITY", "product_id": "SKU-2", "quantity": 5}, {"op": "LINE_ITEM_REMOVE", "product_id": "SKU-1"}]}`},
+	}
+	for _, tt := range tests {
+		// Through the unversioned path too
+		rec := serve(handler, "PATCH", "/orders/"+id+"/items", tt.body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d (body %s), want 400", tt.name, rec.Code, rec.Body)
+		}
+	}
+	if after := get("/orders/" + id); after.Version != "2" || after.Items[0].Quantity != 3 {
+		t.Errorf("order after failed edits = %+v, want it unchanged", after)
+	}
+}
@@ -0,0 +1,127 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"lab10/internal/domain"
+)
+
+// Problem is the RFC 7807 problem details body of REST API errors, sent as
+// application/problem+json. Violations lists every invalid field of a
+// rejected request. The google.rpc.Status of the error is kept as the code,
+// message and details extension members, so clients can read the gRPC
+// error details, and Error repeats Detail for clients that read the plain
+// {"error": "..."} body of earlier versions.
+type Problem struct {
+	Type       string                  `json:"type"`
+	Title      string                  `json:"title"`
+	Status     int                     `json:"status"`
+	Detail     string                  `json:"detail,omitempty"`
+	Violations []domain.FieldViolation `json:"violations,omitempty"`
+	Error      string                  `json:"error"`
+
+	Code    int32           `json:"code"`
+	Message string          `json:"message"`
+	Details json.RawMessage `json:"details"`
+}
+
+// fallbackProblem is written when a Problem can't be marshaled.
+const fallbackProblem = `{"type": "about:blank", "title": "Internal Server Error", "status": 500, "error": "failed to marshal error message", "code": 13, "message": "failed to marshal error message", "details": []}`
+
+// statusJSON marshals google.rpc.Status messages like the gateway's marshaler.
+var statusJSON = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+// newProblem returns the problem details of st, answered with httpStatus.
+func newProblem(st *status.Status, httpStatus int) (*Problem, error) {
+	data, err := statusJSON.Marshal(st.Proto())
+	if err != nil {
+		return nil, err
+	}
+	var details struct {
+		Details json.RawMessage `json:"details"`
+	}
+	if err := json.Unmarshal(data, &details); err != nil {
+		return nil, err
+	}
+
+	title := http.StatusText(httpStatus)
+	if title == "" {
+		title = st.Code().String()
+	}
+	return &Problem{
+		Type:       "about:blank",
+		Title:      title,
+		Status:     httpStatus,
+		Detail:     st.Message(),
+		Violations: fieldViolations(st),
+		Error:      st.Message(),
+		Code:       int32(st.Code()),
+		Message:    st.Message(),
+		Details:    details.Details,
+	}, nil
+}
+
+// fieldViolations returns the field violations of the BadRequest details of st.
+func fieldViolations(st *status.Status) []domain.FieldViolation {
+	var violations []domain.FieldViolation
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range badRequest.GetFieldViolations() {
+				violations = append(violations, domain.FieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+	return violations
+}
+
+// WriteProblem writes st as a problem details body with the given status code.
+func WriteProblem(w http.ResponseWriter, st *status.Status, httpStatus int) {
+	var data []byte
+	problem, err := newProblem(st, httpStatus)
+	if err == nil {
+		data, err = json.Marshal(problem)
+	}
+	if err != nil {
+		httpStatus = http.StatusInternalServerError
+		data = []byte(fallbackProblem)
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(httpStatus)
+	w.Write(data)
+}
+
+// handleError writes errors as problem details, with the status HTTPStatus
+// gives them unless the gateway chose one, and with the header metadata the
+// gRPC server sent.
+func handleError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	var httpStatus int
+	var statusErr *runtime.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		httpStatus, err = statusErr.HTTPStatus, statusErr.Err
+	}
+	st := status.Convert(err)
+	if httpStatus == 0 {
+		httpStatus = HTTPStatus(st)
+	}
+
+	w.Header().Del("Trailer")
+	w.Header().Del("Transfer-Encoding")
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		for key, values := range md.HeaderMD {
+			if name, ok := outgoingHeader(key); ok {
+				for _, value := range values {
+					w.Header().Add(name, value)
+				}
+			}
+		}
+	}
+	WriteProblem(w, st, httpStatus)
+}
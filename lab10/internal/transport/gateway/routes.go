@@ -0,0 +1,73 @@
+package gateway
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+
+	pb "lab10/proto/orders"
+)
+
+// Patterns returns the routes of the REST API as http.ServeMux patterns,
+// such as "GET /v1/orders/{id}", read from the google.api.http annotations
+// (including additional_bindings) of the services in orders.proto. A path
+// variable naming a nested field, like {order.id}, becomes the wildcard
+// {order_id}. Only single-segment variables are supported.
+func Patterns() ([]string, error) {
+	var patterns []string
+	services := pb.File_orders_proto.Services()
+	for i := 0; i < services.Len(); i++ {
+		methods := services.Get(i).Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			if !proto.HasExtension(method.Options(), annotations.E_Http) {
+				continue
+			}
+			rule := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+			for _, binding := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+				pattern, err := servePattern(binding)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", method.FullName(), err)
+				}
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	return patterns, nil
+}
+
+// servePattern converts an HTTP rule to an http.ServeMux pattern.
+func servePattern(rule *annotations.HttpRule) (string, error) {
+	var method, path string
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		method, path = "GET", p.Get
+	case *annotations.HttpRule_Post:
+		method, path = "POST", p.Post
+	case *annotations.HttpRule_Put:
+		method, path = "PUT", p.Put
+	case *annotations.HttpRule_Delete:
+		method, path = "DELETE", p.Delete
+	case *annotations.HttpRule_Patch:
+		method, path = "PATCH", p.Patch
+	case *annotations.HttpRule_Custom:
+		method, path = p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return "", fmt.Errorf("HTTP rule without a pattern")
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.ContainsAny(segment, "{}") {
+			continue
+		}
+		name, ok := strings.CutPrefix(segment, "{")
+		if name, ok = strings.CutSuffix(name, "}"); !ok || strings.ContainsAny(name, "{}=*") {
+			return "", fmt.Errorf("unsupported path template %q", path)
+		}
+		segments[i] = "{" + strings.ReplaceAll(name, ".", "_") + "}"
+	}
+	return method + " " + strings.Join(segments, "/"), nil
+}
@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"lab10/internal/auth"
+)
+
+// apiKeyMetadata carries the API key of a call, like the HTTP X-API-Key header.
+const apiKeyMetadata = "x-api-key"
+
+// UnaryAuthInterceptor authenticates every unary call by its x-api-key or
+// authorization (Bearer) metadata and stores the principal in the context
+// (see auth.FromContext). Calls without valid credentials fail with
+// Unauthenticated. If authn has no credentials configured, all calls are
+// let through.
+func UnaryAuthInterceptor(authn *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !authn.Enabled() {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, authn)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor is the streaming counterpart of UnaryAuthInterceptor.
+func StreamAuthInterceptor(authn *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !authn.Enabled() {
+			return handler(srv, ss)
+		}
+		ctx, err := authenticate(ss.Context(), authn)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate checks the credentials in the incoming metadata of ctx and
+// returns ctx with the principal added.
+func authenticate(ctx context.Context, authn *auth.Authenticator) (context.Context, error) {
+	principal, err := authn.Authenticate(firstMetadata(ctx, apiKeyMetadata), firstMetadata(ctx, "authorization"))
+	if err != nil {
+		if errors.Is(err, auth.ErrMissingCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "authentication required")
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	return auth.NewContext(ctx, principal), nil
+}
+
+// firstMetadata returns the first incoming metadata value for key, or "".
+func firstMetadata(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// authenticatedStream overrides the context of a stream with one carrying
+// the caller's principal.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
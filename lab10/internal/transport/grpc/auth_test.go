@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"lab10/internal/auth"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+	pb "lab10/proto/orders"
+)
+
+// contextStream is a server stream that only has a context.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// TestAuthInterceptors runs both interceptors against the same credentials
+// and checks which calls reach the handler, and with which principal.
+func TestAuthInterceptors(t *testing.T) {
+	authn := auth.NewAuthenticator(auth.WithAPIKey("s3cret"), auth.WithHS256Secret("jwt-secret"))
+
+	tests := []struct {
+		name        string
+		md          metadata.MD
+		authn       *auth.Authenticator
+		wantMessage string // of the Unauthenticated error; "" if let through
+		wantSubject string // "" if let through without a principal
+	}{
+		{"API key", metadata.Pairs("x-api-key", "s3cret"), authn, "", auth.APIKeySubject},
+		{"no credentials", metadata.MD{}, authn, "authentication required", ""},
+		{"wrong API key", metadata.Pairs("x-api-key", "guess"), authn, "invalid credentials", ""},
+		{"malformed token", metadata.Pairs("authorization", "Bearer not.a.jwt"), authn, "invalid credentials", ""},
+		{"not a bearer token", metadata.Pairs("authorization", "Basic dXNlcjpwYXNz"), authn, "invalid credentials", ""},
+		{"disabled", metadata.MD{}, auth.NewAuthenticator(), "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+
+			// check verifies the outcome of one interceptor: the error it
+			// returned and the principal its handler saw, if it was called
+			check := func(kind string, called bool, principal *auth.Principal, err error) {
+				t.Helper()
+				if tt.wantMessage != "" {
+					if called || status.Code(err) != codes.Unauthenticated || status.Convert(err).Message() != tt.wantMessage {
+						t.Errorf("%s: handler called %t, error %v; want Unauthenticated %q", kind, called, err, tt.wantMessage)
+					}
+					return
+				}
+				if err != nil || !called {
+					t.Fatalf("%s: handler called %t, error %v; want the call let through", kind, called, err)
+				}
+				var subject string
+				if principal != nil {
+					subject = principal.Subject
+				}
+				if subject != tt.wantSubject {
+					t.Errorf("%s: principal %q, want %q", kind, subject, tt.wantSubject)
+				}
+			}
+
+			var called bool
+			var principal *auth.Principal
+			_, err := UnaryAuthInterceptor(tt.authn)(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+				called = true
+				principal, _ = auth.FromContext(ctx)
+				return nil, nil
+			})
+			check("unary", called, principal, err)
+
+			called, principal = false, nil
+			err = StreamAuthInterceptor(tt.authn)(nil, &contextStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(_ interface{}, ss grpc.ServerStream) error {
+				called = true
+				principal, _ = auth.FromContext(ss.Context())
+				return nil
+			})
+			check("stream", called, principal, err)
+		})
+	}
+}
+
+// TestUpdateOrderStatusActor checks the actor of a status change is the
+// authenticated caller, end to end through the interceptor.
+func TestUpdateOrderStatusActor(t *testing.T) {
+	authn := auth.NewAuthenticator(auth.WithAPIKey("s3cret"))
+	client := newTestClient(t, service.NewOrderService(repository.NewMemoryRepository()),
+		grpc.UnaryInterceptor(UnaryAuthInterceptor(authn)))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "s3cret")
+
+	created, err := client.CreateOrder(ctx, newStreamOrder("CUST-1"))
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	id := created.GetOrder().GetId()
+
+	_, err = client.UpdateOrderStatus(ctx, &pb.UpdateOrderStatusRequest{Id: id, Status: pb.OrderStatus_CONFIRMED, Actor: "someone-else"})
+	if status.Code(err) != codes.PermissionDenied || ErrorReason(status.Convert(err)) != pb.ErrorReason_PERMISSION_DENIED.String() {
+		t.Fatalf("UpdateOrderStatus() for another actor error = %v, want PermissionDenied", err)
+	}
+	if _, err := client.UpdateOrderStatus(ctx, &pb.UpdateOrderStatusRequest{Id: id, Status: pb.OrderStatus_CONFIRMED}); err != nil {
+		t.Fatalf("UpdateOrderStatus() error = %v", err)
+	}
+
+	history, err := client.GetOrderHistory(ctx, &pb.GetOrderHistoryRequest{Id: id})
+	if err != nil {
+		t.Fatalf("GetOrderHistory() error = %v", err)
+	}
+	if changes := history.GetEvents(); len(changes) != 1 || changes[0].GetActor() != auth.APIKeySubject {
+		t.Errorf("history = %v, want one change by %q", changes, auth.APIKeySubject)
+	}
+}
@@ -0,0 +1,177 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+	pb "lab10/proto/orders"
+)
+
+// CustomerServer implements the gRPC CustomerService interface.
+type CustomerServer struct {
+	pb.UnimplementedCustomerServiceServer
+	service *service.CustomerService
+}
+
+// NewCustomerServer creates a new gRPC customer server with injected service.
+func NewCustomerServer(service *service.CustomerService) *CustomerServer {
+	return &CustomerServer{service: service}
+}
+
+// CreateCustomer handles gRPC CreateCustomer requests.
+func (s *CustomerServer) CreateCustomer(ctx context.Context, req *pb.CreateCustomerRequest) (*pb.CreateCustomerResponse, error) {
+	customer := &domain.Customer{
+		ID:     req.GetId(),
+		Name:   req.GetName(),
+		Email:  req.GetEmail(),
+		Status: protoToCustomerStatus(req.GetStatus()),
+	}
+	if err := s.service.CreateCustomer(ctx, customer); err != nil {
+		return nil, mapCustomerError(err)
+	}
+
+	// Re-read to return the stored timestamps
+	created, err := s.service.GetCustomer(ctx, customer.ID)
+	if err != nil {
+		return nil, mapCustomerError(err)
+	}
+	return &pb.CreateCustomerResponse{Customer: customerToProto(created)}, nil
+}
+
+// GetCustomer handles gRPC GetCustomer requests.
+func (s *CustomerServer) GetCustomer(ctx context.Context, req *pb.GetCustomerRequest) (*pb.GetCustomerResponse, error) {
+	customer, err := s.service.GetCustomer(ctx, req.GetId())
+	if err != nil {
+		return nil, mapCustomerError(err)
+	}
+	return &pb.GetCustomerResponse{Customer: customerToProto(customer)}, nil
+}
+
+// UpdateCustomer handles gRPC UpdateCustomer requests.
+func (s *CustomerServer) UpdateCustomer(ctx context.Context, req *pb.UpdateCustomerRequest) (*pb.UpdateCustomerResponse, error) {
+	version, err := expectedVersion(ctx, req.GetExpectedVersion())
+	if err != nil {
+		return nil, err
+	}
+	customer, err := s.service.UpdateCustomer(ctx, req.GetId(), service.CustomerUpdate{
+		Name:            req.GetName(),
+		Email:           req.GetEmail(),
+		Status:          protoToCustomerStatus(req.GetStatus()),
+		ExpectedVersion: version,
+	})
+	if err != nil {
+		return nil, mapCustomerError(err)
+	}
+	return &pb.UpdateCustomerResponse{Customer: customerToProto(customer)}, nil
+}
+
+// ListCustomerOrders handles gRPC ListCustomerOrders requests.
+func (s *CustomerServer) ListCustomerOrders(ctx context.Context, req *pb.ListCustomerOrdersRequest) (*pb.ListOrdersResponse, error) {
+	opts := repository.ListOptions{
+		PageSize:   int(req.GetPageSize()),
+		PageToken:  req.GetPageToken(),
+		SortBy:     repository.SortField(req.GetSortBy()),
+		Descending: req.GetDescending(),
+	}
+	if req.Status != nil {
+		opts.Filter.Status = protoToStatus(req.GetStatus())
+	}
+
+	result, err := s.service.ListCustomerOrders(ctx, req.GetCustomerId(), opts)
+	if err != nil {
+		return nil, mapCustomerError(err)
+	}
+
+	orders := make([]*pb.Order, 0, len(result.Orders))
+	for _, order := range result.Orders {
+		orders = append(orders, OrderToProto(order))
+	}
+	return &pb.ListOrdersResponse{
+		Orders:        orders,
+		NextPageToken: result.NextPageToken,
+	}, nil
+}
+
+// GetCustomerStats handles gRPC GetCustomerStats requests.
+func (s *CustomerServer) GetCustomerStats(ctx context.Context, req *pb.GetCustomerStatsRequest) (*pb.CustomerStats, error) {
+	stats, err := s.service.GetCustomerStats(ctx, req.GetCustomerId())
+	if err != nil {
+		return nil, mapCustomerError(err)
+	}
+
+	resp := &pb.CustomerStats{
+		CustomerId: stats.CustomerID,
+		OrderCount: int32(stats.OrderCount),
+	}
+	for _, value := range stats.LifetimeValue {
+		resp.LifetimeValue = append(resp.LifetimeValue, moneyToProto(value))
+	}
+	if stats.LastOrderAt != nil {
+		resp.LastOrderAt = stats.LastOrderAt.Unix()
+		resp.LastOrderTime = timeToProto(*stats.LastOrderAt)
+	}
+	return resp, nil
+}
+
+// customerToProto converts domain customer to protobuf customer.
+func customerToProto(customer *domain.Customer) *pb.Customer {
+	return &pb.Customer{
+		Id:         customer.ID,
+		Name:       customer.Name,
+		Email:      customer.Email,
+		Status:     customerStatusToProto(customer.Status),
+		CreatedAt:  timeToUnix(customer.CreatedAt),
+		UpdatedAt:  timeToUnix(customer.UpdatedAt),
+		Version:    customer.Version,
+		CreateTime: timeToProto(customer.CreatedAt),
+		UpdateTime: timeToProto(customer.UpdatedAt),
+	}
+}
+
+// customerStatusToProto converts domain customer status to protobuf enum.
+func customerStatusToProto(s domain.CustomerStatus) pb.CustomerStatus {
+	switch s {
+	case domain.CustomerActive:
+		return pb.CustomerStatus_CUSTOMER_ACTIVE
+	case domain.CustomerInactive:
+		return pb.CustomerStatus_CUSTOMER_INACTIVE
+	default:
+		return pb.CustomerStatus_CUSTOMER_STATUS_UNSPECIFIED
+	}
+}
+
+// protoToCustomerStatus converts protobuf enum to domain customer status.
+// Unspecified becomes "", which means "default" or "unchanged" to the service.
+func protoToCustomerStatus(s pb.CustomerStatus) domain.CustomerStatus {
+	switch s {
+	case pb.CustomerStatus_CUSTOMER_ACTIVE:
+		return domain.CustomerActive
+	case pb.CustomerStatus_CUSTOMER_INACTIVE:
+		return domain.CustomerInactive
+	default:
+		return ""
+	}
+}
+
+// mapCustomerError converts customer service errors to gRPC status errors,
+// falling back to MapServiceError for order errors.
+func mapCustomerError(err error) error {
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return reasonError(codes.NotFound, pb.ErrorReason_CUSTOMER_NOT_FOUND, "customer not found")
+	}
+	if errors.Is(err, repository.ErrCustomerAlreadyExists) {
+		return reasonError(codes.AlreadyExists, pb.ErrorReason_CUSTOMER_ALREADY_EXISTS, "customer already exists")
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return reasonError(codes.FailedPrecondition, pb.ErrorReason_VERSION_CONFLICT, "customer was modified by another request")
+	}
+	if errors.Is(err, service.ErrInvalidCustomer) {
+		return reasonError(codes.InvalidArgument, pb.ErrorReason_INVALID_CUSTOMER, err.Error())
+	}
+	return MapServiceError(err)
+}
@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ifMatchMetadata carries the If-Match header of REST requests. Conditional
+// updates without an expected_version take the version from it.
+const ifMatchMetadata = "if-match"
+
+// ETag returns the entity tag of a resource version, the quoted version, as
+// the REST API sends it in the ETag header.
+func ETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// expectedVersion returns the version a conditional update expects: the
+// request's expected_version if set, else the version in the if-match
+// metadata. Zero means unconditional, as does an If-Match of "*".
+func expectedVersion(ctx context.Context, field int64) (int64, error) {
+	if field != 0 {
+		return field, nil
+	}
+	values := metadata.ValueFromIncomingContext(ctx, ifMatchMetadata)
+	if len(values) == 0 {
+		return 0, nil
+	}
+	return parseIfMatch(values[0])
+}
+
+// parseIfMatch parses an If-Match header holding one ETag as returned by ETag.
+// Weak tags are accepted since the version is the whole representation.
+func parseIfMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	tag, err := strconv.Unquote(strings.TrimPrefix(header, "W/"))
+	if err == nil {
+		var version int64
+		if version, err = strconv.ParseInt(tag, 10, 64); err == nil && version > 0 {
+			return version, nil
+		}
+	}
+	return 0, status.Errorf(codes.InvalidArgument, "invalid If-Match header %q", header)
+}
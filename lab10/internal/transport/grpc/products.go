@@ -0,0 +1,167 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commondomain "golang-for-java-developers-training/common/domain"
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+	pb "lab10/proto/orders"
+)
+
+// ProductServer implements the gRPC ProductService interface.
+type ProductServer struct {
+	pb.UnimplementedProductServiceServer
+	service *service.CatalogService
+}
+
+// NewProductServer creates a new gRPC product server with injected service.
+func NewProductServer(service *service.CatalogService) *ProductServer {
+	return &ProductServer{service: service}
+}
+
+// CreateProduct handles gRPC CreateProduct requests.
+func (s *ProductServer) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.CreateProductResponse, error) {
+	if req.GetPrice() == nil {
+		return nil, status.Error(codes.InvalidArgument, "price is required")
+	}
+	price, err := protoToMoney(req.GetPrice())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid price: %v", err)
+	}
+
+	product := &domain.Product{
+		Product: commondomain.Product{SKU: req.GetSku(), Name: req.GetName()},
+		Status:  protoToProductStatus(req.GetStatus()),
+	}
+	product.SetPrice(price)
+	if err := s.service.CreateProduct(ctx, product); err != nil {
+		return nil, mapProductError(err)
+	}
+
+	// Re-read to return the stored timestamps
+	created, err := s.service.GetProduct(ctx, product.SKU)
+	if err != nil {
+		return nil, mapProductError(err)
+	}
+	return &pb.CreateProductResponse{Product: productToProto(created)}, nil
+}
+
+// GetProduct handles gRPC GetProduct requests.
+func (s *ProductServer) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.GetProductResponse, error) {
+	product, err := s.service.GetProduct(ctx, req.GetSku())
+	if err != nil {
+		return nil, mapProductError(err)
+	}
+	return &pb.GetProductResponse{Product: productToProto(product)}, nil
+}
+
+// ListProducts handles gRPC ListProducts requests.
+func (s *ProductServer) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	products, err := s.service.ListProducts(ctx)
+	if err != nil {
+		return nil, mapProductError(err)
+	}
+
+	resp := &pb.ListProductsResponse{Products: make([]*pb.Product, 0, len(products))}
+	for _, product := range products {
+		resp.Products = append(resp.Products, productToProto(product))
+	}
+	return resp, nil
+}
+
+// UpdateProduct handles gRPC UpdateProduct requests.
+func (s *ProductServer) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.UpdateProductResponse, error) {
+	version, err := expectedVersion(ctx, req.GetExpectedVersion())
+	if err != nil {
+		return nil, err
+	}
+	update := service.ProductUpdate{
+		Name:            req.GetName(),
+		Status:          protoToProductStatus(req.GetStatus()),
+		ExpectedVersion: version,
+	}
+	if req.GetPrice() != nil {
+		price, err := protoToMoney(req.GetPrice())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid price: %v", err)
+		}
+		update.Price = &price
+	}
+
+	product, err := s.service.UpdateProduct(ctx, req.GetSku(), update)
+	if err != nil {
+		return nil, mapProductError(err)
+	}
+	return &pb.UpdateProductResponse{Product: productToProto(product)}, nil
+}
+
+// DeleteProduct handles gRPC DeleteProduct requests.
+func (s *ProductServer) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
+	if err := s.service.DeleteProduct(ctx, req.GetSku()); err != nil {
+		return nil, mapProductError(err)
+	}
+	return &pb.DeleteProductResponse{}, nil
+}
+
+// productToProto converts domain product to protobuf product.
+func productToProto(product *domain.Product) *pb.Product {
+	return &pb.Product{
+		Sku:        product.SKU,
+		Name:       product.Name,
+		Price:      moneyToProto(product.Price),
+		Status:     productStatusToProto(product.Status),
+		CreatedAt:  timeToUnix(product.CreatedAt),
+		UpdatedAt:  timeToUnix(product.UpdatedAt),
+		Version:    product.Version,
+		CreateTime: timeToProto(product.CreatedAt),
+		UpdateTime: timeToProto(product.UpdatedAt),
+	}
+}
+
+// productStatusToProto converts domain product status to protobuf enum.
+func productStatusToProto(s domain.ProductStatus) pb.ProductStatus {
+	switch s {
+	case domain.ProductActive:
+		return pb.ProductStatus_PRODUCT_ACTIVE
+	case domain.ProductDiscontinued:
+		return pb.ProductStatus_PRODUCT_DISCONTINUED
+	default:
+		return pb.ProductStatus_PRODUCT_STATUS_UNSPECIFIED
+	}
+}
+
+// protoToProductStatus converts protobuf enum to domain product status.
+// Unspecified becomes "", which means "default" or "unchanged" to the service.
+func protoToProductStatus(s pb.ProductStatus) domain.ProductStatus {
+	switch s {
+	case pb.ProductStatus_PRODUCT_ACTIVE:
+		return domain.ProductActive
+	case pb.ProductStatus_PRODUCT_DISCONTINUED:
+		return domain.ProductDiscontinued
+	default:
+		return ""
+	}
+}
+
+// mapProductError converts catalog service errors to gRPC status errors.
+func mapProductError(err error) error {
+	if errors.Is(err, repository.ErrProductNotFound) {
+		return reasonError(codes.NotFound, pb.ErrorReason_PRODUCT_NOT_FOUND, "product not found")
+	}
+	if errors.Is(err, repository.ErrProductAlreadyExists) {
+		return reasonError(codes.AlreadyExists, pb.ErrorReason_PRODUCT_ALREADY_EXISTS, "product already exists")
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return reasonError(codes.FailedPrecondition, pb.ErrorReason_VERSION_CONFLICT, "product was modified by another request")
+	}
+	if errors.Is(err, service.ErrInvalidProduct) {
+		return reasonError(codes.InvalidArgument, pb.ErrorReason_INVALID_PRODUCT, err.Error())
+	}
+	return status.Error(codes.Internal, "internal server error")
+}
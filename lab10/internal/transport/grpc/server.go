@@ -3,9 +3,19 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"lab10/internal/domain"
 	"lab10/internal/repository"
@@ -19,122 +29,960 @@ import (
 type OrderServer struct {
 	pb.UnimplementedOrderServiceServer
 	service *service.OrderService
+
+	// done is closed by Shutdown to end open WatchOrders streams
+	done         chan struct{}
+	shutdownOnce sync.Once
 }
 
 // NewOrderServer creates a new gRPC server with injected service.
 func NewOrderServer(service *service.OrderService) *OrderServer {
 	return &OrderServer{
 		service: service,
+		done:    make(chan struct{}),
 	}
 }
 
+// Shutdown ends all open WatchOrders streams. Call it before
+// grpc.Server.GracefulStop, which otherwise waits for them forever.
+func (s *OrderServer) Shutdown() {
+	s.shutdownOnce.Do(func() { close(s.done) })
+}
+
+const (
+	// idempotencyKeyMetadata lets clients retry CreateOrder safely.
+	idempotencyKeyMetadata = "idempotency-key"
+
+	// idempotentReplayedMetadata is set in the response header of a replayed CreateOrder.
+	idempotentReplayedMetadata = "idempotent-replayed"
+)
+
 // CreateOrder handles gRPC CreateOrder requests.
-// TODO: Part 7 - Implement gRPC CreateOrder
+// With idempotency-key request metadata, retries with the same request get
+// the original response back instead of creating a duplicate.
 func (s *OrderServer) CreateOrder(ctx context.Context, req *pb.CreateOrderRequest) (*pb.CreateOrderResponse, error) {
-	// TODO: Convert protobuf request to domain.Order using protoToLineItems()
-	// TODO: Call s.service.CreateOrder()
-	// TODO: Handle errors using mapServiceError()
-	// TODO: Convert domain.Order to protobuf using orderToProto()
-	// TODO: Return CreateOrderResponse
-	return nil, status.Error(codes.Unimplemented, "not implemented")
+	order, err := ProtoToNewOrder(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var key string
+	if values := metadata.ValueFromIncomingContext(ctx, idempotencyKeyMetadata); len(values) > 0 {
+		key = values[0]
+	}
+	replayed, err := s.service.CreateOrderIdempotent(ctx, key, order)
+	if err != nil {
+		return nil, MapServiceError(err)
+	}
+	if replayed {
+		if err := grpc.SetHeader(ctx, metadata.Pairs(idempotentReplayedMetadata, "true")); err != nil {
+			return nil, err
+		}
+	}
+
+	return &pb.CreateOrderResponse{Order: OrderToProto(order)}, nil
+}
+
+// BatchCreateOrders handles gRPC BatchCreateOrders requests.
+func (s *OrderServer) BatchCreateOrders(ctx context.Context, req *pb.BatchCreateOrdersRequest) (*pb.BatchOrdersResponse, error) {
+	orders := make([]*domain.Order, 0, len(req.GetOrders()))
+	for i, pbOrder := range req.GetOrders() {
+		order, err := ProtoToNewOrder(pbOrder)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "orders[%d]: %s", i, status.Convert(err).Message())
+		}
+		orders = append(orders, order)
+	}
+
+	results, err := s.service.BatchCreateOrders(ctx, orders, req.GetAtomic())
+	if err != nil {
+		return nil, MapServiceError(err)
+	}
+	return batchResultsToProto(results), nil
+}
+
+// BatchUpdateStatus handles gRPC BatchUpdateStatus requests.
+func (s *OrderServer) BatchUpdateStatus(ctx context.Context, req *pb.BatchUpdateStatusRequest) (*pb.BatchOrdersResponse, error) {
+	items := make([]service.StatusUpdateItem, 0, len(req.GetUpdates()))
+	for _, update := range req.GetUpdates() {
+		items = append(items, service.StatusUpdateItem{
+			ID: update.GetId(),
+			StatusUpdate: service.StatusUpdate{
+				Status:          protoToStatus(update.GetStatus()),
+				ExpectedVersion: update.GetExpectedVersion(),
+				Actor:           update.GetActor(),
+				Reason:          update.GetReason(),
+			},
+		})
+	}
+
+	results, err := s.service.BatchUpdateStatus(ctx, items, req.GetAtomic())
+	if err != nil {
+		return nil, MapServiceError(err)
+	}
+	return batchResultsToProto(results), nil
+}
+
+// StreamCreateOrders handles streaming order imports. Each order is created
+// as it arrives and its result sent back right away, so memory use doesn't
+// grow with the stream. An atomic stream is held back instead, up to
+// service.MaxBatchSize orders, and created as one atomic batch once the
+// client closes its side.
+func (s *OrderServer) StreamCreateOrders(stream pb.OrderService_StreamCreateOrdersServer) error {
+	ctx := stream.Context()
+	atomic := false
+	var held []*domain.Order
+	for index := 0; ; index++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			if !atomic {
+				return nil
+			}
+			results, err := s.service.BatchCreateOrders(ctx, held, true)
+			if err != nil {
+				return MapServiceError(err)
+			}
+			for i, result := range results {
+				if err := stream.Send(streamResultToProto(i, result)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if index == 0 {
+			atomic = req.GetAtomic()
+		} else if req.GetAtomic() && !atomic {
+			return status.Error(codes.InvalidArgument, "atomic can only be set on the first message of the stream")
+		}
+
+		order, err := ProtoToNewOrder(req.GetOrder())
+		if atomic {
+			if err != nil {
+				return status.Errorf(codes.InvalidArgument, "orders[%d]: %s", index, status.Convert(err).Message())
+			}
+			if len(held) == service.MaxBatchSize {
+				return status.Errorf(codes.InvalidArgument, "atomic streams are limited to %d orders", service.MaxBatchSize)
+			}
+			held = append(held, order)
+			continue
+		}
+
+		if err == nil {
+			err = s.service.CreateOrder(ctx, order)
+		}
+		if err := stream.Send(streamResultToProto(index, service.BatchResult{Order: order, Err: err})); err != nil {
+			return err
+		}
+	}
+}
+
+// streamResultToProto converts the result of the index-th order of a
+// StreamCreateOrders stream, like batchResultsToProto does for a batch.
+func streamResultToProto(index int, result service.BatchResult) *pb.StreamCreateOrdersResult {
+	item := &pb.StreamCreateOrdersResult{Index: int32(index)}
+	if result.Err != nil {
+		st := batchItemStatus(result.Err)
+		item.ErrorCode = int32(st.Code())
+		item.ErrorMessage = st.Message()
+		item.ErrorReason = ErrorReason(st)
+	} else {
+		item.OrderId = result.Order.ID
+	}
+	return item
 }
 
 // GetOrder handles gRPC GetOrder requests.
-// TODO: Part 7 - Implement gRPC GetOrder
 func (s *OrderServer) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
-	// TODO: Call s.service.GetOrder() with req.Id
-	// TODO: Handle errors using mapServiceError()
-	// TODO: Convert order to protobuf and return
-	return nil, status.Error(codes.Unimplemented, "not implemented")
+	get := s.service.GetOrder
+	if req.GetIncludeDeleted() {
+		get = s.service.GetOrderIncludingDeleted
+	}
+	order, err := get(ctx, req.GetId())
+	if err != nil {
+		return nil, MapServiceError(err)
+	}
+
+	return &pb.GetOrderResponse{Order: OrderToProto(order)}, nil
 }
 
 // ListOrders handles gRPC ListOrders requests.
-// TODO: Part 7 - Implement gRPC ListOrders
+// Supports the same filters, sort keys and page tokens as GET /v1/orders.
 func (s *OrderServer) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
-	// TODO: Call s.service.ListOrders()
-	// TODO: Handle errors using mapServiceError()
-	// TODO: Convert all orders to protobuf (loop and call orderToProto())
-	// TODO: Return ListOrdersResponse with orders array
-	return nil, status.Error(codes.Unimplemented, "not implemented")
+	opts, err := ProtoToListOptions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.service.ListOrders(ctx, opts)
+	if err != nil {
+		return nil, MapServiceError(err)
+	}
+
+	orders := make([]*pb.Order, 0, len(result.Orders))
+	for _, order := range result.Orders {
+		orders = append(orders, OrderToProto(order))
+	}
+
+	return &pb.ListOrdersResponse{
+		Orders:        orders,
+		NextPageToken: result.NextPageToken,
+	}, nil
+}
+
+// ProtoToListOptions converts a ListOrdersRequest to repository list
+// options. Invalid amounts and timestamps are reported as InvalidArgument.
+func ProtoToListOptions(req *pb.ListOrdersRequest) (repository.ListOptions, error) {
+	opts := repository.ListOptions{
+		PageSize:   int(req.GetPageSize()),
+		PageToken:  req.GetPageToken(),
+		SortBy:     repository.SortField(req.GetSortBy()),
+		Descending: req.GetDescending(),
+		Filter: repository.ListFilter{
+			CustomerID:     req.GetCustomerId(),
+			IncludeDeleted: req.GetIncludeDeleted(),
+		},
+	}
+	if req.GetMinTotal() != nil {
+		minTotal, err := protoToMoney(req.GetMinTotal())
+		if err != nil {
+			return opts, status.Errorf(codes.InvalidArgument, "invalid min_total: %v", err)
+		}
+		opts.Filter.MinTotal = &minTotal
+	}
+	if req.GetMaxTotal() != nil {
+		maxTotal, err := protoToMoney(req.GetMaxTotal())
+		if err != nil {
+			return opts, status.Errorf(codes.InvalidArgument, "invalid max_total: %v", err)
+		}
+		opts.Filter.MaxTotal = &maxTotal
+	}
+	if req.Status != nil {
+		opts.Filter.Status = protoToStatus(req.GetStatus())
+	}
+	// The Timestamp bounds take precedence over the deprecated Unix seconds
+	var err error
+	if opts.Filter.CreatedAfter, err = protoToTime("create_time_after", req.GetCreateTimeAfter(), req.GetCreatedAfter()); err != nil {
+		return opts, err
+	}
+	if opts.Filter.CreatedBefore, err = protoToTime("create_time_before", req.GetCreateTimeBefore(), req.GetCreatedBefore()); err != nil {
+		return opts, err
+	}
+	return opts, nil
 }
 
 // UpdateOrderStatus handles gRPC UpdateOrderStatus requests.
-// TODO: Part 7 - Implement gRPC UpdateOrderStatus
+// A non-zero expected_version (or an If-Match header through the REST
+// gateway) makes the update conditional on the order's version.
 func (s *OrderServer) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrderStatusRequest) (*pb.UpdateOrderStatusResponse, error) {
-	// TODO: Convert protobuf status to domain status using protoToStatus()
-	// TODO: Call s.service.UpdateOrderStatus()
-	// TODO: Handle errors using mapServiceError()
-	// TODO: Return empty UpdateOrderStatusResponse on success
-	return nil, status.Error(codes.Unimplemented, "not implemented")
+	version, err := expectedVersion(ctx, req.GetExpectedVersion())
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.service.UpdateOrderStatus(ctx, req.GetId(), service.StatusUpdate{
+		Status:          protoToStatus(req.GetStatus()),
+		ExpectedVersion: version,
+		Actor:           req.GetActor(),
+		Reason:          req.GetReason(),
+	})
+	if err != nil {
+		return nil, MapServiceError(err)
+	}
+
+	return &pb.UpdateOrderStatusResponse{Version: order.Version}, nil
 }
 
-// DeleteOrder handles gRPC DeleteOrder requests.
-// TODO: Part 7 - Implement gRPC DeleteOrder
+// UpdateOrder handles gRPC UpdateOrder requests, changing only the fields
+// named in the update mask.
+func (s *OrderServer) UpdateOrder(ctx context.Context, req *pb.UpdateOrderRequest) (*pb.UpdateOrderResponse, error) {
+	if req.GetOrder().GetId() == "" {
+		return nil, invalidArgument(domain.NewFieldError("order.id", "order ID is required"))
+	}
+	update, err := protoToOrderUpdate(req)
+	if err != nil {
+		return nil, err
+	}
+	if update.ExpectedVersion, err = expectedVersion(ctx, update.ExpectedVersion); err != nil {
+		return nil, err
+	}
+
+	order, err := s.service.UpdateOrder(ctx, req.GetOrder().GetId(), update)
+	if err != nil {
+		return nil, MapServiceError(err)
+	}
+	return &pb.UpdateOrderResponse{Order: OrderToProto(order)}, nil
+}
+
+// DeleteOrder handles gRPC DeleteOrder requests. The delete is soft.
 func (s *OrderServer) DeleteOrder(ctx context.Context, req *pb.DeleteOrderRequest) (*pb.DeleteOrderResponse, error) {
-	// TODO: Call s.service.DeleteOrder() with req.Id
-	// TODO: Handle errors using mapServiceError()
-	// TODO: Return empty DeleteOrderResponse on success
-	return nil, status.Error(codes.Unimplemented, "not implemented")
+	if err := s.service.DeleteOrder(ctx, req.GetId()); err != nil {
+		return nil, MapServiceError(err)
+	}
+	return &pb.DeleteOrderResponse{}, nil
+}
+
+// RestoreOrder handles gRPC RestoreOrder requests.
+func (s *OrderServer) RestoreOrder(ctx context.Context, req *pb.RestoreOrderRequest) (*pb.RestoreOrderResponse, error) {
+	order, err := s.service.RestoreOrder(ctx, req.GetId())
+	if err != nil {
+		return nil, MapServiceError(err)
+	}
+	return &pb.RestoreOrderResponse{Order: OrderToProto(order)}, nil
+}
+
+// GetAllowedTransitions handles gRPC GetAllowedTransitions requests.
+func (s *OrderServer) GetAllowedTransitions(ctx context.Context, req *pb.GetAllowedTransitionsRequest) (*pb.GetAllowedTransitionsResponse, error) {
+	order, allowed, err := s.service.GetAllowedTransitions(ctx, req.GetId())
+	if err != nil {
+		return nil, MapServiceError(err)
+	}
+
+	pbAllowed := make([]pb.OrderStatus, 0, len(allowed))
+	for _, status := range allowed {
+		pbAllowed = append(pbAllowed, statusToProto(status))
+	}
+
+	return &pb.GetAllowedTransitionsResponse{
+		Status:             statusToProto(order.Status),
+		AllowedTransitions: pbAllowed,
+	}, nil
+}
+
+// GetOrderHistory handles gRPC GetOrderHistory requests.
+func (s *OrderServer) GetOrderHistory(ctx context.Context, req *pb.GetOrderHistoryRequest) (*pb.GetOrderHistoryResponse, error) {
+	history, err := s.service.GetOrderHistory(ctx, req.GetId())
+	if err != nil {
+		return nil, MapServiceError(err)
+	}
+
+	events := make([]*pb.StatusChange, 0, len(history))
+	for _, change := range history {
+		events = append(events, statusChangeToProto(change))
+	}
+
+	return &pb.GetOrderHistoryResponse{Events: events}, nil
+}
+
+// WatchOrders handles gRPC WatchOrders streams, sending each matching order
+// event until the client goes away or the server shuts down.
+func (s *OrderServer) WatchOrders(req *pb.WatchOrdersRequest, stream pb.OrderService_WatchOrdersServer) error {
+	ctx := stream.Context()
+	events, err := s.service.WatchOrders(ctx, service.WatchFilter{
+		CustomerID: req.GetCustomerId(),
+		OrderID:    req.GetOrderId(),
+	})
+	if err != nil {
+		return MapServiceError(err)
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case event, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return status.Error(codes.ResourceExhausted, "client fell too far behind, watch again")
+			}
+			if err := stream.Send(EventToProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// EventToProto converts a domain Event to a protobuf OrderEvent.
+func EventToProto(event domain.Event) *pb.OrderEvent {
+	pbEvent := &pb.OrderEvent{
+		Id:         event.ID,
+		OrderId:    event.OrderID,
+		OccurredAt: event.OccurredAt.Unix(),
+		EventTime:  timeToProto(event.OccurredAt),
+	}
+	switch event.Type {
+	case domain.EventOrderCreated:
+		pbEvent.Type = pb.OrderEventType_ORDER_CREATED
+	case domain.EventOrderUpdated:
+		pbEvent.Type = pb.OrderEventType_ORDER_UPDATED
+	case domain.EventOrderStatusChanged:
+		pbEvent.Type = pb.OrderEventType_ORDER_STATUS_CHANGED
+	case domain.EventOrderDeleted:
+		pbEvent.Type = pb.OrderEventType_ORDER_DELETED
+	case domain.EventOrderRestored:
+		pbEvent.Type = pb.OrderEventType_ORDER_RESTORED
+	case domain.EventOrderPurged:
+		pbEvent.Type = pb.OrderEventType_ORDER_PURGED
+	}
+	if event.Order != nil {
+		pbEvent.Order = OrderToProto(event.Order)
+	}
+	if event.StatusChange != nil {
+		pbEvent.StatusChange = statusChangeToProto(*event.StatusChange)
+	}
+	return pbEvent
+}
+
+// statusChangeToProto converts a domain StatusChange to protobuf.
+func statusChangeToProto(change domain.StatusChange) *pb.StatusChange {
+	return &pb.StatusChange{
+		FromStatus: statusToProto(change.FromStatus),
+		ToStatus:   statusToProto(change.ToStatus),
+		Actor:      change.Actor,
+		Reason:     change.Reason,
+		Timestamp:  change.Timestamp.Unix(),
+		ChangeTime: timeToProto(change.Timestamp),
+	}
+}
+
+// AddLineItem implements the AddLineItem RPC.
+func (s *OrderServer) AddLineItem(ctx context.Context, req *pb.AddLineItemRequest) (*pb.LineItemsResponse, error) {
+	if req.GetItem() == nil {
+		return nil, status.Error(codes.InvalidArgument, "item is required")
+	}
+	items, err := protoToLineItems([]*pb.LineItem{req.GetItem()})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.editLineItems(ctx, req.GetOrderId(), req.GetExpectedVersion(), domain.ItemEdit{
+		Op:   domain.ItemEditAdd,
+		Item: &items[0],
+	})
+}
+
+// RemoveLineItem implements the RemoveLineItem RPC.
+func (s *OrderServer) RemoveLineItem(ctx context.Context, req *pb.RemoveLineItemRequest) (*pb.LineItemsResponse, error) {
+	return s.editLineItems(ctx, req.GetOrderId(), req.GetExpectedVersion(), domain.ItemEdit{
+		Op:        domain.ItemEditRemove,
+		ProductID: req.GetProductId(),
+	})
+}
+
+// UpdateLineItemQuantity implements the UpdateLineItemQuantity RPC.
+func (s *OrderServer) UpdateLineItemQuantity(ctx context.Context, req *pb.UpdateLineItemQuantityRequest) (*pb.LineItemsResponse, error) {
+	return s.editLineItems(ctx, req.GetOrderId(), req.GetExpectedVersion(), domain.ItemEdit{
+		Op:        domain.ItemEditSetQuantity,
+		ProductID: req.GetProductId(),
+		Quantity:  int(req.GetQuantity()),
+	})
+}
+
+// UpdateLineItems implements the UpdateLineItems RPC. All operations are
+// applied as one update with a single version bump.
+func (s *OrderServer) UpdateLineItems(ctx context.Context, req *pb.UpdateLineItemsRequest) (*pb.LineItemsResponse, error) {
+	edits := make([]domain.ItemEdit, 0, len(req.GetOperations()))
+	for i, op := range req.GetOperations() {
+		edit, err := protoToItemEdit(op)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "operations[%d]: %v", i, err)
+		}
+		edits = append(edits, edit)
+	}
+	return s.editLineItems(ctx, req.GetOrderId(), req.GetExpectedVersion(), edits...)
+}
+
+// protoToItemEdit converts a LineItemOperation to a domain ItemEdit.
+func protoToItemEdit(op *pb.LineItemOperation) (domain.ItemEdit, error) {
+	switch op.GetOp() {
+	case pb.LineItemOp_LINE_ITEM_ADD:
+		if op.GetItem() == nil {
+			return domain.ItemEdit{}, errors.New("item is required")
+		}
+		items, err := protoToLineItems([]*pb.LineItem{op.GetItem()})
+		if err != nil {
+			return domain.ItemEdit{}, err
+		}
+		return domain.ItemEdit{Op: domain.ItemEditAdd, Item: &items[0]}, nil
+	case pb.LineItemOp_LINE_ITEM_REMOVE:
+		return domain.ItemEdit{Op: domain.ItemEditRemove, ProductID: op.GetProductId()}, nil
+	case pb.LineItemOp_LINE_ITEM_SET_QUANTITY:
+		return domain.ItemEdit{Op: domain.ItemEditSetQuantity, ProductID: op.GetProductId(), Quantity: int(op.GetQuantity())}, nil
+	}
+	return domain.ItemEdit{}, fmt.Errorf("unknown op %v", op.GetOp())
+}
+
+// editLineItems applies line-item edits as one update and returns the updated order.
+func (s *OrderServer) editLineItems(ctx context.Context, orderID string, expected int64, edits ...domain.ItemEdit) (*pb.LineItemsResponse, error) {
+	version, err := expectedVersion(ctx, expected)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.service.UpdateOrderItems(ctx, orderID, edits, version)
+	if err != nil {
+		return nil, MapServiceError(err)
+	}
+	return &pb.LineItemsResponse{Order: OrderToProto(order)}, nil
+}
+
+// ProtoToNewOrder converts a CreateOrderRequest to a domain Order.
+func ProtoToNewOrder(req *pb.CreateOrderRequest) (*domain.Order, error) {
+	items, err := protoToLineItems(req.GetItems())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &domain.Order{
+		ID:         req.GetId(),
+		CustomerID: req.GetCustomerId(),
+		Items:      items,
+		CouponCode: req.GetCouponCode(),
+		Region:     req.GetRegion(),
+	}, nil
+}
+
+// updatableOrderFields are the Order fields UpdateOrder can change, in the
+// order "*" expands to.
+var updatableOrderFields = []string{"customer_id", "items", "coupon_code", "region"}
+
+// outputOnlyOrderFields are Order fields the server sets. Update masks may
+// name them (e.g. when a client sends back a whole order) but they are ignored.
+var outputOnlyOrderFields = map[string]bool{
+	"id": true, "total_amount": true, "total": true, "pricing": true, "version": true,
+	"created_at": true, "updated_at": true, "deleted_at": true,
+	"create_time": true, "update_time": true, "delete_time": true,
+}
+
+// protoToOrderUpdate converts an UpdateOrderRequest to a service OrderUpdate
+// holding the fields named in its update mask. Without a mask, the fields
+// set on the order are updated.
+func protoToOrderUpdate(req *pb.UpdateOrderRequest) (service.OrderUpdate, error) {
+	pbOrder := req.GetOrder()
+	update := service.OrderUpdate{ExpectedVersion: req.GetExpectedVersion()}
+
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = populatedOrderFields(pbOrder)
+	}
+	for _, path := range paths {
+		fields := []string{path}
+		if path == "*" {
+			fields = updatableOrderFields
+		}
+		for _, field := range fields {
+			switch field {
+			case "customer_id":
+				customerID := pbOrder.GetCustomerId()
+				update.CustomerID = &customerID
+			case "items":
+				items, err := protoToLineItems(pbOrder.GetItems())
+				if err != nil {
+					return service.OrderUpdate{}, status.Error(codes.InvalidArgument, err.Error())
+				}
+				update.Items = &items
+			case "coupon_code":
+				couponCode := pbOrder.GetCouponCode()
+				update.CouponCode = &couponCode
+			case "region":
+				region := pbOrder.GetRegion()
+				update.Region = &region
+			case "status":
+				return service.OrderUpdate{}, invalidArgument(domain.NewFieldError("update_mask", "status is changed with UpdateOrderStatus"))
+			default:
+				if outputOnlyOrderFields[field] {
+					continue
+				}
+				if strings.HasPrefix(field, "items.") {
+					return service.OrderUpdate{}, invalidArgument(domain.NewFieldError("update_mask", "items can only be replaced as a whole"))
+				}
+				return service.OrderUpdate{}, invalidArgument(domain.NewFieldError("update_mask", fmt.Sprintf("unknown field %q", field)))
+			}
+		}
+	}
+	return update, nil
+}
+
+// populatedOrderFields returns the updatable fields set on order, the
+// implied update mask of a request without one.
+func populatedOrderFields(order *pb.Order) []string {
+	var fields []string
+	if order.GetCustomerId() != "" {
+		fields = append(fields, "customer_id")
+	}
+	if len(order.GetItems()) > 0 {
+		fields = append(fields, "items")
+	}
+	if order.GetCouponCode() != "" {
+		fields = append(fields, "coupon_code")
+	}
+	if order.GetRegion() != "" {
+		fields = append(fields, "region")
+	}
+	return fields
+}
+
+// batchResultsToProto converts per-item batch results to protobuf, mapping
+// each failure to the status code the single-item RPC would have returned.
+func batchResultsToProto(results []service.BatchResult) *pb.BatchOrdersResponse {
+	resp := &pb.BatchOrdersResponse{Results: make([]*pb.BatchItemResult, 0, len(results))}
+	for i, result := range results {
+		item := &pb.BatchItemResult{Index: int32(i)}
+		if result.Err != nil {
+			st := batchItemStatus(result.Err)
+			item.ErrorCode = int32(st.Code())
+			item.ErrorMessage = st.Message()
+			item.ErrorReason = ErrorReason(st)
+			resp.Failed++
+		} else {
+			item.Order = OrderToProto(result.Order)
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, item)
+	}
+	return resp
 }
 
-// orderToProto converts domain Order to protobuf Order.
-// TODO: Part 7 - Implement conversion helper
-func orderToProto(order *domain.Order) *pb.Order {
-	// TODO: Create pb.Order and populate fields
-	// TODO: Use lineItemsToProto() for items
-	// TODO: Use statusToProto() for status
-	// TODO: Convert timestamps to Unix (order.CreatedAt.Unix())
-	return nil
+// batchItemStatus returns the status of a failed batch item: the error's
+// own, or the one the single-item RPC would have returned.
+func batchItemStatus(err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	return status.Convert(MapServiceError(err))
+}
+
+// OrderToProto converts domain Order to protobuf Order.
+// The deprecated Unix-second timestamps are filled in alongside the
+// Timestamp fields for clients that haven't moved over yet.
+func OrderToProto(order *domain.Order) *pb.Order {
+	pbOrder := &pb.Order{
+		Id:          order.ID,
+		CustomerId:  order.CustomerID,
+		Items:       lineItemsToProto(order.Items),
+		Status:      statusToProto(order.Status),
+		TotalAmount: moneyToFloat(order.TotalAmount),
+		Total:       moneyToProto(order.TotalAmount),
+		CreatedAt:   timeToUnix(order.CreatedAt),
+		UpdatedAt:   timeToUnix(order.UpdatedAt),
+		Version:     order.Version,
+		Pricing:     priceBreakdownToProto(order.Pricing),
+		CouponCode:  order.CouponCode,
+		Region:      order.Region,
+		CreateTime:  timeToProto(order.CreatedAt),
+		UpdateTime:  timeToProto(order.UpdatedAt),
+	}
+	if order.IsDeleted() {
+		pbOrder.DeletedAt = order.DeletedAt.Unix()
+		pbOrder.DeleteTime = timeToProto(*order.DeletedAt)
+	}
+	return pbOrder
+}
+
+// timeToUnix converts a time to Unix seconds; the zero time is unset (0).
+func timeToUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// timeToProto converts a time to a protobuf Timestamp; the zero time is unset.
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// protoToTime converts the Timestamp field name, or the Unix seconds that
+// preceded it if it is unset. Both unset is the zero time.
+func protoToTime(name string, ts *timestamppb.Timestamp, unix int64) (time.Time, error) {
+	if ts != nil {
+		if err := ts.CheckValid(); err != nil {
+			return time.Time{}, status.Errorf(codes.InvalidArgument, "invalid %s: %v", name, err)
+		}
+		return ts.AsTime(), nil
+	}
+	if unix != 0 {
+		return time.Unix(unix, 0), nil
+	}
+	return time.Time{}, nil
+}
+
+// priceBreakdownToProto converts a price breakdown; nil stays nil.
+func priceBreakdownToProto(b *domain.PriceBreakdown) *pb.PriceBreakdown {
+	if b == nil {
+		return nil
+	}
+	return &pb.PriceBreakdown{
+		Subtotal:      moneyToProto(b.Subtotal),
+		Discounts:     adjustmentsToProto(b.Discounts),
+		DiscountTotal: moneyToProto(b.DiscountTotal),
+		Taxes:         adjustmentsToProto(b.Taxes),
+		Tax:           moneyToProto(b.Tax),
+		Total:         moneyToProto(b.Total),
+	}
+}
+
+// adjustmentsToProto converts domain PriceAdjustments to protobuf PriceAdjustments.
+func adjustmentsToProto(adjustments []domain.PriceAdjustment) []*pb.PriceAdjustment {
+	pbAdjustments := make([]*pb.PriceAdjustment, 0, len(adjustments))
+	for _, a := range adjustments {
+		pbAdjustments = append(pbAdjustments, &pb.PriceAdjustment{
+			Rule:        a.Rule,
+			Description: a.Description,
+			Amount:      moneyToProto(a.Amount),
+		})
+	}
+	return pbAdjustments
 }
 
 // lineItemsToProto converts domain LineItems to protobuf LineItems.
-// TODO: Part 7 - Implement conversion helper
 func lineItemsToProto(items []domain.LineItem) []*pb.LineItem {
-	// TODO: Create slice of pb.LineItem
-	// TODO: Loop through items and convert each one
-	// TODO: Note: convert Quantity to int32
-	return nil
+	pbItems := make([]*pb.LineItem, 0, len(items))
+	for _, item := range items {
+		pbItems = append(pbItems, &pb.LineItem{
+			ProductId:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    int32(item.Quantity),
+			UnitPrice:   moneyToFloat(item.UnitPrice),
+			Price:       moneyToProto(item.UnitPrice),
+		})
+	}
+	return pbItems
 }
 
 // protoToLineItems converts protobuf LineItems to domain LineItems.
-// TODO: Part 7 - Implement conversion helper
-func protoToLineItems(items []*pb.LineItem) []domain.LineItem {
-	// TODO: Create slice of domain.LineItem
-	// TODO: Loop through items and convert each one
-	// TODO: Note: convert Quantity from int32 to int
-	return nil
+// Items without a price fall back to the deprecated unit_price, read as USD.
+func protoToLineItems(items []*pb.LineItem) ([]domain.LineItem, error) {
+	domainItems := make([]domain.LineItem, 0, len(items))
+	for i, item := range items {
+		var price domain.Money
+		var err error
+		if item.GetPrice() != nil {
+			price, err = protoToMoney(item.GetPrice())
+		} else {
+			price, err = domain.NewMoney(strconv.FormatFloat(item.GetUnitPrice(), 'f', -1, 64), domain.DefaultCurrency)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("item %d: invalid price: %w", i, err)
+		}
+
+		domainItems = append(domainItems, domain.LineItem{
+			ProductID:   item.GetProductId(),
+			ProductName: item.GetProductName(),
+			Quantity:    int(item.GetQuantity()),
+			UnitPrice:   price,
+		})
+	}
+	return domainItems, nil
+}
+
+// moneyToProto converts domain Money (minor units) to protobuf Money (units + nanos).
+func moneyToProto(m domain.Money) *pb.Money {
+	exp, err := domain.CurrencyExponent(m.Currency)
+	if err != nil {
+		return &pb.Money{CurrencyCode: m.Currency, Units: m.Amount}
+	}
+	scale := pow10(exp)
+	return &pb.Money{
+		CurrencyCode: m.Currency,
+		Units:        m.Amount / scale,
+		Nanos:        int32(m.Amount % scale * pow10(9-exp)),
+	}
+}
+
+// protoToMoney converts protobuf Money to domain Money.
+// Nanos finer than the currency's minor unit are rounded half away from zero.
+func protoToMoney(m *pb.Money) (domain.Money, error) {
+	exp, err := domain.CurrencyExponent(m.GetCurrencyCode())
+	if err != nil {
+		return domain.Money{}, err
+	}
+	if m.GetNanos() <= -1e9 || m.GetNanos() >= 1e9 {
+		return domain.Money{}, fmt.Errorf("nanos out of range: %d", m.GetNanos())
+	}
+	if (m.GetUnits() > 0 && m.GetNanos() < 0) || (m.GetUnits() < 0 && m.GetNanos() > 0) {
+		return domain.Money{}, errors.New("units and nanos must have the same sign")
+	}
+
+	nanosPerMinor := pow10(9 - exp)
+	minor := int64(m.GetNanos()) / nanosPerMinor
+	if rem := int64(m.GetNanos()) % nanosPerMinor; rem*2 >= nanosPerMinor {
+		minor++
+	} else if rem*2 <= -nanosPerMinor {
+		minor--
+	}
+
+	units, err := domain.Money{Amount: m.GetUnits(), Currency: m.GetCurrencyCode()}.Mul(pow10(exp))
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return units.Add(domain.Money{Amount: minor, Currency: m.GetCurrencyCode()})
+}
+
+// moneyToFloat approximates Money as a float64 for the deprecated double fields.
+func moneyToFloat(m domain.Money) float64 {
+	f, _ := strconv.ParseFloat(m.Decimal(), 64)
+	return f
+}
+
+// pow10 returns 10^n for small non-negative n.
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
 }
 
 // statusToProto converts domain OrderStatus to protobuf OrderStatus.
-// TODO: Part 7 - Implement conversion helper
 func statusToProto(status domain.OrderStatus) pb.OrderStatus {
-	// TODO: Use switch statement to map domain status constants to pb.OrderStatus constants
-	// TODO: Handle all cases: pending, confirmed, shipped, delivered, cancelled
-	return pb.OrderStatus_PENDING
+	switch status {
+	case domain.StatusConfirmed:
+		return pb.OrderStatus_CONFIRMED
+	case domain.StatusShipped:
+		return pb.OrderStatus_SHIPPED
+	case domain.StatusDelivered:
+		return pb.OrderStatus_DELIVERED
+	case domain.StatusCancelled:
+		return pb.OrderStatus_CANCELLED
+	case domain.StatusPartiallyShipped:
+		return pb.OrderStatus_PARTIALLY_SHIPPED
+	case domain.StatusReturned:
+		return pb.OrderStatus_RETURNED
+	case domain.StatusRefunded:
+		return pb.OrderStatus_REFUNDED
+	default:
+		return pb.OrderStatus_PENDING
+	}
 }
 
 // protoToStatus converts protobuf OrderStatus to domain OrderStatus.
-// TODO: Part 7 - Implement conversion helper
 func protoToStatus(status pb.OrderStatus) domain.OrderStatus {
-	// TODO: Use switch statement to map pb.OrderStatus constants to domain status constants
-	// TODO: Handle all cases
-	return domain.StatusPending
+	switch status {
+	case pb.OrderStatus_CONFIRMED:
+		return domain.StatusConfirmed
+	case pb.OrderStatus_SHIPPED:
+		return domain.StatusShipped
+	case pb.OrderStatus_DELIVERED:
+		return domain.StatusDelivered
+	case pb.OrderStatus_CANCELLED:
+		return domain.StatusCancelled
+	case pb.OrderStatus_PARTIALLY_SHIPPED:
+		return domain.StatusPartiallyShipped
+	case pb.OrderStatus_RETURNED:
+		return domain.StatusReturned
+	case pb.OrderStatus_REFUNDED:
+		return domain.StatusRefunded
+	default:
+		return domain.StatusPending
+	}
 }
 
-// mapServiceError converts service errors to gRPC status codes.
+// MapServiceError converts service errors to gRPC status codes.
 // This is how we communicate errors to gRPC clients.
-func mapServiceError(err error) error {
+func MapServiceError(err error) error {
 	if errors.Is(err, repository.ErrNotFound) {
-		return status.Error(codes.NotFound, "order not found")
+		return reasonError(codes.NotFound, pb.ErrorReason_ORDER_NOT_FOUND, "order not found")
 	}
 	if errors.Is(err, repository.ErrAlreadyExists) {
-		return status.Error(codes.AlreadyExists, "order already exists")
+		return reasonError(codes.AlreadyExists, pb.ErrorReason_ORDER_ALREADY_EXISTS, "order already exists")
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return reasonError(codes.FailedPrecondition, pb.ErrorReason_VERSION_CONFLICT, "order was modified by another request")
+	}
+	if errors.Is(err, repository.ErrNotDeleted) {
+		return reasonError(codes.FailedPrecondition, pb.ErrorReason_ORDER_NOT_DELETED, "order is not deleted")
 	}
 	if errors.Is(err, service.ErrInvalidOrder) {
-		return status.Error(codes.InvalidArgument, err.Error())
+		return invalidArgument(err)
 	}
 	if errors.Is(err, service.ErrInvalidStatusTransition) {
-		return status.Error(codes.FailedPrecondition, err.Error())
+		return reasonError(codes.FailedPrecondition, pb.ErrorReason_INVALID_STATUS_TRANSITION, err.Error())
+	}
+	if errors.Is(err, service.ErrOrderNotEditable) {
+		return reasonError(codes.FailedPrecondition, pb.ErrorReason_ORDER_NOT_EDITABLE, err.Error())
+	}
+	if errors.Is(err, service.ErrInsufficientStock) {
+		return reasonError(codes.FailedPrecondition, pb.ErrorReason_INSUFFICIENT_STOCK, err.Error())
+	}
+	if errors.Is(err, service.ErrInvalidIdempotencyKey) {
+		return reasonError(codes.InvalidArgument, pb.ErrorReason_INVALID_IDEMPOTENCY_KEY, err.Error())
+	}
+	if errors.Is(err, service.ErrIdempotencyKeyReused) {
+		return reasonError(codes.InvalidArgument, pb.ErrorReason_IDEMPOTENCY_KEY_REUSED, err.Error())
+	}
+	if errors.Is(err, service.ErrIdempotencyKeyInProgress) {
+		return reasonError(codes.Aborted, pb.ErrorReason_IDEMPOTENCY_KEY_IN_PROGRESS, err.Error())
+	}
+	if errors.Is(err, service.ErrInvalidBatch) {
+		return reasonError(codes.InvalidArgument, pb.ErrorReason_INVALID_BATCH, err.Error())
+	}
+	if errors.Is(err, service.ErrBatchAborted) {
+		return reasonError(codes.Aborted, pb.ErrorReason_BATCH_ABORTED, err.Error())
+	}
+	if errors.Is(err, service.ErrWatchUnavailable) {
+		return reasonError(codes.Unimplemented, pb.ErrorReason_WATCH_UNAVAILABLE, err.Error())
+	}
+	if errors.Is(err, service.ErrInvalidListOptions) {
+		return reasonError(codes.InvalidArgument, pb.ErrorReason_INVALID_LIST_OPTIONS, err.Error())
+	}
+	if errors.Is(err, service.ErrPermissionDenied) {
+		return reasonError(codes.PermissionDenied, pb.ErrorReason_PERMISSION_DENIED, err.Error())
 	}
 	return status.Error(codes.Internal, "internal server error")
 }
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo details the API
+// attaches to errors, next to a pb.ErrorReason name.
+const ErrorDomain = "orders.lab10"
+
+// reasonError returns a status error with an ErrorInfo detail for reason.
+func reasonError(code codes.Code, reason pb.ErrorReason, msg string) error {
+	st := status.New(code, msg)
+	detailed, err := st.WithDetails(errorInfo(reason))
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// errorInfo returns the ErrorInfo detail for reason.
+func errorInfo(reason pb.ErrorReason) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{Reason: reason.String(), Domain: ErrorDomain}
+}
+
+// ErrorReason returns the pb.ErrorReason name in the ErrorInfo detail of
+// st, or "" if it has none.
+func ErrorReason(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain {
+			return info.GetReason()
+		}
+	}
+	return ""
+}
+
+// invalidArgument maps a validation error to InvalidArgument. Field
+// violations are attached as google.rpc.BadRequest details, with field paths
+// translated to the protobuf field names.
+func invalidArgument(err error) error {
+	st := status.New(codes.InvalidArgument, err.Error())
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) {
+		return reasonError(codes.InvalidArgument, pb.ErrorReason_INVALID_ORDER, err.Error())
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, v := range verr.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       protoFieldPaths.Replace(v.Field),
+			Description: v.Description,
+		})
+	}
+	detailed, detailErr := st.WithDetails(errorInfo(pb.ErrorReason_INVALID_ORDER), badRequest)
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// protoFieldPaths rewrites the JSON field paths of domain validation errors
+// where the protobuf messages name a field differently.
+var protoFieldPaths = strings.NewReplacer(
+	".unit_price.currency", ".price.currency_code",
+	".unit_price.amount", ".price",
+)
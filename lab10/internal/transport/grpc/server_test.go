@@ -0,0 +1,315 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"lab10/internal/auth"
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+	pb "lab10/proto/orders"
+)
+
+// newTestClient serves an OrderServer over an in-memory connection and
+// returns a client for it. opts are passed to grpc.NewServer.
+func newTestClient(t *testing.T, svc *service.OrderService, opts ...grpc.ServerOption) pb.OrderServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(opts...)
+	pb.RegisterOrderServiceServer(server, NewOrderServer(svc))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewOrderServiceClient(conn)
+}
+
+func newStreamOrder(customerID string) *pb.CreateOrderRequest {
+	return &pb.CreateOrderRequest{
+		CustomerId: customerID,
+		Items: []*pb.LineItem{{
+			ProductId:   "SKU-1",
+			ProductName: "Widget",
+			Quantity:    1,
+			Price:       &pb.Money{CurrencyCode: "USD", Units: 10},
+		}},
+	}
+}
+
+// streamOrders sends orders on a StreamCreateOrders stream, the first one
+// with atomic set as given, and returns the results and the final error.
+func streamOrders(t *testing.T, client pb.OrderServiceClient, atomic bool, orders ...*pb.CreateOrderRequest) ([]*pb.StreamCreateOrdersResult, error) {
+	t.Helper()
+	stream, err := client.StreamCreateOrders(context.Background())
+	if err != nil {
+		t.Fatalf("StreamCreateOrders() error = %v", err)
+	}
+	for i, order := range orders {
+		if err := stream.Send(&pb.StreamCreateOrdersRequest{Order: order, Atomic: atomic && i == 0}); err != nil {
+			break
+		}
+	}
+	stream.CloseSend()
+
+	var results []*pb.StreamCreateOrdersResult
+	for {
+		result, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return results, nil
+		}
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+}
+
+func TestStreamCreateOrders(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	client := newTestClient(t, service.NewOrderService(repo))
+
+	results, err := streamOrders(t, client, false, newStreamOrder("CUST-1"), newStreamOrder(""), newStreamOrder("CUST-2"))
+	if err != nil {
+		t.Fatalf("stream error = %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	for i, result := range results {
+		if int(result.GetIndex()) != i {
+			t.Errorf("result %d has index %d", i, result.GetIndex())
+		}
+	}
+	if results[0].GetOrderId() == "" || results[2].GetOrderId() == "" {
+		t.Errorf("results = %v, want IDs for orders 0 and 2", results)
+	}
+	if results[1].GetOrderId() != "" || codes.Code(results[1].GetErrorCode()) != codes.InvalidArgument {
+		t.Errorf("result 1 = %v, want InvalidArgument", results[1])
+	}
+	if _, err := repo.Get(context.Background(), results[2].GetOrderId()); err != nil {
+		t.Errorf("streamed order not stored: %v", err)
+	}
+}
+
+func TestStreamCreateOrdersAtomic(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	client := newTestClient(t, service.NewOrderService(repo))
+
+	// One invalid order means none is created
+	results, err := streamOrders(t, client, true, newStreamOrder("CUST-1"), newStreamOrder(""))
+	if err != nil {
+		t.Fatalf("stream error = %v", err)
+	}
+	if len(results) != 2 || results[0].GetOrderId() != "" || results[0].GetErrorCode() == 0 || results[1].GetErrorCode() == 0 {
+		t.Fatalf("results = %v, want both failed", results)
+	}
+	if all, _ := repo.GetAll(context.Background()); len(all) != 0 {
+		t.Fatalf("%d orders created by a failed atomic stream, want 0", len(all))
+	}
+
+	results, err = streamOrders(t, client, true, newStreamOrder("CUST-1"), newStreamOrder("CUST-2"))
+	if err != nil || len(results) != 2 || results[0].GetOrderId() == "" || results[1].GetOrderId() == "" {
+		t.Fatalf("atomic stream = %v, %v, want 2 created orders", results, err)
+	}
+
+	orders := make([]*pb.CreateOrderRequest, service.MaxBatchSize+1)
+	for i := range orders {
+		orders[i] = newStreamOrder("CUST-1")
+	}
+	if _, err := streamOrders(t, client, true, orders...); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("oversized atomic stream error = %v, want InvalidArgument", err)
+	}
+	if all, _ := repo.GetAll(context.Background()); len(all) != 2 {
+		t.Fatalf("%d orders after oversized atomic stream, want 2", len(all))
+	}
+}
+
+func TestStreamCreateOrdersAtomicLate(t *testing.T) {
+	client := newTestClient(t, service.NewOrderService(repository.NewMemoryRepository()))
+
+	stream, err := client.StreamCreateOrders(context.Background())
+	if err != nil {
+		t.Fatalf("StreamCreateOrders() error = %v", err)
+	}
+	stream.Send(&pb.StreamCreateOrdersRequest{Order: newStreamOrder("CUST-1")})
+	stream.Send(&pb.StreamCreateOrdersRequest{Order: newStreamOrder("CUST-1"), Atomic: true})
+	stream.CloseSend()
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("stream error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestIncludeDeletedPermissionDenied(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	svc := service.NewOrderService(repo)
+	// Every call is made by a JWT caller without the admin role
+	asUser := grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(auth.NewContext(ctx, &auth.Principal{Subject: "alice", Method: auth.MethodJWT}), req)
+	})
+	client := newTestClient(t, svc, asUser)
+	ctx := context.Background()
+
+	created, err := client.CreateOrder(ctx, newStreamOrder("CUST-1"))
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	id := created.GetOrder().GetId()
+	if _, err := client.GetOrder(ctx, &pb.GetOrderRequest{Id: id, IncludeDeleted: true}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("GetOrder(include_deleted) error = %v, want PermissionDenied", err)
+	}
+	if _, err := client.ListOrders(ctx, &pb.ListOrdersRequest{IncludeDeleted: true}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("ListOrders(include_deleted) error = %v, want PermissionDenied", err)
+	}
+	if _, err := client.GetOrder(ctx, &pb.GetOrderRequest{Id: id}); err != nil {
+		t.Errorf("GetOrder() error = %v", err)
+	}
+}
+
+func TestProtoToListOptionsTimes(t *testing.T) {
+	after := time.Date(2024, 5, 1, 12, 0, 0, 250_000_000, time.UTC)
+	before := after.Add(time.Hour)
+
+	// Timestamps keep sub-second precision and take precedence
+	opts, err := ProtoToListOptions(&pb.ListOrdersRequest{
+		CreateTimeAfter:  timestamppb.New(after),
+		CreateTimeBefore: timestamppb.New(before),
+		CreatedAfter:     1,
+	})
+	if err != nil {
+		t.Fatalf("ProtoToListOptions() error = %v", err)
+	}
+	if !opts.Filter.CreatedAfter.Equal(after) || !opts.Filter.CreatedBefore.Equal(before) {
+		t.Errorf("created range = %v - %v, want %v - %v", opts.Filter.CreatedAfter, opts.Filter.CreatedBefore, after, before)
+	}
+
+	// The deprecated Unix seconds still work on their own
+	opts, err = ProtoToListOptions(&pb.ListOrdersRequest{CreatedAfter: after.Unix()})
+	if err != nil || !opts.Filter.CreatedAfter.Equal(after.Truncate(time.Second)) || !opts.Filter.CreatedBefore.IsZero() {
+		t.Errorf("ProtoToListOptions(created_after) = %v - %v, %v", opts.Filter.CreatedAfter, opts.Filter.CreatedBefore, err)
+	}
+
+	invalid := &pb.ListOrdersRequest{CreateTimeAfter: &timestamppb.Timestamp{Nanos: -1}}
+	if _, err := ProtoToListOptions(invalid); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ProtoToListOptions() of invalid timestamp error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestEventToProtoTimes(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 250_000_000, time.UTC)
+	event := domain.Event{
+		ID:           1,
+		Type:         domain.EventOrderStatusChanged,
+		OrderID:      "ORD-1",
+		OccurredAt:   at,
+		StatusChange: &domain.StatusChange{FromStatus: domain.StatusPending, ToStatus: domain.StatusConfirmed, Timestamp: at},
+	}
+
+	got := EventToProto(event)
+	if !got.GetEventTime().AsTime().Equal(at) || got.GetOccurredAt() != at.Unix() {
+		t.Errorf("event times = %v, %d, want %v", got.GetEventTime().AsTime(), got.GetOccurredAt(), at)
+	}
+	change := got.GetStatusChange()
+	if !change.GetChangeTime().AsTime().Equal(at) || change.GetTimestamp() != at.Unix() {
+		t.Errorf("status change times = %v, %d, want %v", change.GetChangeTime().AsTime(), change.GetTimestamp(), at)
+	}
+}
+
+// TestCreateOrderTimes checks a created order, and its idempotent replay,
+// carry the creation time, and that a zero time is left unset.
+func TestCreateOrderTimes(t *testing.T) {
+	client := newTestClient(t, service.NewOrderService(repository.NewMemoryRepository()))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), idempotencyKeyMetadata, "create-1")
+
+	created, err := client.CreateOrder(ctx, newStreamOrder("CUST-1"))
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	replayed, err := client.CreateOrder(ctx, newStreamOrder("CUST-1"))
+	if err != nil {
+		t.Fatalf("replayed CreateOrder() error = %v", err)
+	}
+	for name, order := range map[string]*pb.Order{"created": created.GetOrder(), "replayed": replayed.GetOrder()} {
+		if order.GetCreateTime() == nil || order.GetCreatedAt() != order.GetCreateTime().AsTime().Unix() || order.GetCreatedAt() <= 0 {
+			t.Errorf("%s order times = %d, %v; want the creation time", name, order.GetCreatedAt(), order.GetCreateTime())
+		}
+		if order.GetUpdateTime() == nil || order.GetUpdatedAt() <= 0 {
+			t.Errorf("%s order update times = %d, %v; want the creation time", name, order.GetUpdatedAt(), order.GetUpdateTime())
+		}
+	}
+
+	if got := OrderToProto(&domain.Order{ID: "ORD-1"}); got.GetCreatedAt() != 0 || got.GetCreateTime() != nil || got.GetUpdatedAt() != 0 || got.GetUpdateTime() != nil {
+		t.Errorf("OrderToProto() of an order without times = %d, %v, %d, %v; want them unset",
+			got.GetCreatedAt(), got.GetCreateTime(), got.GetUpdatedAt(), got.GetUpdateTime())
+	}
+}
+
+// TestStatusToProto checks every status a state machine may use has its
+// own OrderStatus value, so none is sent as PENDING by mistake.
+func TestStatusToProto(t *testing.T) {
+	seen := make(map[pb.OrderStatus]domain.OrderStatus)
+	for _, status := range domain.Statuses() {
+		got := statusToProto(status)
+		if other, ok := seen[got]; ok {
+			t.Errorf("statusToProto(%q) = %v, same as for %q", status, got, other)
+		}
+		seen[got] = status
+		if back := protoToStatus(got); back != status {
+			t.Errorf("protoToStatus(statusToProto(%q)) = %q", status, back)
+		}
+	}
+	if len(seen) != len(pb.OrderStatus_name) {
+		t.Errorf("domain statuses map to %d OrderStatus values, want all %d", len(seen), len(pb.OrderStatus_name))
+	}
+}
+
+func TestMapServiceErrorReasons(t *testing.T) {
+	tests := []struct {
+		err  error
+		want pb.ErrorReason
+	}{
+		{repository.ErrNotFound, pb.ErrorReason_ORDER_NOT_FOUND},
+		{repository.ErrVersionConflict, pb.ErrorReason_VERSION_CONFLICT},
+		{service.ErrInsufficientStock, pb.ErrorReason_INSUFFICIENT_STOCK},
+		{service.ErrPermissionDenied, pb.ErrorReason_PERMISSION_DENIED},
+		{service.ErrInvalidOrder, pb.ErrorReason_INVALID_ORDER},
+	}
+	for _, tt := range tests {
+		st := status.Convert(MapServiceError(tt.err))
+		if got := ErrorReason(st); got != tt.want.String() {
+			t.Errorf("MapServiceError(%v) reason = %q, want %s", tt.err, got, tt.want)
+		}
+	}
+
+	// Validation errors keep their field violations next to the reason
+	st := status.Convert(MapServiceError(errors.Join(service.ErrInvalidOrder, domain.NewFieldError("customer_id", "is required"))))
+	if len(st.Details()) != 2 {
+		t.Errorf("invalid order details = %v, want ErrorInfo and BadRequest", st.Details())
+	}
+}
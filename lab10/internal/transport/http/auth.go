@@ -0,0 +1,49 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"lab10/internal/auth"
+)
+
+// apiKeyHeader carries the API key of a request.
+const apiKeyHeader = "X-API-Key"
+
+// RequireAuth authenticates every request to next by its X-API-Key or
+// Authorization: Bearer header and stores the principal in the request
+// context (see auth.FromContext). Unauthenticated requests get a 401 JSON
+// error, with the same message gRPC calls get with Unauthenticated.
+// Requests for the exempt paths, e.g. health checks, are let through
+// without credentials, as are all requests if authn has no credentials
+// configured.
+func RequireAuth(authn *auth.Authenticator, next http.Handler, exempt ...string) http.Handler {
+	if !authn.Enabled() {
+		return next
+	}
+	public := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		public[path] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if public[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		principal, err := authn.Authenticate(r.Header.Get(apiKeyHeader), r.Header.Get("Authorization"))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="orders"`)
+			message := "invalid credentials"
+			if errors.Is(err, auth.ErrMissingCredentials) {
+				message = "authentication required"
+			}
+			respondStatus(w, status.New(codes.Unauthenticated, message), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+	})
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lab10/internal/auth"
+)
+
+func TestRequireAuth(t *testing.T) {
+	authn := auth.NewAuthenticator(auth.WithAPIKey("s3cret"), auth.WithHS256Secret("jwt-secret"))
+	var principal *auth.Principal
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = auth.FromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := RequireAuth(authn, next, "/health", "/ready")
+
+	tests := []struct {
+		name        string
+		path        string
+		header      http.Header
+		wantMessage string // of the 401 response; "" if let through
+		wantSubject string // "" if let through without a principal
+	}{
+		{"API key", "/v1/orders", http.Header{"X-Api-Key": {"s3cret"}}, "", auth.APIKeySubject},
+		{"exempt path", "/health", nil, "", ""},
+		{"exempt path with credentials", "/ready", http.Header{"X-Api-Key": {"guess"}}, "", ""},
+		{"below an exempt path", "/health/deep", nil, "authentication required", ""},
+		{"no credentials", "/v1/orders", nil, "authentication required", ""},
+		{"wrong API key", "/v1/orders", http.Header{"X-Api-Key": {"guess"}}, "invalid credentials", ""},
+		{"malformed token", "/v1/orders", http.Header{"Authorization": {"Bearer not.a.jwt"}}, "invalid credentials", ""},
+		{"not a bearer token", "/v1/orders", http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}}, "invalid credentials", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest("GET", tt.path, nil)
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tt.wantMessage != "" {
+				var body apiError
+				if rec.Code != http.StatusUnauthorized || json.Unmarshal(rec.Body.Bytes(), &body) != nil {
+					t.Fatalf("status = %d (body %s), want 401", rec.Code, rec.Body)
+				}
+				if body.Code != 16 || body.Message != tt.wantMessage {
+					t.Errorf("error = %+v, want code 16 (UNAUTHENTICATED) with %q", body, tt.wantMessage)
+				}
+				if got := rec.Header().Get("WWW-Authenticate"); !strings.HasPrefix(got, "Bearer ") {
+					t.Errorf("WWW-Authenticate = %q, want a Bearer challenge", got)
+				}
+				return
+			}
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("status = %d (body %s), want the request let through", rec.Code, rec.Body)
+			}
+			var subject string
+			if principal != nil {
+				subject = principal.Subject
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("principal %q, want %q", subject, tt.wantSubject)
+			}
+		})
+	}
+
+	// Without credentials configured, everything is let through
+	rec := httptest.NewRecorder()
+	RequireAuth(auth.NewAuthenticator(), next).ServeHTTP(rec, httptest.NewRequest("GET", "/v1/orders", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status with authentication disabled = %d, want the request let through", rec.Code)
+	}
+}
+
+// TestUpdateStatusActor checks REST status changes record the authenticated
+// caller and reject a different actor.
+func TestUpdateStatusActor(t *testing.T) {
+	router, _ := newTestRouter(t)
+	handler := RequireAuth(auth.NewAuthenticator(auth.WithAPIKey("s3cret")), router)
+	call := func(method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "s3cret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call("POST", "/v1/orders", `{"customer_id": "CUST-1", "items": [{"product_id": "SKU-1", "product_name": "Widget", "quantity": 1, "price": {"currency_code": "USD", "units": "10"}}]}`)
+	var created struct {
+		Order struct {
+			ID string `json:"id"`
+		} `json:"order"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.Order.ID == "" {
+		t.Fatalf("create = %d %s, want an order", rec.Code, rec.Body)
+	}
+	target := "/v1/orders/" + created.Order.ID
+
+	if rec := call("PATCH", target+"/status", `{"status": "CONFIRMED", "actor": "someone-else"}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("status change for another actor = %d (body %s), want 403", rec.Code, rec.Body)
+	}
+	if rec := call("PATCH", target+"/status", `{"status": "CONFIRMED"}`); rec.Code != http.StatusOK {
+		t.Fatalf("status change = %d (body %s), want 200", rec.Code, rec.Body)
+	}
+
+	var history struct {
+		Events []struct {
+			Actor string `json:"actor"`
+		} `json:"events"`
+	}
+	rec = call("GET", target+"/history", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil || len(history.Events) != 1 || history.Events[0].Actor != auth.APIKeySubject {
+		t.Errorf("history = %s, want one change by %q", rec.Body, auth.APIKeySubject)
+	}
+}
@@ -0,0 +1,549 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"lab10/internal/domain"
+	"lab10/internal/service"
+	grpcTransport "lab10/internal/transport/grpc"
+	pb "lab10/proto/orders"
+)
+
+// Bulk formats for GET /v1/orders/export and POST /v1/orders/import.
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// csvColumns is the header of a CSV export. Each row is one line item; the
+// order columns repeat on every row of the order. Pricing columns are empty
+// for orders priced before the pricing pipeline existed.
+var csvColumns = []string{
+	"order_id", "customer_id", "status", "created_at", "updated_at", "deleted_at",
+	"coupon_code", "region", "currency", "subtotal", "discount_total", "tax", "total",
+	"product_id", "product_name", "quantity", "unit_price",
+}
+
+// csvRequiredColumns must be present in the header of a CSV import.
+var csvRequiredColumns = []string{"customer_id", "product_id", "quantity"}
+
+const (
+	// maxImportErrors caps the row errors listed in an import response; the
+	// failed count still covers every row.
+	maxImportErrors = 1000
+
+	// maxImportLine bounds one NDJSON line, i.e. one order.
+	maxImportLine = 1 << 20
+)
+
+// ImportResponse summarizes POST /v1/orders/import. Errors lists rejected
+// orders by the line of the file they start on. Error is set if the file
+// couldn't be read to the end; the counts cover the orders read until then.
+type ImportResponse struct {
+	Error           string           `json:"error,omitempty"`
+	DryRun          bool             `json:"dry_run"`
+	Total           int              `json:"total"`
+	Succeeded       int              `json:"succeeded"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportRowError `json:"errors,omitempty"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty"`
+}
+
+// ImportRowError describes why an imported order was rejected. Ref is the
+// order's order_id (CSV) or id (NDJSON) as given in the file, if any.
+// Error is the google.rpc.Status POST /v1/orders would have answered with.
+type ImportRowError struct {
+	Line  int             `json:"line"`
+	Ref   string          `json:"ref,omitempty"`
+	Error json.RawMessage `json:"error"`
+}
+
+// ExportOrders handles GET /v1/orders/export - streams all orders matching
+// the filter parameters of GET /v1/orders as CSV (format=csv) or NDJSON
+// (format=ndjson, the default: one Order per line, as GET /v1/orders/{id}
+// returns it), oldest first. Orders are read and written
+// a page at a time, so exports of any size use little memory. If reading
+// fails midway the connection is aborted, so clients never mistake a
+// partial export for a complete one.
+func (h *OrderHandler) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatNDJSON
+	}
+	if format != formatCSV && format != formatNDJSON {
+		respondError(w, invalidFormat(format))
+		return
+	}
+	// The status filter is also accepted as written in CSV exports, e.g. "pending"
+	query := r.URL.Query()
+	if v := query.Get("status"); v != "" {
+		query.Set("status", strings.ToUpper(v))
+	}
+	var req pb.ListOrdersRequest
+	if err := runtime.PopulateQueryParameters(&req, query, utilities.NewDoubleArray(nil)); err != nil {
+		respondError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	opts, err := grpcTransport.ProtoToListOptions(&req)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	// Large exports outlive the server's WriteTimeout, so lift the deadline
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		respondError(w, err)
+		return
+	}
+
+	var contentType, filename string
+	var begin, flush func() error
+	var write func(*domain.Order) error
+	switch format {
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		contentType, filename = "text/csv; charset=utf-8", "orders.csv"
+		begin = func() error { return cw.Write(csvColumns) }
+		write = func(order *domain.Order) error {
+			for _, row := range csvRows(order) {
+				if err := cw.Write(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		contentType, filename = "application/x-ndjson", "orders.ndjson"
+		begin = func() error { return nil }
+		write = func(order *domain.Order) error {
+			data, err := jsonOptions.Marshal(grpcTransport.OrderToProto(order))
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(data, '\n'))
+			return err
+		}
+		flush = func() error { return nil }
+	}
+
+	// Headers are only sent with the first order, so errors before it, such
+	// as a caller that may not export deleted orders, get a proper response.
+	// Once they are sent, errors can only be reported by aborting.
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		w.WriteHeader(http.StatusOK)
+		return begin()
+	}
+	err = h.service.ExportOrders(r.Context(), opts.Filter, func(order *domain.Order) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return write(order)
+	})
+	if err != nil && !started {
+		respondError(w, err)
+		return
+	}
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if !started {
+		if err := start(); err != nil {
+			panic(http.ErrAbortHandler)
+		}
+	}
+	if err := flush(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// csvRows renders an order as CSV rows, one per line item.
+func csvRows(order *domain.Order) [][]string {
+	deletedAt := ""
+	if order.DeletedAt != nil {
+		deletedAt = order.DeletedAt.UTC().Format(time.RFC3339Nano)
+	}
+	var subtotal, discountTotal, tax string
+	if p := order.Pricing; p != nil {
+		subtotal, discountTotal, tax = p.Subtotal.Decimal(), p.DiscountTotal.Decimal(), p.Tax.Decimal()
+	}
+	head := []string{
+		order.ID,
+		order.CustomerID,
+		string(order.Status),
+		order.CreatedAt.UTC().Format(time.RFC3339Nano),
+		order.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		deletedAt,
+		order.CouponCode,
+		order.Region,
+		order.TotalAmount.Currency,
+		subtotal,
+		discountTotal,
+		tax,
+		order.TotalAmount.Decimal(),
+	}
+
+	rows := make([][]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		row := append(head[:len(head):len(head)],
+			item.ProductID,
+			item.ProductName,
+			strconv.Itoa(item.Quantity),
+			item.UnitPrice.Decimal(),
+		)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// ImportOrders handles POST /v1/orders/import - creates orders from a CSV or
+// NDJSON body, checking each exactly like POST /v1/orders. The format is the
+// format query parameter, else taken from the Content-Type (text/csv or
+// application/x-ndjson). With dry_run=true orders are only validated.
+//
+// CSV needs a header row naming the columns, as written by the CSV export;
+// customer_id, product_id and quantity are required. Consecutive rows with
+// the same order_id are the line items of one order, a row without one is
+// an order by itself. NDJSON holds one order per line in the body format
+// of POST /v1/orders. In both, IDs in the file only identify orders in the
+// error report: the server assigns new IDs, and status, totals and
+// timestamps in the file are ignored, so exports can be imported again.
+//
+// The response is 200 with a summary whenever the file could be read; a
+// rejected order doesn't stop the others from being imported. If reading
+// fails partway, the summary of what was imported comes with a 400.
+func (h *OrderHandler) ImportOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	dryRun, err := parseBoolParam(q, "dry_run")
+	if err != nil {
+		respondError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	format := q.Get("format")
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+
+	var next func() (importRecord, error)
+	switch format {
+	case formatCSV:
+		reader, err := newCSVOrderReader(r.Body)
+		if err != nil {
+			respondError(w, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+		next = reader.next
+	case formatNDJSON:
+		next = newNDJSONOrderReader(r.Body).next
+	default:
+		respondError(w, invalidFormat(format))
+		return
+	}
+
+	// Bulk loads outlive the server's read and write timeouts, so lift them
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		respondError(w, err)
+		return
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		respondError(w, err)
+		return
+	}
+
+	resp := ImportResponse{DryRun: dryRun}
+	for r.Context().Err() == nil {
+		record, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			resp.Error = fmt.Sprintf("reading line %d: %v", record.line, err)
+			respondJSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		resp.Total++
+		err = record.err
+		if err == nil {
+			err = h.service.ImportOrder(r.Context(), record.order, dryRun)
+		}
+		if err == nil {
+			resp.Succeeded++
+			continue
+		}
+		resp.Failed++
+		if len(resp.Errors) == maxImportErrors {
+			resp.ErrorsTruncated = true
+			continue
+		}
+		rowError := ImportRowError{Line: record.line, Ref: record.ref}
+		if rowError.Error, err = jsonOptions.Marshal(errorStatus(err).Proto()); err != nil {
+			rowError.Error = json.RawMessage(`{"code": 13, "message": "failed to marshal error message"}`)
+		}
+		resp.Errors = append(resp.Errors, rowError)
+	}
+	if r.Context().Err() != nil {
+		return
+	}
+	respondJSON(w, resp, http.StatusOK)
+}
+
+// invalidFormat reports an unsupported export or import format.
+func invalidFormat(format string) error {
+	return status.Errorf(codes.InvalidArgument, "invalid format %q: must be csv or ndjson", format)
+}
+
+// importFormat picks the import format from a Content-Type header.
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return formatCSV
+	case "application/x-ndjson", "application/jsonl", "application/json", "":
+		return formatNDJSON
+	default:
+		return mediaType
+	}
+}
+
+// importRecord is one order read from an import file. err is set instead
+// of order if the order's lines couldn't be turned into an order.
+type importRecord struct {
+	line  int
+	ref   string
+	order *domain.Order
+	err   error
+}
+
+// invalidRecord reports a problem with a field of an imported order.
+func invalidRecord(field, description string) error {
+	return fmt.Errorf("%w: %w", service.ErrInvalidOrder, domain.NewFieldError(field, description))
+}
+
+// csvRow is one row of a CSV import. err is set instead of fields if the
+// row isn't well-formed CSV.
+type csvRow struct {
+	fields []string
+	line   int
+	err    error
+}
+
+// csvOrderReader reads orders from CSV, grouping consecutive rows with the
+// same order_id into one order.
+type csvOrderReader struct {
+	r       *csv.Reader
+	columns map[string]int
+
+	// pending is the row read ahead while looking for the end of an order.
+	pending *csvRow
+
+	// line is the last line read, for errors that have no position of their own.
+	line int
+}
+
+func newCSVOrderReader(body io.Reader) (*csvOrderReader, error) {
+	r := csv.NewReader(body)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("empty CSV: a header row is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	for _, name := range csvRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header is missing the %s column", name)
+		}
+	}
+	return &csvOrderReader{r: r, columns: columns}, nil
+}
+
+// field returns the value of the named column in row, or "" if the column
+// or the value is missing.
+func (c *csvOrderReader) field(row []string, name string) string {
+	i, ok := c.columns[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// read returns the next non-blank row. Malformed rows are returned with
+// their error set; the error result is only for failures to read at all.
+func (c *csvOrderReader) read() (csvRow, error) {
+	if c.pending != nil {
+		row := *c.pending
+		c.pending = nil
+		return row, nil
+	}
+	for {
+		fields, err := c.r.Read()
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			c.line = perr.Line
+			return csvRow{line: perr.StartLine, err: fmt.Errorf("%w: %v", service.ErrInvalidOrder, perr.Err)}, nil
+		}
+		if err != nil {
+			return csvRow{line: c.line + 1}, err
+		}
+		c.line, _ = c.r.FieldPos(len(fields) - 1)
+		if len(fields) == 1 && strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+		line, _ := c.r.FieldPos(0)
+		return csvRow{fields: fields, line: line}, nil
+	}
+}
+
+// next returns the next order.
+func (c *csvOrderReader) next() (importRecord, error) {
+	row, err := c.read()
+	if err != nil || row.err != nil {
+		return importRecord{line: row.line, err: row.err}, err
+	}
+
+	record := importRecord{line: row.line, ref: c.field(row.fields, "order_id")}
+	order := &domain.Order{
+		CustomerID: c.field(row.fields, "customer_id"),
+		CouponCode: c.field(row.fields, "coupon_code"),
+		Region:     c.field(row.fields, "region"),
+	}
+	for {
+		item, err := c.item(row.fields, len(order.Items))
+		if err != nil && record.err == nil {
+			record.err = err
+		}
+		order.Items = append(order.Items, item)
+
+		if record.ref == "" {
+			break
+		}
+		row, err = c.read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return importRecord{line: row.line}, err
+		}
+		if row.err != nil || c.field(row.fields, "order_id") != record.ref {
+			c.pending = &row
+			break
+		}
+	}
+	if record.err == nil {
+		record.order = order
+	}
+	return record, nil
+}
+
+// item parses the line item columns of row as the i-th item of its order.
+func (c *csvOrderReader) item(row []string, i int) (domain.LineItem, error) {
+	field := fmt.Sprintf("items[%d].", i)
+	item := domain.LineItem{
+		ProductID:   c.field(row, "product_id"),
+		ProductName: c.field(row, "product_name"),
+	}
+	quantity := c.field(row, "quantity")
+	n, err := strconv.Atoi(quantity)
+	if err != nil {
+		return item, invalidRecord(field+"quantity", fmt.Sprintf("invalid quantity %q", quantity))
+	}
+	item.Quantity = n
+
+	if price := c.field(row, "unit_price"); price != "" {
+		currency := c.field(row, "currency")
+		if currency == "" {
+			currency = domain.DefaultCurrency
+		}
+		if item.UnitPrice, err = domain.NewMoney(price, currency); err != nil {
+			return item, invalidRecord(field+"unit_price", err.Error())
+		}
+	}
+	return item, nil
+}
+
+// ndjsonOrderReader reads one order per line in the body format of POST
+// /v1/orders. Unknown fields are ignored, so exported orders can be read.
+type ndjsonOrderReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// ndjsonUnmarshal reads NDJSON import lines.
+var ndjsonUnmarshal = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+func newNDJSONOrderReader(body io.Reader) *ndjsonOrderReader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+	return &ndjsonOrderReader{scanner: scanner}
+}
+
+// next returns the next order. A line that isn't a valid order is reported
+// as a rejected record, and reading continues with the next line.
+func (n *ndjsonOrderReader) next() (importRecord, error) {
+	for n.scanner.Scan() {
+		n.line++
+		data := bytes.TrimSpace(n.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		record := importRecord{line: n.line}
+		var req pb.CreateOrderRequest
+		if err := ndjsonUnmarshal.Unmarshal(data, &req); err != nil {
+			record.err = fmt.Errorf("%w: invalid JSON: %v", service.ErrInvalidOrder, err)
+			return record, nil
+		}
+		record.ref = req.GetId()
+		order, err := grpcTransport.ProtoToNewOrder(&req)
+		if err != nil {
+			record.err = fmt.Errorf("%w: %s", service.ErrInvalidOrder, status.Convert(err).Message())
+			return record, nil
+		}
+		// The ID in the file only identifies the order in the error report
+		order.ID = ""
+		record.order = order
+		return record, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		return importRecord{line: n.line + 1}, err
+	}
+	return importRecord{}, io.EOF
+}
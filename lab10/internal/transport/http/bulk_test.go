@@ -0,0 +1,331 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lab10/internal/auth"
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+)
+
+// wantRecord is what an import reader should return for one order.
+type wantRecord struct {
+	line    int
+	ref     string
+	items   int
+	invalid bool
+}
+
+// readAll reads every record of an import reader and reports the error
+// that ended it, io.EOF if none.
+func readAll(next func() (importRecord, error)) ([]importRecord, importRecord, error) {
+	var records []importRecord
+	for {
+		record, err := next()
+		if err != nil {
+			return records, record, err
+		}
+		records = append(records, record)
+	}
+}
+
+func checkRecords(t *testing.T, got []importRecord, want []wantRecord) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("read %d records, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		r := got[i]
+		if r.line != w.line || r.ref != w.ref {
+			t.Errorf("record %d at line %d ref %q, want line %d ref %q", i, r.line, r.ref, w.line, w.ref)
+		}
+		if w.invalid {
+			if !errors.Is(r.err, service.ErrInvalidOrder) || r.order != nil {
+				t.Errorf("record %d = %+v, want an ErrInvalidOrder", i, r)
+			}
+			continue
+		}
+		if r.err != nil || r.order == nil || len(r.order.Items) != w.items {
+			t.Errorf("record %d = %+v, %v, want an order with %d items", i, r.order, r.err, w.items)
+		}
+	}
+}
+
+func TestExportOrdersIncludeDeletedForbidden(t *testing.T) {
+	router, _ := newTestRouter(t)
+	user := auth.NewContext(context.Background(), &auth.Principal{Subject: "alice", Method: auth.MethodJWT})
+
+	for _, format := range []string{"csv", "ndjson"} {
+		req := httptest.NewRequest("GET", "/v1/orders/export?include_deleted=true&format="+format, nil).WithContext(user)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s export of deleted orders status = %d, want 403 (body %s)", format, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("Content-Disposition"); got != "" {
+			t.Errorf("%s export error has Content-Disposition %q", format, got)
+		}
+	}
+
+	// An empty export still sends its headers
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/orders/export?format=csv", nil).WithContext(user))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" || rec.Body.Len() == 0 {
+		t.Errorf("empty CSV export = %d %q %q, want 200 with the header row", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+}
+
+func TestCSVOrderReader(t *testing.T) {
+	body := strings.Join([]string{
+		"Order_ID,customer_id,product_id,product_name,quantity,unit_price,currency",
+		"A,CUST-1,SKU-1,Widget,1,10.00,USD",
+		"A,CUST-1,SKU-2,Gadget,2,5.50,EUR",
+		"",
+		"B,CUST-2,SKU-1,Widget,3,,",
+		",CUST-3,SKU-1,Widget,1,,",
+		",CUST-3,SKU-2,Gadget,1,,",
+		"C,CUST-4,SKU-1,Widget,many,,",
+		"C,CUST-4,SKU-2,Gadget,1,,",
+		`D,CUST-5,SKU"1,Widget,1,,`,
+		"E,CUST-6,SKU-1,Widget,1,,",
+		`F,CUST-7,SKU-1,"Widget`,
+		`with a newline",1,,`,
+		"G,CUST-8,SKU-1,Widget,1,,",
+	}, "\n")
+	reader, err := newCSVOrderReader(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("newCSVOrderReader() error = %v", err)
+	}
+	records, _, err := readAll(reader.next)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("reading CSV error = %v, want io.EOF", err)
+	}
+	checkRecords(t, records, []wantRecord{
+		{line: 2, ref: "A", items: 2},
+		{line: 5, ref: "B", items: 1},
+		{line: 6, items: 1},
+		{line: 7, items: 1},
+		{line: 8, ref: "C", invalid: true},
+		{line: 10, invalid: true},
+		{line: 11, ref: "E", items: 1},
+		{line: 12, ref: "F", items: 1},
+		{line: 14, ref: "G", items: 1},
+	})
+
+	a := records[0].order
+	if a.CustomerID != "CUST-1" || a.Items[1].ProductID != "SKU-2" || a.Items[1].Quantity != 2 || a.Items[1].UnitPrice != domain.MustMoney("5.50", "EUR") {
+		t.Errorf("order A = %+v", a)
+	}
+	if b := records[1].order; b.Items[0].UnitPrice != (domain.Money{}) {
+		t.Errorf("order B without a price has unit price %v", b.Items[0].UnitPrice)
+	}
+	var verr *domain.ValidationError
+	if !errors.As(records[4].err, &verr) || verr.Violations[0].Field != "items[0].quantity" {
+		t.Errorf("order C error = %v, want a violation of items[0].quantity", records[4].err)
+	}
+}
+
+func TestCSVOrderReaderHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"empty", "", "header row is required"},
+		{"no product", "customer_id,quantity\nCUST-1,1\n", "missing the product_id column"},
+		{"no quantity", "customer_id,product_id\n", "missing the quantity column"},
+		{"byte order mark", "\ufeffcustomer_id,product_id,quantity\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newCSVOrderReader(strings.NewReader(tt.body))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("newCSVOrderReader() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newCSVOrderReader() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNDJSONOrderReader(t *testing.T) {
+	item := `"items": [{"product_id": "SKU-1", "quantity": 1, "price": {"currency_code": "USD", "units": "10"}}]`
+	body := strings.Join([]string{
+		`{"customer_id": "CUST-1", ` + item + `}`,
+		``,
+		`{"customer_id": "CUST-2", ` + item + `, "status": "ORDER_STATUS_SHIPPED"}`,
+		`{"customer_id": `,
+		`{"id": "ORD-1", "customer_id": "CUST-3", ` + item + `}`,
+		`{"customer_id": "CUST-4", "note": "` + strings.Repeat("x", maxImportLine) + `"}`,
+		`{"customer_id": "CUST-5", ` + item + `}`,
+	}, "\n")
+
+	records, last, err := readAll(newNDJSONOrderReader(strings.NewReader(body)).next)
+	if !errors.Is(err, bufio.ErrTooLong) || last.line != 6 {
+		t.Fatalf("reading an over-long line = line %d, %v; want line 6, bufio.ErrTooLong", last.line, err)
+	}
+	checkRecords(t, records, []wantRecord{
+		{line: 1, items: 1},
+		{line: 3, items: 1},
+		{line: 4, invalid: true},
+		{line: 5, ref: "ORD-1", items: 1},
+	})
+	if order := records[3].order; order.ID != "" || order.CustomerID != "CUST-3" {
+		t.Errorf("order ORD-1 = ID %q customer %q, want no ID and CUST-3", order.ID, order.CustomerID)
+	}
+}
+
+// importOrders posts body to the import endpoint and decodes the summary.
+func importOrders(t *testing.T, router http.Handler, query, contentType, body string) (int, ImportResponse) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/v1/orders/import"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	var resp ImportResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("import response %s: %v", rec.Body, err)
+	}
+	return rec.Code, resp
+}
+
+func listAll(t *testing.T, repo *repository.MemoryRepository) []*domain.Order {
+	t.Helper()
+	result, err := repo.List(context.Background(), repository.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	return result.Orders
+}
+
+func TestImportOrders(t *testing.T) {
+	router, repo := newTestRouter(t)
+	body := strings.Join([]string{
+		"order_id,customer_id,product_id,product_name,quantity,unit_price",
+		"A,CUST-1,SKU-1,Widget,1,10.00",
+		"B,,SKU-1,Widget,1,10.00",
+		`C,CUST-2,SKU"1,Widget,1,10.00`,
+		"D,CUST-3,SKU-1,Widget,2,10.00",
+	}, "\n")
+
+	code, resp := importOrders(t, router, "?dry_run=true", "text/csv", body)
+	if code != http.StatusOK || !resp.DryRun || resp.Total != 4 || resp.Succeeded != 2 || resp.Failed != 2 {
+		t.Fatalf("dry run = %d %+v, want 200 with 2 of 4 orders", code, resp)
+	}
+	if orders := listAll(t, repo); len(orders) != 0 {
+		t.Fatalf("dry run created %d orders", len(orders))
+	}
+	wantErrors := []struct {
+		line int
+		ref  string
+	}{{3, "B"}, {4, ""}}
+	for i, want := range wantErrors {
+		got := resp.Errors[i]
+		var st apiError
+		if err := json.Unmarshal(got.Error, &st); err != nil || st.Code != 3 {
+			t.Errorf("error %d = %s, want an INVALID_ARGUMENT status", i, got.Error)
+		}
+		if got.Line != want.line || got.Ref != want.ref {
+			t.Errorf("error %d at line %d ref %q, want line %d ref %q", i, got.Line, got.Ref, want.line, want.ref)
+		}
+	}
+
+	code, resp = importOrders(t, router, "", "text/csv", body)
+	if code != http.StatusOK || resp.DryRun || resp.Succeeded != 2 {
+		t.Fatalf("import = %d %+v, want 200 with 2 orders", code, resp)
+	}
+	if orders := listAll(t, repo); len(orders) != 2 {
+		t.Fatalf("import created %d orders, want 2", len(orders))
+	}
+}
+
+func TestImportOrdersLimits(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	body := strings.Repeat("{}\n", maxImportErrors+1)
+	code, resp := importOrders(t, router, "?dry_run=true", "application/x-ndjson", body)
+	if code != http.StatusOK || resp.Failed != maxImportErrors+1 || len(resp.Errors) != maxImportErrors || !resp.ErrorsTruncated {
+		t.Errorf("import of %d bad orders = %d, failed %d, %d errors, truncated %t; want all failed, %d errors listed and truncated",
+			maxImportErrors+1, code, resp.Failed, len(resp.Errors), resp.ErrorsTruncated, maxImportErrors)
+	}
+
+	body = `{"customer_id": "CUST-1", "items": [{"product_id": "SKU-1", "product_name": "Widget", "quantity": 1, "price": {"currency_code": "USD", "units": "10"}}]}` +
+		"\n" + strings.Repeat(" ", maxImportLine+1)
+	code, resp = importOrders(t, router, "", "application/x-ndjson", body)
+	if code != http.StatusBadRequest || resp.Succeeded != 1 || !strings.HasPrefix(resp.Error, "reading line 2") {
+		t.Errorf("import with an over-long line = %d %+v, want 400 reading line 2 after 1 order", code, resp)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	source, sourceRepo := newTestRouter(t)
+	totals := map[string]domain.Money{
+		"CUST-1": domain.MustMoney("11.24", "EUR"),
+		"CUST-2": domain.MustMoney("21.49", "EUR"),
+		"CUST-3": domain.MustMoney("31.74", "EUR"),
+	}
+	for i := 1; i <= 3; i++ {
+		customerID := fmt.Sprintf("CUST-%d", i)
+		order := &domain.Order{
+			ID:         fmt.Sprintf("ORD-%d", i),
+			CustomerID: customerID,
+			Region:     "EU",
+			Items: []domain.LineItem{
+				{ProductID: "SKU-1", ProductName: "Widget, large", Quantity: i, UnitPrice: domain.MustMoney("10.25", "EUR")},
+				{ProductID: "SKU-2", ProductName: `Gadget "pro"`, Quantity: 1, UnitPrice: domain.MustMoney("0.99", "EUR")},
+			},
+			TotalAmount: totals[customerID],
+			Status:      domain.StatusPending,
+		}
+		if err := sourceRepo.Create(context.Background(), order); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	source.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/orders/export?format=csv", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d (body %s)", rec.Code, rec.Body)
+	}
+
+	target, targetRepo := newTestRouter(t)
+	code, resp := importOrders(t, target, "?format=csv", "", rec.Body.String())
+	if code != http.StatusOK || resp.Succeeded != 3 || resp.Failed != 0 {
+		t.Fatalf("import of export = %d %+v, want 3 orders", code, resp)
+	}
+
+	imported := make(map[string]*domain.Order)
+	for _, order := range listAll(t, targetRepo) {
+		imported[order.CustomerID] = order
+	}
+	for _, want := range listAll(t, sourceRepo) {
+		got, ok := imported[want.CustomerID]
+		if !ok {
+			t.Errorf("order of %s not imported", want.CustomerID)
+			continue
+		}
+		if got.ID == want.ID || got.Region != want.Region || got.TotalAmount != want.TotalAmount || len(got.Items) != len(want.Items) {
+			t.Errorf("imported %+v, want a copy of %+v with a new ID", got, want)
+			continue
+		}
+		for i := range want.Items {
+			if got.Items[i] != want.Items[i] {
+				t.Errorf("order of %s item %d = %+v, want %+v", want.CustomerID, i, got.Items[i], want.Items[i])
+			}
+		}
+	}
+}
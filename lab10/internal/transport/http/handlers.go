@@ -3,179 +3,132 @@ package http
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 
-	commonhttp "golang-for-java-developers-training/common/http"
-	"lab10/internal/domain"
-	"lab10/internal/repository"
 	"lab10/internal/service"
+	"lab10/internal/transport/gateway"
+	grpcTransport "lab10/internal/transport/grpc"
 )
 
-// OrderHandler handles HTTP requests for order operations.
+// OrderHandler handles the HTTP endpoints of the order API that the REST
+// gateway can't serve: the Server-Sent Events stream and the bulk export
+// and import. Everything else is served from the annotations in
+// orders.proto (see package gateway).
 // Transport layer - focuses on HTTP concerns (request/response handling).
 // Delegates business logic to the service layer.
 type OrderHandler struct {
 	service *service.OrderService
+
+	// done is closed by Shutdown to end open WatchOrders streams
+	done         chan struct{}
+	shutdownOnce sync.Once
 }
 
+// watchKeepAlive is how often an idle WatchOrders stream sends a comment
+// line, so proxies don't close it and dead clients are noticed.
+const watchKeepAlive = 15 * time.Second
+
+// jsonOptions are the JSON options of the REST gateway, so that orders,
+// events and errors written here look the same as the ones it writes.
+var jsonOptions = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
 // NewOrderHandler creates a new HTTP handler with injected service.
 func NewOrderHandler(service *service.OrderService) *OrderHandler {
 	return &OrderHandler{
 		service: service,
+		done:    make(chan struct{}),
 	}
 }
 
-// CreateOrderRequest represents the JSON structure for creating orders.
-type CreateOrderRequest struct {
-	ID         string            `json:"id"`
-	CustomerID string            `json:"customer_id"`
-	Items      []domain.LineItem `json:"items"`
+// Shutdown ends all open WatchOrders streams. Register it with
+// http.Server.RegisterOnShutdown, since Shutdown doesn't close them itself.
+func (h *OrderHandler) Shutdown() {
+	h.shutdownOnce.Do(func() { close(h.done) })
 }
 
-// UpdateStatusRequest represents the JSON structure for status updates.
-type UpdateStatusRequest struct {
-	Status domain.OrderStatus `json:"status"`
-}
-
-// CreateOrder handles POST /orders - creates a new order.
-func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var req CreateOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	order := &domain.Order{
-		ID:         req.ID,
-		CustomerID: req.CustomerID,
-		Items:      req.Items,
-	}
-
-	if err := h.service.CreateOrder(r.Context(), order); err != nil {
-		if errors.Is(err, service.ErrInvalidOrder) {
-			respondError(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		if errors.Is(err, repository.ErrAlreadyExists) {
-			respondError(w, "Order already exists", http.StatusConflict)
-			return
-		}
-		respondError(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	respondJSON(w, order, http.StatusCreated)
-}
-
-// GetOrder handles GET /orders/{id} - retrieves an order by ID.
-func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract ID from URL path (simple path parsing)
-	id := r.URL.Path[len("/orders/"):]
-	if id == "" {
-		respondError(w, "Order ID required", http.StatusBadRequest)
-		return
-	}
-
-	order, err := h.service.GetOrder(r.Context(), id)
+// WatchOrders handles GET /v1/orders/watch - streams order events as
+// Server-Sent Events. Optional customer_id and order_id query parameters
+// filter the stream. Each event's id is the event ID and its data is the
+// OrderEvent message in the JSON form of the REST API.
+func (h *OrderHandler) WatchOrders(w http.ResponseWriter, r *http.Request) {
+	events, err := h.service.WatchOrders(r.Context(), service.WatchFilter{
+		CustomerID: r.URL.Query().Get("customer_id"),
+		OrderID:    r.URL.Query().Get("order_id"),
+	})
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			respondError(w, "Order not found", http.StatusNotFound)
-			return
-		}
-		respondError(w, "Internal server error", http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	respondJSON(w, order, http.StatusOK)
-}
-
-// ListOrders handles GET /orders - retrieves all orders.
-func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	// The stream outlives the server's WriteTimeout, so lift the deadline
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		respondError(w, err)
 		return
 	}
 
-	orders, err := h.service.ListOrders(r.Context())
-	if err != nil {
-		respondError(w, "Internal server error", http.StatusInternalServerError)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
 		return
 	}
 
-	respondJSON(w, orders, http.StatusOK)
-}
-
-// UpdateOrderStatus handles PATCH /orders/{id}/status - updates order status.
-func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPatch {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+	keepAlive := time.NewTicker(watchKeepAlive)
+	defer keepAlive.Stop()
 
-	// Extract ID from URL path
-	id := r.URL.Path[len("/orders/"):]
-	id = id[:len(id)-len("/status")]
-	if id == "" {
-		respondError(w, "Order ID required", http.StatusBadRequest)
-		return
-	}
-
-	var req UpdateStatusRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	if err := h.service.UpdateOrderStatus(r.Context(), id, req.Status); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			respondError(w, "Order not found", http.StatusNotFound)
+	for {
+		select {
+		case <-h.done:
 			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				// Closed with the request still active: the client fell behind
+				// and missed events, so tell it to re-read state and reconnect
+				if r.Context().Err() == nil {
+					fmt.Fprint(w, "event: overflow\ndata: {}\n\n")
+					rc.Flush()
+				}
+				return
+			}
+			data, err := jsonOptions.Marshal(grpcTransport.EventToProto(event))
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
 		}
-		if errors.Is(err, service.ErrInvalidStatusTransition) {
-			respondError(w, err.Error(), http.StatusBadRequest)
+		if err := rc.Flush(); err != nil {
 			return
 		}
-		respondError(w, "Internal server error", http.StatusInternalServerError)
-		return
 	}
-
-	w.WriteHeader(http.StatusNoContent)
 }
 
-// DeleteOrder handles DELETE /orders/{id} - deletes an order.
-func (h *OrderHandler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract ID from URL path
-	id := r.URL.Path[len("/orders/"):]
-	if id == "" {
-		respondError(w, "Order ID required", http.StatusBadRequest)
-		return
+// parseBoolParam parses an optional boolean query parameter.
+func parseBoolParam(q url.Values, name string) (bool, error) {
+	v := q.Get(name)
+	if v == "" {
+		return false, nil
 	}
-
-	if err := h.service.DeleteOrder(r.Context(), id); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			respondError(w, "Order not found", http.StatusNotFound)
-			return
-		}
-		respondError(w, "Internal server error", http.StatusInternalServerError)
-		return
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: must be true or false", name, v)
 	}
-
-	w.WriteHeader(http.StatusNoContent)
+	return b, nil
 }
 
 // respondJSON writes a JSON response with the given status code.
@@ -185,7 +138,24 @@ func respondJSON(w http.ResponseWriter, data interface{}, statusCode int) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// respondError writes an error response in JSON format.
-func respondError(w http.ResponseWriter, message string, statusCode int) {
-	respondJSON(w, commonhttp.ErrorResponse{Error: message}, statusCode)
+// errorStatus returns the gRPC status of err: err's own if it has one,
+// else the one the gRPC API answers the service error with.
+func errorStatus(err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	return status.Convert(grpcTransport.MapServiceError(err))
+}
+
+// respondError writes err the way the REST gateway does: as RFC 7807
+// problem details holding its google.rpc.Status, with the HTTP status the
+// gateway uses for it.
+func respondError(w http.ResponseWriter, err error) {
+	st := errorStatus(err)
+	respondStatus(w, st, gateway.HTTPStatus(st))
+}
+
+// respondStatus writes st as problem details with the given status code.
+func respondStatus(w http.ResponseWriter, st *status.Status, statusCode int) {
+	gateway.WriteProblem(w, st, statusCode)
 }
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewRouter returns the handler for the REST API: the streaming and bulk
+// endpoints of orders and, for every pattern in apiPatterns (see
+// gateway.Patterns), the api handler. Requests are routed by method and
+// path, so handlers can rely on both and read path parameters with
+// r.PathValue; IDs containing a slash must be sent escaped (%2F). Paths that
+// match no route get a JSON 404, and known paths requested with the wrong
+// method a JSON 405 with an Allow header, in the error format of the api.
+func NewRouter(orders *OrderHandler, api http.Handler, apiPatterns []string) http.Handler {
+	mux := http.NewServeMux()
+
+	// Like the api routes, these are also served at their unversioned path
+	for _, prefix := range []string{"/v1", ""} {
+		mux.HandleFunc("GET "+prefix+"/orders/watch", orders.WatchOrders)
+		mux.HandleFunc("GET "+prefix+"/orders/export", orders.ExportOrders)
+		mux.HandleFunc("POST "+prefix+"/orders/import", orders.ImportOrders)
+	}
+
+	// GET patterns also match HEAD requests, which the gateway doesn't
+	// serve: pass them on as GET, the server drops the response body
+	apiHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			r = r.Clone(r.Context())
+			r.Method = http.MethodGet
+		}
+		api.ServeHTTP(w, r)
+	})
+	for _, pattern := range apiPatterns {
+		mux.Handle(pattern, apiHandler)
+	}
+
+	return router{mux: mux}
+}
+
+// router serves requests from mux, answering unrouted ones in the same JSON
+// error format as the handlers and the REST gateway instead of ServeMux's
+// plain text.
+type router struct {
+	mux *http.ServeMux
+}
+
+func (rt router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// An empty pattern means no route: the mux's handler will answer 404 or,
+	// if only the method is wrong, 405 after setting the Allow header
+	if _, pattern := rt.mux.Handler(r); pattern == "" {
+		w = &routeErrorWriter{ResponseWriter: w}
+	}
+	rt.mux.ServeHTTP(w, r)
+}
+
+// routeErrorWriter replaces the plain text body of the mux's 404 and 405
+// responses with a JSON error. Headers set before, like Allow, are kept.
+type routeErrorWriter struct {
+	http.ResponseWriter
+	replaced bool
+}
+
+func (w *routeErrorWriter) WriteHeader(statusCode int) {
+	switch statusCode {
+	case http.StatusNotFound:
+		respondStatus(w.ResponseWriter, status.New(codes.NotFound, http.StatusText(statusCode)), statusCode)
+	case http.StatusMethodNotAllowed:
+		respondStatus(w.ResponseWriter, status.New(codes.Unimplemented, http.StatusText(statusCode)), statusCode)
+	default:
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	w.replaced = true
+}
+
+func (w *routeErrorWriter) Write(data []byte) (int, error) {
+	if w.replaced {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
@@ -0,0 +1,185 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lab10/internal/domain"
+	"lab10/internal/repository"
+	"lab10/internal/service"
+	"lab10/internal/transport/gateway"
+	grpcTransport "lab10/internal/transport/grpc"
+)
+
+// newTestRouter returns the router of the server, with the REST gateway
+// behind it, over an empty in-memory repository.
+func newTestRouter(t *testing.T) (http.Handler, *repository.MemoryRepository) {
+	t.Helper()
+	repo := repository.NewMemoryRepository()
+	orders := service.NewOrderService(repo)
+	api, err := gateway.NewHandler(context.Background(),
+		grpcTransport.NewOrderServer(orders),
+		grpcTransport.NewCustomerServer(service.NewCustomerService(repo, orders)),
+		grpcTransport.NewProductServer(service.NewCatalogService(repo)),
+	)
+	if err != nil {
+		t.Fatalf("gateway.NewHandler() error = %v", err)
+	}
+	patterns, err := gateway.Patterns()
+	if err != nil {
+		t.Fatalf("gateway.Patterns() error = %v", err)
+	}
+	return NewRouter(NewOrderHandler(orders), api, patterns), repo
+}
+
+// apiError holds the google.rpc.Status members of the REST API's problem
+// details error body.
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func TestRouterErrors(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		wantStatus  int
+		wantAllow   string
+		wantMessage string
+	}{
+		{"unknown path", "GET", "/v1/nothing", http.StatusNotFound, "", "Not Found"},
+		{"trailing slash", "GET", "/v1/orders/", http.StatusNotFound, "", "Not Found"},
+		{"trailing slash after ID", "GET", "/v1/orders/x/history/", http.StatusNotFound, "", "Not Found"},
+		{"too deep", "GET", "/v1/orders/x/history/more", http.StatusNotFound, "", "Not Found"},
+		{"unknown order", "GET", "/v1/orders/x", http.StatusNotFound, "", "order not found"},
+		{"status with GET", "GET", "/v1/orders/x/status", http.StatusMethodNotAllowed, "PATCH", "Method Not Allowed"},
+		{"status with POST", "POST", "/v1/orders/x/status", http.StatusMethodNotAllowed, "PATCH", "Method Not Allowed"},
+		{"collection with PUT", "PUT", "/v1/orders", http.StatusMethodNotAllowed, "GET, HEAD, POST", "Method Not Allowed"},
+		{"export with POST", "POST", "/v1/orders/export", http.StatusMethodNotAllowed, "DELETE, GET, HEAD, PATCH", "Method Not Allowed"},
+		{"import with GET", "GET", "/v1/orders/import", http.StatusNotFound, "", "order not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want application/problem+json", got)
+			}
+			var body apiError
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %q is not a JSON error: %v", rec.Body, err)
+			}
+			if body.Message != tt.wantMessage || body.Code == 0 {
+				t.Errorf("body = %+v, want message %q and a non-zero code", body, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestRouterEncodedSlash(t *testing.T) {
+	router, repo := newTestRouter(t)
+
+	// Orders created before IDs were validated may contain a slash
+	order := &domain.Order{
+		ID:         "legacy/1",
+		CustomerID: "CUST-001",
+		Items:      []domain.LineItem{{ProductID: "SKU-1", ProductName: "Widget", Quantity: 1, UnitPrice: domain.MustMoney("10.00", "USD")}},
+		Status:     domain.StatusPending,
+	}
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	for _, target := range []string{"/v1/orders/legacy%2F1", "/v1/orders/legacy%2f1/history"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want 200 (body %s)", target, rec.Code, rec.Body)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/orders/legacy%2F1", nil))
+	var resp struct {
+		Order struct {
+			ID string `json:"id"`
+		} `json:"order"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Order.ID != "legacy/1" {
+		t.Errorf("GET order = %s, %v, want order legacy/1", rec.Body, err)
+	}
+
+	// Unescaped, the slash separates path segments
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/orders/legacy/1", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /v1/orders/legacy/1 status = %d, want 404", rec.Code)
+	}
+}
+
+func TestRouterHead(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("HEAD", "/v1/orders", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("HEAD /v1/orders status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+}
+
+// TestRouterUnversionedPaths checks the API is also served without the /v1
+// prefix, for clients written before it existed.
+func TestRouterUnversionedPaths(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	rec := httptest.NewRecorder()
+	body := `{"customer_id": "CUST-1", "items": [{"product_id": "SKU-1", "product_name": "Widget", "quantity": 1, "price": {"currency_code": "USD", "units": "10"}}]}`
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/orders", strings.NewReader(body)))
+	var created struct {
+		Order struct {
+			ID string `json:"id"`
+		} `json:"order"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.Order.ID == "" {
+		t.Fatalf("POST /orders = %d %s, want an order", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{"/orders", http.StatusOK},
+		{"/orders/" + created.Order.ID, http.StatusOK},
+		{"/orders/" + created.Order.ID + "/history", http.StatusOK},
+		{"/orders/export", http.StatusOK},
+		{"/products", http.StatusOK},
+		// Routed to the API, which doesn't know the customer
+		{"/customers/CUST-1", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", tt.target, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("GET %s status = %d, want %d (body %s)", tt.target, rec.Code, tt.wantStatus, rec.Body)
+		}
+	}
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/customers/CUST-1", nil))
+	if !strings.Contains(rec.Body.String(), "customer not found") {
+		t.Errorf("GET /customers/CUST-1 = %s, want the API's customer not found error", rec.Body)
+	}
+}